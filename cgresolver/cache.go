@@ -0,0 +1,102 @@
+package cgresolver
+
+import (
+	"strconv"
+	"sync"
+)
+
+// Cache resolves cgroup paths for a process's subsystems, reading and
+// parsing /proc/self/mountinfo, /proc/cgroups and /proc/<pid>/cgroup at most
+// once (until Invalidate is called), rather than on every call like
+// SelfSubsystemPath and PIDSubsystemPath.
+// It's intended to be shared across the resolutions for a single query; it
+// does not notice changes to mounts or cgroup membership on its own.
+// A Cache is safe for concurrent use.
+type Cache struct {
+	mu  sync.Mutex
+	src cachingCGSource
+}
+
+// NewCache constructs a Cache for the current process.
+func NewCache() *Cache {
+	return newCache(osCGSource{}, "self")
+}
+
+// NewPIDCache constructs a Cache for the specified PID.
+func NewPIDCache(pid int) *Cache {
+	return newCache(osCGSource{}, strconv.Itoa(pid))
+}
+
+func newCache(src cgSource, procSubDir string) *Cache {
+	return &Cache{src: cachingCGSource{src: src, procSubDir: procSubDir}}
+}
+
+// Resolve returns a CGroupPath for the cgroup associated with a specific
+// subsystem, following the same logic as SelfSubsystemPath.
+func (c *Cache) Resolve(subsystem string) (CGroupPath, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return resolveSubsystemPath(&c.src, c.src.procSubDir, subsystem)
+}
+
+// Invalidate discards any cached procfs contents, so they'll be re-read on
+// the next call to Resolve. (e.g. after a mount change)
+func (c *Cache) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.src.invalidate()
+}
+
+// cachingCGSource wraps another cgSource, memoizing successful results.
+// Each file is only read the first time it's needed, so the cgroup v2-only
+// fast path still skips /proc/cgroups.
+type cachingCGSource struct {
+	src        cgSource
+	procSubDir string
+
+	// the have* fields track whether the corresponding field has been
+	// populated (since nil is a legitimate result)
+	subsystems     []CGroupSubsystem
+	haveSubsystems bool
+	hiers          []CGProcHierarchy
+	haveHiers      bool
+	mounts         []Mount
+	haveMounts     bool
+}
+
+func (c *cachingCGSource) cgSubsystems() ([]CGroupSubsystem, error) {
+	if !c.haveSubsystems {
+		subsystems, err := c.src.cgSubsystems()
+		if err != nil {
+			return nil, err
+		}
+		c.subsystems, c.haveSubsystems = subsystems, true
+	}
+	return c.subsystems, nil
+}
+
+func (c *cachingCGSource) procCGroups(string) ([]CGProcHierarchy, error) {
+	if !c.haveHiers {
+		hiers, err := c.src.procCGroups(c.procSubDir)
+		if err != nil {
+			return nil, err
+		}
+		c.hiers, c.haveHiers = hiers, true
+	}
+	return c.hiers, nil
+}
+
+func (c *cachingCGSource) cgMounts() ([]Mount, error) {
+	if !c.haveMounts {
+		mounts, err := c.src.cgMounts()
+		if err != nil {
+			return nil, err
+		}
+		c.mounts, c.haveMounts = mounts, true
+	}
+	return c.mounts, nil
+}
+
+func (c *cachingCGSource) invalidate() {
+	*c = cachingCGSource{src: c.src, procSubDir: c.procSubDir}
+}
@@ -0,0 +1,152 @@
+package cgresolver
+
+import "testing"
+
+func TestCacheResolve(t *testing.T) {
+	src := fakeCGSource{
+		procCgroups:   testHybridProcCgroups,
+		procPidCgroup: testHybridProcPidCgroup,
+		mountinfo:     testHybridMountinfo,
+	}
+	c := newCache(&src, "self")
+
+	for _, tbl := range []struct {
+		subsystem string
+		expPath   CGroupPath
+	}{
+		{
+			subsystem: "memory",
+			expPath: CGroupPath{
+				AbsPath:   "/sys/fs/cgroup/memory/foo/bar",
+				MountPath: "/sys/fs/cgroup/memory",
+				Mode:      CGModeV1,
+			},
+		},
+		{
+			subsystem: "cpu",
+			expPath: CGroupPath{
+				AbsPath:   "/sys/fs/cgroup/cpu",
+				MountPath: "/sys/fs/cgroup/cpu",
+				Mode:      CGModeV1,
+			},
+		},
+		{
+			subsystem: "memory",
+			expPath: CGroupPath{
+				AbsPath:   "/sys/fs/cgroup/memory/foo/bar",
+				MountPath: "/sys/fs/cgroup/memory",
+				Mode:      CGModeV1,
+			},
+		},
+	} {
+		p, err := c.Resolve(tbl.subsystem)
+		if err != nil {
+			t.Fatalf("failed to resolve %q: %s", tbl.subsystem, err)
+		}
+		if p != tbl.expPath {
+			t.Errorf("unexpected CGroupPath for %q:\n  got %+v\n want %+v", tbl.subsystem, p, tbl.expPath)
+		}
+	}
+	if src.reads != 3 {
+		t.Errorf("unexpected number of reads: %d; expected 3", src.reads)
+	}
+
+	c.Invalidate()
+	if _, err := c.Resolve("memory"); err != nil {
+		t.Fatalf("failed to resolve after invalidation: %s", err)
+	}
+	if src.reads != 6 {
+		t.Errorf("unexpected number of reads after invalidation: %d; expected 6", src.reads)
+	}
+
+	if _, err := c.Resolve("nonexistent"); err == nil {
+		t.Error("expected error for unknown subsystem")
+	}
+}
+
+func TestCacheResolveV2OnlyFastPath(t *testing.T) {
+	src := fakeCGSource{
+		procCgroups:   testV2OnlyProcCgroups,
+		procPidCgroup: testV2OnlyProcPidCgroup,
+		mountinfo:     testV2OnlyMountinfo,
+	}
+	c := newCache(&src, "self")
+	for _, subsystem := range []string{"memory", "cpu", "pids"} {
+		if _, err := c.Resolve(subsystem); err != nil {
+			t.Fatalf("failed to resolve %q: %s", subsystem, err)
+		}
+	}
+	// /proc/cgroups is never consulted on the fast path
+	if src.reads != 2 {
+		t.Errorf("unexpected number of reads: %d; expected 2", src.reads)
+	}
+}
+
+func TestCacheInvalidatePicksUpChanges(t *testing.T) {
+	src := fakeCGSource{
+		procCgroups:   testV2OnlyProcCgroups,
+		procPidCgroup: testV2OnlyProcPidCgroup,
+		mountinfo:     testV2OnlyMountinfo,
+	}
+	c := newCache(&src, "self")
+
+	before, err := c.Resolve("memory")
+	if err != nil {
+		t.Fatalf("failed to resolve: %s", err)
+	}
+	if before.AbsPath != "/sys/fs/cgroup/user.slice/user-1000.slice/session-2.scope" {
+		t.Errorf("unexpected initial path: %q", before.AbsPath)
+	}
+
+	// move the process to another cgroup
+	src.procPidCgroup = "0::/system.slice/foo.service\n"
+
+	if stale, err := c.Resolve("memory"); err != nil || stale != before {
+		t.Errorf("expected cached path %+v before invalidation; got %+v (err: %v)", before, stale, err)
+	}
+
+	c.Invalidate()
+	after, err := c.Resolve("memory")
+	if err != nil {
+		t.Fatalf("failed to resolve after invalidation: %s", err)
+	}
+	if after.AbsPath != "/sys/fs/cgroup/system.slice/foo.service" {
+		t.Errorf("unexpected path after invalidation: %q", after.AbsPath)
+	}
+}
+
+func BenchmarkCacheResolve(b *testing.B) {
+	src := fakeCGSource{
+		procCgroups:   testHybridProcCgroups,
+		procPidCgroup: testHybridProcPidCgroup,
+		mountinfo:     testHybridMountinfo,
+	}
+	// resolve the subsystems GetCgroupCPUStats and GetCgroupMemoryStats
+	// need under cgroup v1
+	subsystems := []string{"cpu", "cpuacct", "memory"}
+	b.Run("uncached", func(b *testing.B) {
+		src := src
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for _, ss := range subsystems {
+				if _, err := resolveSubsystemPath(&src, "self", ss); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+		b.ReportMetric(float64(src.reads)/float64(b.N), "reads/op")
+	})
+	b.Run("cached", func(b *testing.B) {
+		src := src
+		c := newCache(&src, "self")
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for _, ss := range subsystems {
+				if _, err := c.Resolve(ss); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+		b.ReportMetric(float64(src.reads)/float64(b.N), "reads/op")
+	})
+}
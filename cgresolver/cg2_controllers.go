@@ -0,0 +1,98 @@
+package cgresolver
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNoCGroup2Mount indicates that no cgroup2 (unified hierarchy) mount is
+// present in the current mount namespace, so cgroup v2 functionality is not
+// supported (usually a legacy cgroup v1-only host).
+var ErrNoCGroup2Mount = errors.New("no cgroup2 mount present in the current mount namespace")
+
+// ErrNotCGroupV2 indicates that a cgroup v2-only operation was attempted on
+// a CGroupPath that isn't in the unified hierarchy.
+var ErrNotCGroupV2 = errors.New("cgroup is not in the cgroup v2 unified hierarchy")
+
+const (
+	cgroupV2ControllersFile    = "cgroup.controllers"
+	cgroupV2SubtreeControlFile = "cgroup.subtree_control"
+)
+
+// cgroup2RootMount returns the first cgroup2 mount whose root is within our
+// cgroup namespace.
+func cgroup2RootMount(mounts []Mount) (Mount, error) {
+	for _, mp := range mounts {
+		// Skip any mountpoints originating outside our cgroup namespace
+		// (see the comment in CGProcHierarchy.cgPath)
+		if !mp.CGroupV2 || strings.HasPrefix(mp.Root, "/..") {
+			continue
+		}
+		return mp, nil
+	}
+	return Mount{}, ErrNoCGroup2Mount
+}
+
+// RootControllers reads the cgroup.controllers file at the root of the
+// cgroup2 mount, returning the names of all controllers the kernel makes
+// available for delegation in the unified hierarchy.
+// On hosts without a cgroup2 mount (v1-only), it returns an error wrapping
+// ErrNoCGroup2Mount.
+// Note: on hybrid hosts, controllers bound to a v1 hierarchy are not
+// available to the unified hierarchy, so they will not be listed.
+func RootControllers() ([]string, error) {
+	mounts, mountsErr := CGroupMountInfo()
+	if mountsErr != nil {
+		return nil, fmt.Errorf("failed to parse mountinfo: %w", mountsErr)
+	}
+	cg2Mnt, mntErr := cgroup2RootMount(mounts)
+	if mntErr != nil {
+		return nil, fmt.Errorf("unable to resolve cgroup2 root controllers: %w", mntErr)
+	}
+	return readControllersFile(filepath.Join(cg2Mnt.Mountpoint, cgroupV2ControllersFile))
+}
+
+func readControllersFile(path string) ([]string, error) {
+	contents, readErr := os.ReadFile(path)
+	if readErr != nil {
+		return nil, fmt.Errorf("failed to read %q: %w", path, readErr)
+	}
+	return parseControllersList(string(contents)), nil
+}
+
+// parseControllersList parses the space-separated list of controllers used
+// by cgroup.controllers and cgroup.subtree_control.
+func parseControllersList(contents string) []string {
+	return strings.Fields(contents)
+}
+
+// Controllers reads the cgroup.controllers and cgroup.subtree_control files
+// for the cgroup v2 cgroup cgPath.
+// enabled lists the controllers available to the cgroup itself (and hence
+// whose interface files, e.g. memory.current, are present), while delegated
+// lists the controllers enabled for the cgroup's children.
+// If cgPath is not a cgroup v2 cgroup, it returns ErrNotCGroupV2.
+func (c *CGroupPath) Controllers() (enabled, delegated []string, err error) {
+	if c.Mode != CGModeV2 {
+		return nil, nil, ErrNotCGroupV2
+	}
+	return readCGroupControllers(os.DirFS(c.AbsPath))
+}
+
+// readCGroupControllers reads the controller lists from the cgroup v2
+// cgroup directory f.
+func readCGroupControllers(f fs.FS) (enabled, delegated []string, err error) {
+	ctrls, ctrlsErr := fs.ReadFile(f, cgroupV2ControllersFile)
+	if ctrlsErr != nil {
+		return nil, nil, fmt.Errorf("failed to read %q: %w", cgroupV2ControllersFile, ctrlsErr)
+	}
+	subtree, subtreeErr := fs.ReadFile(f, cgroupV2SubtreeControlFile)
+	if subtreeErr != nil {
+		return nil, nil, fmt.Errorf("failed to read %q: %w", cgroupV2SubtreeControlFile, subtreeErr)
+	}
+	return parseControllersList(string(ctrls)), parseControllersList(string(subtree)), nil
+}
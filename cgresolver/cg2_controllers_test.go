@@ -0,0 +1,126 @@
+package cgresolver
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCGroup2RootMount(t *testing.T) {
+	for _, itbl := range []struct {
+		name   string
+		mounts []Mount
+		expMnt Mount
+		expErr error
+	}{
+		{
+			name: "cg2_only",
+			mounts: []Mount{{
+				Mountpoint: "/sys/fs/cgroup",
+				Root:       "/",
+				CGroupV2:   true,
+			}},
+			expMnt: Mount{
+				Mountpoint: "/sys/fs/cgroup",
+				Root:       "/",
+				CGroupV2:   true,
+			},
+		}, {
+			name: "hybrid",
+			mounts: []Mount{{
+				Mountpoint: "/sys/fs/cgroup/memory",
+				Root:       "/",
+				Subsystems: []string{"memory"},
+			}, {
+				Mountpoint: "/sys/fs/cgroup/unified",
+				Root:       "/",
+				CGroupV2:   true,
+			}},
+			expMnt: Mount{
+				Mountpoint: "/sys/fs/cgroup/unified",
+				Root:       "/",
+				CGroupV2:   true,
+			},
+		}, {
+			name: "skip_outside_namespace",
+			mounts: []Mount{{
+				Mountpoint: "/tmp/cg2",
+				Root:       "/../../foo",
+				CGroupV2:   true,
+			}, {
+				Mountpoint: "/sys/fs/cgroup",
+				Root:       "/",
+				CGroupV2:   true,
+			}},
+			expMnt: Mount{
+				Mountpoint: "/sys/fs/cgroup",
+				Root:       "/",
+				CGroupV2:   true,
+			},
+		}, {
+			name: "cg1_only",
+			mounts: []Mount{{
+				Mountpoint: "/sys/fs/cgroup/memory",
+				Root:       "/",
+				Subsystems: []string{"memory"},
+			}, {
+				Mountpoint: "/sys/fs/cgroup/cpu",
+				Root:       "/",
+				Subsystems: []string{"cpu"},
+			}},
+			expErr: ErrNoCGroup2Mount,
+		},
+	} {
+		tbl := itbl
+		t.Run(tbl.name, func(t *testing.T) {
+			t.Parallel()
+			mnt, err := cgroup2RootMount(tbl.mounts)
+			if tbl.expErr != nil {
+				assert.True(t, errors.Is(err, tbl.expErr), "unexpected error: %v", err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tbl.expMnt, mnt)
+		})
+	}
+}
+
+func TestParseControllersList(t *testing.T) {
+	assert.Equal(t, []string{"cpuset", "cpu", "io", "memory", "hugetlb", "pids", "rdma", "misc"},
+		parseControllersList("cpuset cpu io memory hugetlb pids rdma misc\n"))
+	assert.Empty(t, parseControllersList("\n"))
+}
+
+func TestRootControllersRead(t *testing.T) {
+	ctrls, err := RootControllers()
+	if errors.Is(err, ErrNoCGroup2Mount) {
+		t.Skip("no cgroup2 mount")
+	}
+	require.NoError(t, err)
+	t.Logf("root controllers: %q", ctrls)
+}
+
+func TestReadCGroupControllers(t *testing.T) {
+	f := fstest.MapFS{
+		"cgroup.controllers":     &fstest.MapFile{Data: []byte("cpu io memory pids\n")},
+		"cgroup.subtree_control": &fstest.MapFile{Data: []byte("memory\n")},
+	}
+	enabled, delegated, err := readCGroupControllers(f)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cpu", "io", "memory", "pids"}, enabled)
+	assert.Equal(t, []string{"memory"}, delegated)
+
+	delete(f, "cgroup.subtree_control")
+	_, _, missingErr := readCGroupControllers(f)
+	assert.True(t, errors.Is(missingErr, fs.ErrNotExist), "unexpected error: %v", missingErr)
+}
+
+func TestCGroupPathControllersV1(t *testing.T) {
+	cgp := CGroupPath{AbsPath: "/sys/fs/cgroup/memory", MountPath: "/sys/fs/cgroup/memory", Mode: CGModeV1}
+	_, _, err := cgp.Controllers()
+	assert.True(t, errors.Is(err, ErrNotCGroupV2), "unexpected error: %v", err)
+}
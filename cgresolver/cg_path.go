@@ -19,8 +19,44 @@ const (
 	CGModeV1
 	// CGroup V2
 	CGModeV2
+	// Both CGroup V1 and V2 hierarchies are mounted (only returned by
+	// DetectMode; any individual controller is in one or the other)
+	CGModeHybrid
 )
 
+// DetectMode inspects the cgroup mounts in the current mount namespace (see
+// CGroupMountInfo), and returns CGModeV2 if only the unified hierarchy is
+// mounted, CGModeV1 if only cgroup v1 hierarchies are mounted, and
+// CGModeHybrid if both are.
+func DetectMode() (CGMode, error) {
+	mounts, mountsErr := CGroupMountInfo()
+	if mountsErr != nil {
+		return CGModeUnknown, fmt.Errorf("failed to parse mountinfo: %w", mountsErr)
+	}
+	return detectMode(mounts)
+}
+
+func detectMode(mounts []Mount) (CGMode, error) {
+	haveV1, haveV2 := false, false
+	for _, mnt := range mounts {
+		if mnt.CGroupV2 {
+			haveV2 = true
+		} else {
+			haveV1 = true
+		}
+	}
+	switch {
+	case haveV1 && haveV2:
+		return CGModeHybrid, nil
+	case haveV2:
+		return CGModeV2, nil
+	case haveV1:
+		return CGModeV1, nil
+	default:
+		return CGModeUnknown, fmt.Errorf("no cgroup or cgroup2 mounts present")
+	}
+}
+
 func cgroup2Mode(iscg2 bool) CGMode {
 	if iscg2 {
 		return CGModeV2
@@ -61,6 +97,9 @@ func (c *CGroupPath) Parent() (CGroupPath, bool) {
 }
 
 // SelfSubsystemPath returns a CGroupPath for the cgroup associated with a specific subsystem for the current process.
+// If the process is only a member of the cgroup v2 unified hierarchy, the
+// subsystem is not validated against /proc/cgroups, and the unified
+// hierarchy's cgroup is returned.
 func SelfSubsystemPath(subsystem string) (CGroupPath, error) {
 	return subsystemPath("self", subsystem)
 }
@@ -70,37 +109,133 @@ func PIDSubsystemPath(pid int, subsystem string) (CGroupPath, error) {
 	return subsystemPath(strconv.Itoa(pid), subsystem)
 }
 
-func subsystemPath(procSubDir string, subsystem string) (CGroupPath, error) {
-	cgSubSyses, cgSubSysReadErr := ParseReadCGSubsystems()
+// SelfAllSubsystemPaths returns a map from subsystem name (as listed in
+// /proc/cgroups) to the CGroupPath of the current process's cgroup for that
+// subsystem. Each procfs file is read and parsed only once, but every path
+// matches what SelfSubsystemPath would return for that subsystem.
+// Subsystems for which SelfSubsystemPath would fail (e.g. a v1 controller
+// whose hierarchy isn't mounted in this mount namespace) are omitted.
+func SelfAllSubsystemPaths() (map[string]CGroupPath, error) {
+	return allSubsystemPaths(osCGSource{}, "self")
+}
+
+func allSubsystemPaths(src cgSource, procSubDir string) (map[string]CGroupPath, error) {
+	cSrc := cachingCGSource{src: src, procSubDir: procSubDir}
+	// Read everything up-front so errors reading procfs are surfaced,
+	// rather than being mistaken for subsystems that fail to resolve.
+	cgSubSyses, cgSubSysReadErr := cSrc.cgSubsystems()
 	if cgSubSysReadErr != nil {
-		return CGroupPath{}, fmt.Errorf("failed to resolve subsystems to hierarchies: %w", cgSubSysReadErr)
+		return nil, fmt.Errorf("failed to resolve subsystems to hierarchies: %w", cgSubSysReadErr)
+	}
+	if _, procCGsErr := cSrc.procCGroups(procSubDir); procCGsErr != nil {
+		return nil, fmt.Errorf("failed to resolve cgroup controllers: %w", procCGsErr)
+	}
+	if _, mountInfoParseErr := cSrc.cgMounts(); mountInfoParseErr != nil {
+		return nil, fmt.Errorf("failed to parse mountinfo: %w", mountInfoParseErr)
+	}
+
+	out := make(map[string]CGroupPath, len(cgSubSyses))
+	for _, ss := range cgSubSyses {
+		cgPath, resolveErr := resolveSubsystemPath(&cSrc, procSubDir, ss.Subsys)
+		if resolveErr != nil {
+			continue
+		}
+		out[ss.Subsys] = cgPath
 	}
-	cgIdx := slices.IndexFunc(cgSubSyses, func(c CGroupSubsystem) bool {
-		return c.Subsys == subsystem
-	})
-	if cgIdx == -1 {
-		return CGroupPath{}, fmt.Errorf("no cgroup hierarchy associated with subsystem %q", subsystem)
+	return out, nil
+}
+
+// cgSource provides parsed copies of the procfs files used to resolve the
+// cgroup paths for a process.
+type cgSource interface {
+	// cgSubsystems provides the parsed contents of /proc/cgroups
+	cgSubsystems() ([]CGroupSubsystem, error)
+	// procCGroups provides the parsed contents of /proc/<procSubDir>/cgroup
+	procCGroups(procSubDir string) ([]CGProcHierarchy, error)
+	// cgMounts provides the cgroup mounts from /proc/self/mountinfo
+	cgMounts() ([]Mount, error)
+}
+
+// osCGSource reads everything from procfs on every call
+type osCGSource struct{}
+
+func (osCGSource) cgSubsystems() ([]CGroupSubsystem, error) {
+	return ParseReadCGSubsystems()
+}
+
+func (osCGSource) procCGroups(procSubDir string) ([]CGProcHierarchy, error) {
+	return resolveProcCGControllers(procSubDir)
+}
+
+func (osCGSource) cgMounts() ([]Mount, error) {
+	return CGroupMountInfo()
+}
+
+func subsystemPath(procSubDir string, subsystem string) (CGroupPath, error) {
+	return resolveSubsystemPath(osCGSource{}, procSubDir, subsystem)
+}
+
+// v2OnlyHierarchy returns the unified hierarchy if it's the only hierarchy
+// the process belongs to.
+func v2OnlyHierarchy(procCGs []CGProcHierarchy) (*CGProcHierarchy, bool) {
+	if len(procCGs) != 1 || procCGs[0].HierarchyID != CGroupV2HierarchyID {
+		return nil, false
 	}
-	cgHierID := cgSubSyses[cgIdx].Hierarchy
+	return &procCGs[0], true
+}
+
+func resolveSubsystemPath(src cgSource, procSubDir string, subsystem string) (CGroupPath, error) {
+	return resolveSubsystemPathStrict(src, procSubDir, subsystem, false)
+}
 
-	procCGs, procCGsErr := resolveProcCGControllers(procSubDir)
+// resolveSubsystemPathStrict resolves the path for a subsystem; if strict is
+// true, it fails rather than picking the first usable mount if there are
+// conflicting mounts of the relevant hierarchy.
+func resolveSubsystemPathStrict(src cgSource, procSubDir string, subsystem string, strict bool) (CGroupPath, error) {
+	procCGs, procCGsErr := src.procCGroups(procSubDir)
 	if procCGsErr != nil {
 		return CGroupPath{}, fmt.Errorf("failed to resolve cgroup controllers: %w", procCGsErr)
 	}
 
-	procCGIdx := slices.IndexFunc(procCGs, func(cg CGProcHierarchy) bool { return cg.HierarchyID == cgHierID })
-	if procCGIdx == -1 {
-		return CGroupPath{}, fmt.Errorf("failed to resolve process cgroup controllers: %w", procCGsErr)
+	// Fast path: if the process is only a member of the unified hierarchy
+	// (pure cgroup v2), every controller lives there, so there's no need
+	// to map the subsystem to a hierarchy via /proc/cgroups.
+	hier, v2Only := v2OnlyHierarchy(procCGs)
+	if !v2Only {
+		cgSubSyses, cgSubSysReadErr := src.cgSubsystems()
+		if cgSubSysReadErr != nil {
+			return CGroupPath{}, fmt.Errorf("failed to resolve subsystems to hierarchies: %w", cgSubSysReadErr)
+		}
+		cgIdx := slices.IndexFunc(cgSubSyses, func(c CGroupSubsystem) bool {
+			return c.Subsys == subsystem
+		})
+		if cgIdx == -1 {
+			return CGroupPath{}, fmt.Errorf("no cgroup hierarchy associated with subsystem %q", subsystem)
+		}
+		cgHierID := cgSubSyses[cgIdx].Hierarchy
+
+		procCGIdx := slices.IndexFunc(procCGs, func(cg CGProcHierarchy) bool { return cg.HierarchyID == cgHierID })
+		if procCGIdx == -1 {
+			return CGroupPath{}, fmt.Errorf("failed to resolve process cgroup controllers: process not a member of hierarchy %d (subsystem %q)",
+				cgHierID, subsystem)
+		}
+		hier = &procCGs[procCGIdx]
 	}
 
-	cgMountInfo, mountInfoParseErr := CGroupMountInfo()
+	cgMountInfo, mountInfoParseErr := src.cgMounts()
 	if mountInfoParseErr != nil {
 		return CGroupPath{}, fmt.Errorf("failed to parse mountinfo: %w", mountInfoParseErr)
 	}
 
-	cgPath, cgPathErr := procCGs[procCGIdx].cgPath(cgMountInfo)
+	if strict {
+		if conflictErr := hier.conflictingMounts(cgMountInfo); conflictErr != nil {
+			return CGroupPath{}, fmt.Errorf("unable to unambiguously resolve filesystem path for cgroup %+v: %w", *hier, conflictErr)
+		}
+	}
+
+	cgPath, cgPathErr := hier.cgPath(cgMountInfo)
 	if cgPathErr != nil {
-		return CGroupPath{}, fmt.Errorf("failed to resolve filesystem path for cgroup %+v: %w", procCGs[procCGIdx], cgPathErr)
+		return CGroupPath{}, fmt.Errorf("failed to resolve filesystem path for cgroup %+v: %w", *hier, cgPathErr)
 	}
 	return cgPath, nil
 }
@@ -1,6 +1,9 @@
 package cgresolver
 
-import "testing"
+import (
+	"strings"
+	"testing"
+)
 
 func TestCGroupPathParent(t *testing.T) {
 	for _, tbl := range []struct {
@@ -91,3 +94,321 @@ func TestCGroupPathParent(t *testing.T) {
 		})
 	}
 }
+
+// fakeCGSource parses fixed file-contents, counting the number of
+// simulated file reads.
+type fakeCGSource struct {
+	procCgroups   string
+	procPidCgroup string
+	mountinfo     string
+
+	reads int
+}
+
+func (f *fakeCGSource) cgSubsystems() ([]CGroupSubsystem, error) {
+	f.reads++
+	return parseCGSubsystems(f.procCgroups)
+}
+
+func (f *fakeCGSource) procCGroups(string) ([]CGProcHierarchy, error) {
+	f.reads++
+	return parseProcPidCgroup([]byte(f.procPidCgroup))
+}
+
+func (f *fakeCGSource) cgMounts() ([]Mount, error) {
+	f.reads++
+	return getCGroupMountsFromMountinfo(f.mountinfo)
+}
+
+const (
+	testV2OnlyProcCgroups = `#subsys_name	hierarchy	num_cgroups	enabled
+cpuset	0	179	1
+cpu	0	179	1
+cpuacct	0	179	1
+blkio	0	179	1
+memory	0	179	1
+devices	0	179	1
+freezer	0	179	1
+net_cls	0	179	1
+perf_event	0	179	1
+net_prio	0	179	1
+hugetlb	0	179	1
+pids	0	179	1
+rdma	0	179	1
+misc	0	179	1
+`
+	testV2OnlyProcPidCgroup = "0::/user.slice/user-1000.slice/session-2.scope\n"
+	testV2OnlyMountinfo     = `24 30 0:22 / /sys rw,nosuid,nodev,noexec,relatime shared:7 - sysfs sysfs rw
+35 24 0:30 / /sys/fs/cgroup rw,nosuid,nodev,noexec,relatime shared:9 - cgroup2 cgroup2 rw,nsdelegate,memory_recursiveprot
+`
+
+	testHybridProcCgroups = `#subsys_name	hierarchy	num_cgroups	enabled
+cpuset	3	1	1
+cpu	1	1	1
+cpuacct	2	1	1
+blkio	7	1	1
+memory	4	2	1
+devices	5	1	1
+freezer	6	1	1
+pids	8	1	1
+`
+	testHybridProcPidCgroup = `8:pids:/
+7:blkio:/
+6:freezer:/
+5:devices:/
+4:memory:/foo/bar
+3:cpuset:/
+2:cpuacct:/
+1:cpu:/
+0::/
+`
+	testHybridMountinfo = `32 24 0:28 / /sys/fs/cgroup rw,relatime - tmpfs tmpfs rw,mode=755
+33 32 0:29 / /sys/fs/cgroup/cpu rw,relatime - cgroup cgroup rw,cpu
+34 32 0:30 / /sys/fs/cgroup/cpuacct rw,relatime - cgroup cgroup rw,cpuacct
+35 32 0:31 / /sys/fs/cgroup/cpuset rw,relatime - cgroup cgroup rw,cpuset
+36 32 0:32 / /sys/fs/cgroup/memory rw,relatime - cgroup cgroup rw,memory
+37 32 0:33 / /sys/fs/cgroup/devices rw,relatime - cgroup cgroup rw,devices
+38 32 0:34 / /sys/fs/cgroup/freezer rw,relatime - cgroup cgroup rw,freezer
+39 32 0:35 / /sys/fs/cgroup/blkio rw,relatime - cgroup cgroup rw,blkio
+40 32 0:36 / /sys/fs/cgroup/pids rw,relatime - cgroup cgroup rw,pids
+42 32 0:38 / /sys/fs/cgroup/unified rw,relatime - cgroup2 cgroup2 rw
+`
+)
+
+func TestDetectMode(t *testing.T) {
+	for _, tbl := range []struct {
+		name      string
+		mountinfo string
+		expMode   CGMode
+		expErr    bool
+	}{
+		{
+			name:      "v2_only",
+			mountinfo: testV2OnlyMountinfo,
+			expMode:   CGModeV2,
+		},
+		{
+			name:      "hybrid",
+			mountinfo: testHybridMountinfo,
+			expMode:   CGModeHybrid,
+		},
+		{
+			name: "v1_only",
+			mountinfo: strings.Replace(testHybridMountinfo,
+				"42 32 0:38 / /sys/fs/cgroup/unified rw,relatime - cgroup2 cgroup2 rw\n", "", 1),
+			expMode: CGModeV1,
+		},
+		{
+			name:      "no_cgroups",
+			mountinfo: "24 30 0:22 / /sys rw,nosuid,nodev,noexec,relatime shared:7 - sysfs sysfs rw\n",
+			expErr:    true,
+		},
+	} {
+		t.Run(tbl.name, func(t *testing.T) {
+			mounts, mntErr := getCGroupMountsFromMountinfo(tbl.mountinfo)
+			if mntErr != nil {
+				t.Fatalf("failed to parse mountinfo: %s", mntErr)
+			}
+			mode, err := detectMode(mounts)
+			if tbl.expErr {
+				if err == nil {
+					t.Errorf("expected error; got mode %d", mode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if mode != tbl.expMode {
+				t.Errorf("unexpected mode %d; expected %d", mode, tbl.expMode)
+			}
+		})
+	}
+}
+
+func TestResolveSubsystemPath(t *testing.T) {
+	for _, tbl := range []struct {
+		name      string
+		src       fakeCGSource
+		subsystem string
+		expPath   CGroupPath
+		expReads  int
+	}{
+		{
+			name: "v2_only_fast_path",
+			src: fakeCGSource{
+				procCgroups:   testV2OnlyProcCgroups,
+				procPidCgroup: testV2OnlyProcPidCgroup,
+				mountinfo:     testV2OnlyMountinfo,
+			},
+			subsystem: "memory",
+			expPath: CGroupPath{
+				AbsPath:   "/sys/fs/cgroup/user.slice/user-1000.slice/session-2.scope",
+				MountPath: "/sys/fs/cgroup",
+				Mode:      CGModeV2,
+			},
+			expReads: 2,
+		},
+		{
+			name: "hybrid_v1_memory",
+			src: fakeCGSource{
+				procCgroups:   testHybridProcCgroups,
+				procPidCgroup: testHybridProcPidCgroup,
+				mountinfo:     testHybridMountinfo,
+			},
+			subsystem: "memory",
+			expPath: CGroupPath{
+				AbsPath:   "/sys/fs/cgroup/memory/foo/bar",
+				MountPath: "/sys/fs/cgroup/memory",
+				Mode:      CGModeV1,
+			},
+			expReads: 3,
+		},
+	} {
+		t.Run(tbl.name, func(t *testing.T) {
+			src := tbl.src
+			p, err := resolveSubsystemPath(&src, "self", tbl.subsystem)
+			if err != nil {
+				t.Fatalf("failed to resolve path: %s", err)
+			}
+			if p != tbl.expPath {
+				t.Errorf("unexpected CGroupPath:\n  got %+v\n want %+v", p, tbl.expPath)
+			}
+			if src.reads != tbl.expReads {
+				t.Errorf("unexpected number of reads: %d; expected %d", src.reads, tbl.expReads)
+			}
+		})
+	}
+}
+
+func TestResolveSubsystemPathAnomalousV2(t *testing.T) {
+	// Hierarchy 0 carries a controller list, which would previously match
+	// the v1 memory mount (listed first).
+	src := fakeCGSource{
+		procCgroups: `#subsys_name	hierarchy	num_cgroups	enabled
+cpu	1	1	1
+memory	0	1	1
+`,
+		procPidCgroup: `1:cpu:/
+0:memory:/foo/bar
+`,
+		mountinfo: `33 32 0:29 / /sys/fs/cgroup/cpu rw,relatime - cgroup cgroup rw,cpu
+36 32 0:32 / /sys/fs/cgroup/memory rw,relatime - cgroup cgroup rw,memory
+42 32 0:38 / /sys/fs/cgroup/unified rw,relatime - cgroup2 cgroup2 rw
+`,
+	}
+	p, err := resolveSubsystemPath(&src, "self", "memory")
+	if err != nil {
+		t.Fatalf("failed to resolve path: %s", err)
+	}
+	expPath := CGroupPath{
+		AbsPath:   "/sys/fs/cgroup/unified/foo/bar",
+		MountPath: "/sys/fs/cgroup/unified",
+		Mode:      CGModeV2,
+	}
+	if p != expPath {
+		t.Errorf("unexpected CGroupPath:\n  got %+v\n want %+v", p, expPath)
+	}
+}
+
+func TestAllSubsystemPaths(t *testing.T) {
+	for _, tbl := range []struct {
+		name     string
+		src      fakeCGSource
+		expPaths int
+	}{
+		{
+			name: "v2_only",
+			src: fakeCGSource{
+				procCgroups:   testV2OnlyProcCgroups,
+				procPidCgroup: testV2OnlyProcPidCgroup,
+				mountinfo:     testV2OnlyMountinfo,
+			},
+			expPaths: 14,
+		},
+		{
+			name: "hybrid",
+			src: fakeCGSource{
+				procCgroups:   testHybridProcCgroups,
+				procPidCgroup: testHybridProcPidCgroup,
+				mountinfo:     testHybridMountinfo,
+			},
+			expPaths: 8,
+		},
+		{
+			name: "hybrid_unmounted_hierarchy",
+			src: fakeCGSource{
+				procCgroups:   testHybridProcCgroups,
+				procPidCgroup: testHybridProcPidCgroup,
+				// drop the pids mount
+				mountinfo: strings.Replace(testHybridMountinfo,
+					"40 32 0:36 / /sys/fs/cgroup/pids rw,relatime - cgroup cgroup rw,pids\n", "", 1),
+			},
+			expPaths: 7,
+		},
+	} {
+		t.Run(tbl.name, func(t *testing.T) {
+			src := tbl.src
+			paths, err := allSubsystemPaths(&src, "self")
+			if err != nil {
+				t.Fatalf("failed to resolve paths: %s", err)
+			}
+			if src.reads != 3 {
+				t.Errorf("unexpected number of reads: %d; expected 3", src.reads)
+			}
+			if len(paths) != tbl.expPaths {
+				t.Errorf("unexpected number of paths %d; expected %d: %+v", len(paths), tbl.expPaths, paths)
+			}
+			subsyses, _ := parseCGSubsystems(src.procCgroups)
+			for _, ss := range subsyses {
+				expPath, expErr := resolveSubsystemPath(&src, "self", ss.Subsys)
+				p, ok := paths[ss.Subsys]
+				if expErr != nil {
+					if ok {
+						t.Errorf("unexpected path for unresolvable subsystem %q: %+v", ss.Subsys, p)
+					}
+					continue
+				}
+				if !ok || p != expPath {
+					t.Errorf("unexpected path for subsystem %q:\n  got %+v (present: %t)\n want %+v",
+						ss.Subsys, p, ok, expPath)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkResolveSubsystemPath(b *testing.B) {
+	for _, bb := range []struct {
+		name string
+		src  fakeCGSource
+	}{
+		{
+			name: "v2_only",
+			src: fakeCGSource{
+				procCgroups:   testV2OnlyProcCgroups,
+				procPidCgroup: testV2OnlyProcPidCgroup,
+				mountinfo:     testV2OnlyMountinfo,
+			},
+		},
+		{
+			name: "hybrid",
+			src: fakeCGSource{
+				procCgroups:   testHybridProcCgroups,
+				procPidCgroup: testHybridProcPidCgroup,
+				mountinfo:     testHybridMountinfo,
+			},
+		},
+	} {
+		b.Run(bb.name, func(b *testing.B) {
+			src := bb.src
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := resolveSubsystemPath(&src, "self", "memory"); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(src.reads)/float64(b.N), "reads/op")
+		})
+	}
+}
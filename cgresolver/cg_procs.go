@@ -0,0 +1,69 @@
+package cgresolver
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	cgroupProcsFile   = "cgroup.procs"
+	cgroupV1TasksFile = "tasks"
+)
+
+// CGroupProcs returns the (sorted) PIDs of the processes that are members of
+// the cgroup at path, as listed in its cgroup.procs file. (under cgroup v1,
+// it falls back to the tasks file on kernels that lack cgroup.procs, in
+// which case the IDs of all threads are returned)
+// Note: the list is a point-in-time snapshot; processes may be created,
+// exit, or move between cgroups at any time, so it may be stale by the time
+// it's returned.
+func CGroupProcs(path CGroupPath) ([]int, error) {
+	return readCGroupProcs(os.DirFS(path.AbsPath), path.Mode)
+}
+
+// SelfCGroupProcs returns the PIDs of the processes in the current process's
+// cgroup for the specified subsystem. See CGroupProcs for caveats.
+func SelfCGroupProcs(subsystem string) ([]int, error) {
+	cgPath, cgPathErr := SelfSubsystemPath(subsystem)
+	if cgPathErr != nil {
+		return nil, fmt.Errorf("failed to resolve cgroup path for subsystem %q: %w", subsystem, cgPathErr)
+	}
+	return CGroupProcs(cgPath)
+}
+
+func readCGroupProcs(f fs.FS, mode CGMode) ([]int, error) {
+	contents, readErr := fs.ReadFile(f, cgroupProcsFile)
+	if readErr != nil {
+		if mode != CGModeV1 || !errors.Is(readErr, fs.ErrNotExist) {
+			return nil, fmt.Errorf("failed to read %q: %w", cgroupProcsFile, readErr)
+		}
+		tasksContents, tasksReadErr := fs.ReadFile(f, cgroupV1TasksFile)
+		if tasksReadErr != nil {
+			return nil, fmt.Errorf("failed to read %q: %w", cgroupV1TasksFile, tasksReadErr)
+		}
+		contents = tasksContents
+	}
+	return parsePIDList(string(contents))
+}
+
+// parsePIDList parses the newline-separated list of PIDs in a cgroup.procs
+// or tasks file. Under cgroup v1, cgroup.procs is neither sorted nor
+// deduplicated, so the result is sorted and duplicates are removed.
+func parsePIDList(contents string) ([]int, error) {
+	lines := strings.Fields(contents)
+	pids := make([]int, 0, len(lines))
+	for _, line := range lines {
+		pid, parseErr := strconv.Atoi(line)
+		if parseErr != nil {
+			return nil, fmt.Errorf("failed to parse PID %q: %w", line, parseErr)
+		}
+		pids = append(pids, pid)
+	}
+	slices.Sort(pids)
+	return slices.Compact(pids), nil
+}
@@ -0,0 +1,89 @@
+package cgresolver
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"slices"
+	"testing"
+	"testing/fstest"
+)
+
+func TestReadCGroupProcs(t *testing.T) {
+	for _, tbl := range []struct {
+		name    string
+		f       fstest.MapFS
+		mode    CGMode
+		expPIDs []int
+		expErr  error
+	}{
+		{
+			name: "v2",
+			f: fstest.MapFS{
+				"cgroup.procs": &fstest.MapFile{Data: []byte("12\n1\n345\n")},
+			},
+			mode:    CGModeV2,
+			expPIDs: []int{1, 12, 345},
+		},
+		{
+			name: "v1_duplicates",
+			f: fstest.MapFS{
+				"cgroup.procs": &fstest.MapFile{Data: []byte("7\n3\n7\n")},
+				"tasks":        &fstest.MapFile{Data: []byte("3\n7\n8\n")},
+			},
+			mode:    CGModeV1,
+			expPIDs: []int{3, 7},
+		},
+		{
+			name: "v1_tasks_fallback",
+			f: fstest.MapFS{
+				"tasks": &fstest.MapFile{Data: []byte("3\n7\n8\n")},
+			},
+			mode:    CGModeV1,
+			expPIDs: []int{3, 7, 8},
+		},
+		{
+			name:    "empty",
+			f:       fstest.MapFS{"cgroup.procs": &fstest.MapFile{}},
+			mode:    CGModeV2,
+			expPIDs: []int{},
+		},
+		{
+			name: "v2_no_tasks_fallback",
+			f: fstest.MapFS{
+				"tasks": &fstest.MapFile{Data: []byte("3\n")},
+			},
+			mode:   CGModeV2,
+			expErr: fs.ErrNotExist,
+		},
+	} {
+		t.Run(tbl.name, func(t *testing.T) {
+			pids, err := readCGroupProcs(tbl.f, tbl.mode)
+			if tbl.expErr != nil {
+				if !errors.Is(err, tbl.expErr) {
+					t.Fatalf("unexpected error: %v; expected %v", err, tbl.expErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !slices.Equal(pids, tbl.expPIDs) {
+				t.Errorf("unexpected PIDs: %v; expected %v", pids, tbl.expPIDs)
+			}
+		})
+	}
+	if _, err := parsePIDList("1\nfoo\n"); err == nil {
+		t.Error("expected error for malformed PID")
+	}
+}
+
+func TestSelfCGroupProcs(t *testing.T) {
+	pids, err := SelfCGroupProcs("memory")
+	if err != nil {
+		t.Skipf("unable to read cgroup membership: %s", err)
+	}
+	if _, found := slices.BinarySearch(pids, os.Getpid()); !found {
+		t.Errorf("current process (%d) not listed in its own cgroup: %v", os.Getpid(), pids)
+	}
+}
@@ -0,0 +1,92 @@
+package cgresolver
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ConflictingMountsErr describes a set of mounts of the same cgroup hierarchy
+// (the same set of v1 subsystems, or the cgroup2 unified hierarchy) which
+// have different roots. Path resolution normally uses the first usable
+// mount, which may not be the intended one in such a setup.
+type ConflictingMountsErr struct {
+	// Subsystems is the set of v1 subsystems bound to the hierarchy (nil
+	// for the unified hierarchy)
+	Subsystems []string
+	// CGroupV2 is true if the conflicting mounts are of the unified
+	// hierarchy
+	CGroupV2 bool
+	// Mounts contains all the mounts of the hierarchy, in mountinfo order
+	Mounts []Mount
+}
+
+func (c *ConflictingMountsErr) Error() string {
+	roots := make([]string, len(c.Mounts))
+	for i, mp := range c.Mounts {
+		roots[i] = mp.Mountpoint + " (root " + mp.Root + ")"
+	}
+	hier := "cgroup2 unified hierarchy"
+	if !c.CGroupV2 {
+		hier = fmt.Sprintf("cgroup hierarchy with subsystems %q", c.Subsystems)
+	}
+	return fmt.Sprintf("%s has %d mounts with divergent roots: %s",
+		hier, len(c.Mounts), strings.Join(roots, ", "))
+}
+
+// DetectMountConflicts groups the passed mounts by hierarchy, and returns a
+// diagnostic for each hierarchy that is mounted more than once with
+// divergent roots. Mounts originating outside the current cgroup namespace
+// are ignored, as they're never used for path resolution.
+// A nil return indicates that no conflicts were found.
+func DetectMountConflicts(mounts []Mount) []ConflictingMountsErr {
+	hierKeys := []string{}
+	byHier := map[string][]Mount{}
+	for _, mp := range mounts {
+		// (see the comment in CGProcHierarchy.cgPath)
+		if strings.HasPrefix(mp.Root, "/..") {
+			continue
+		}
+		key := "v1:" + strings.Join(mp.Subsystems, ",")
+		if mp.CGroupV2 {
+			key = "cgroup2"
+		}
+		if _, ok := byHier[key]; !ok {
+			hierKeys = append(hierKeys, key)
+		}
+		byHier[key] = append(byHier[key], mp)
+	}
+
+	var out []ConflictingMountsErr
+	for _, key := range hierKeys {
+		hierMounts := byHier[key]
+		if !slices.ContainsFunc(hierMounts[1:], func(mp Mount) bool { return mp.Root != hierMounts[0].Root }) {
+			continue
+		}
+		out = append(out, ConflictingMountsErr{
+			Subsystems: hierMounts[0].Subsystems,
+			CGroupV2:   hierMounts[0].CGroupV2,
+			Mounts:     hierMounts,
+		})
+	}
+	return out
+}
+
+// conflictingMounts returns a non-nil error if the hierarchy is mounted
+// multiple times with divergent roots.
+func (c *CGProcHierarchy) conflictingMounts(mountpoints []Mount) error {
+	for _, conflict := range DetectMountConflicts(mountpoints) {
+		if (conflict.CGroupV2 && c.HierarchyID == CGroupV2HierarchyID) ||
+			(!conflict.CGroupV2 && slices.Equal(conflict.Subsystems, c.Subsystems)) {
+			return &conflict
+		}
+	}
+	return nil
+}
+
+// SelfSubsystemPathStrict is like SelfSubsystemPath, but it returns an error
+// (of type *ConflictingMountsErr) rather than picking the first usable mount
+// if the relevant hierarchy is mounted multiple times with divergent roots.
+func SelfSubsystemPathStrict(subsystem string) (CGroupPath, error) {
+	return resolveSubsystemPathStrict(osCGSource{}, "self", subsystem, true)
+}
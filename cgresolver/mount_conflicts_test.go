@@ -0,0 +1,105 @@
+package cgresolver
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testConflictingMemoryMountinfo adds a second mount of the memory
+// hierarchy, rooted at a different cgroup, to testHybridMountinfo.
+const testConflictingMemoryMountinfo = testHybridMountinfo +
+	`50 24 0:32 /foo /mnt/memory rw,relatime - cgroup cgroup rw,memory
+`
+
+func TestDetectMountConflicts(t *testing.T) {
+	for _, tbl := range []struct {
+		name         string
+		mountinfo    string
+		expConflicts []ConflictingMountsErr
+	}{
+		{
+			name:         "hybrid_no_conflicts",
+			mountinfo:    testHybridMountinfo,
+			expConflicts: nil,
+		},
+		{
+			name:         "v2_only_no_conflicts",
+			mountinfo:    testV2OnlyMountinfo,
+			expConflicts: nil,
+		},
+		{
+			name: "same_root_no_conflicts",
+			mountinfo: testHybridMountinfo +
+				"51 24 0:32 / /mnt/memory rw,relatime - cgroup cgroup rw,memory\n",
+			expConflicts: nil,
+		},
+		{
+			name: "outside_namespace_ignored",
+			mountinfo: testHybridMountinfo +
+				"51 24 0:32 /../.. /mnt/memory rw,relatime - cgroup cgroup rw,memory\n",
+			expConflicts: nil,
+		},
+		{
+			name:      "conflicting_memory_mounts",
+			mountinfo: testConflictingMemoryMountinfo,
+			expConflicts: []ConflictingMountsErr{{
+				Subsystems: []string{"memory"},
+				CGroupV2:   false,
+				Mounts: []Mount{
+					{Mountpoint: "/sys/fs/cgroup/memory", Root: "/", Subsystems: []string{"memory"}},
+					{Mountpoint: "/mnt/memory", Root: "/foo", Subsystems: []string{"memory"}},
+				},
+			}},
+		},
+		{
+			name: "conflicting_cgroup2_mounts",
+			mountinfo: testV2OnlyMountinfo +
+				"51 24 0:30 /system.slice /mnt/cg2 rw,relatime - cgroup2 cgroup2 rw\n",
+			expConflicts: []ConflictingMountsErr{{
+				CGroupV2: true,
+				Mounts: []Mount{
+					{Mountpoint: "/sys/fs/cgroup", Root: "/", CGroupV2: true},
+					{Mountpoint: "/mnt/cg2", Root: "/system.slice", CGroupV2: true},
+				},
+			}},
+		},
+	} {
+		t.Run(tbl.name, func(t *testing.T) {
+			mounts, err := getCGroupMountsFromMountinfo(tbl.mountinfo)
+			require.NoError(t, err)
+			assert.Equal(t, tbl.expConflicts, DetectMountConflicts(mounts))
+		})
+	}
+}
+
+func TestResolveSubsystemPathConflictingMounts(t *testing.T) {
+	src := fakeCGSource{
+		procCgroups:   testHybridProcCgroups,
+		procPidCgroup: testHybridProcPidCgroup,
+		mountinfo:     testConflictingMemoryMountinfo,
+	}
+
+	// non-strict resolution still picks the first usable mount
+	p, err := resolveSubsystemPath(&src, "self", "memory")
+	require.NoError(t, err)
+	assert.Equal(t, CGroupPath{
+		AbsPath:   "/sys/fs/cgroup/memory/foo/bar",
+		MountPath: "/sys/fs/cgroup/memory",
+		Mode:      CGModeV1,
+	}, p)
+
+	_, strictErr := resolveSubsystemPathStrict(&src, "self", "memory", true)
+	require.Error(t, strictErr)
+	conflictErr := &ConflictingMountsErr{}
+	require.True(t, errors.As(strictErr, &conflictErr))
+	assert.Equal(t, []string{"memory"}, conflictErr.Subsystems)
+	assert.Len(t, conflictErr.Mounts, 2)
+
+	// other hierarchies are unaffected
+	cpuPath, cpuErr := resolveSubsystemPathStrict(&src, "self", "cpu", true)
+	require.NoError(t, cpuErr)
+	assert.Equal(t, "/sys/fs/cgroup/cpu", cpuPath.AbsPath)
+}
@@ -0,0 +1,66 @@
+package cgresolver
+
+import (
+	"slices"
+)
+
+// sameSubsystems compares two sets of subsystems, ignoring order.
+func sameSubsystems(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	as, bs := slices.Clone(a), slices.Clone(b)
+	slices.Sort(as)
+	slices.Sort(bs)
+	return slices.Equal(as, bs)
+}
+
+// sameMount compares two mounts of the same mountpoint
+func sameMount(a, b *Mount) bool {
+	return a.Root == b.Root && a.CGroupV2 == b.CGroupV2 && sameSubsystems(a.Subsystems, b.Subsystems)
+}
+
+// DiffMounts compares two sets of cgroup mounts (as returned by
+// CGroupMountInfo), keyed by mountpoint. It returns the mounts in new whose
+// mountpoints aren't present in old, the mounts in old whose mountpoints
+// aren't present in new, and the mounts in new whose root, subsystems or
+// cgroup version differ from the mount at the same mountpoint in old.
+// Subsystems are compared without regard to order. If a mountpoint appears
+// multiple times within one set (e.g. due to over-mounting), the last mount
+// wins, as it's the one that's visible.
+func DiffMounts(old, new []Mount) (added, removed, changed []Mount) {
+	oldByMP := make(map[string]*Mount, len(old))
+	for i := range old {
+		oldByMP[old[i].Mountpoint] = &old[i]
+	}
+	newByMP := make(map[string]*Mount, len(new))
+	for i := range new {
+		newByMP[new[i].Mountpoint] = &new[i]
+	}
+
+	for i := range new {
+		mp := &new[i]
+		if newByMP[mp.Mountpoint] != mp {
+			// shadowed by a later mount
+			continue
+		}
+		oldMP, ok := oldByMP[mp.Mountpoint]
+		if !ok {
+			added = append(added, *mp)
+			continue
+		}
+		if !sameMount(oldMP, mp) {
+			changed = append(changed, *mp)
+		}
+	}
+	for i := range old {
+		mp := &old[i]
+		if oldByMP[mp.Mountpoint] != mp {
+			continue
+		}
+		if _, ok := newByMP[mp.Mountpoint]; !ok {
+			removed = append(removed, *mp)
+		}
+	}
+	return added, removed, changed
+}
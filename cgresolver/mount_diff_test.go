@@ -0,0 +1,92 @@
+package cgresolver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffMounts(t *testing.T) {
+	cpuMnt := Mount{Mountpoint: "/sys/fs/cgroup/cpu,cpuacct", Root: "/", Subsystems: []string{"cpu", "cpuacct"}}
+	memMnt := Mount{Mountpoint: "/sys/fs/cgroup/memory", Root: "/", Subsystems: []string{"memory"}}
+	cg2Mnt := Mount{Mountpoint: "/sys/fs/cgroup/unified", Root: "/", CGroupV2: true}
+
+	for _, tbl := range []struct {
+		name       string
+		old, new   []Mount
+		expAdded   []Mount
+		expRemoved []Mount
+		expChanged []Mount
+	}{
+		{
+			name: "unchanged",
+			old:  []Mount{cpuMnt, memMnt, cg2Mnt},
+			new:  []Mount{cpuMnt, memMnt, cg2Mnt},
+		},
+		{
+			name: "reordered_mounts_and_subsystems",
+			old:  []Mount{cpuMnt, memMnt, cg2Mnt},
+			new: []Mount{
+				cg2Mnt,
+				memMnt,
+				{Mountpoint: "/sys/fs/cgroup/cpu,cpuacct", Root: "/", Subsystems: []string{"cpuacct", "cpu"}},
+			},
+		},
+		{
+			name:       "added_and_removed",
+			old:        []Mount{cpuMnt, memMnt},
+			new:        []Mount{cpuMnt, cg2Mnt},
+			expAdded:   []Mount{cg2Mnt},
+			expRemoved: []Mount{memMnt},
+		},
+		{
+			name: "changed_root",
+			old:  []Mount{cpuMnt, memMnt},
+			new: []Mount{
+				cpuMnt,
+				{Mountpoint: "/sys/fs/cgroup/memory", Root: "/kubepods/pod1", Subsystems: []string{"memory"}},
+			},
+			expChanged: []Mount{
+				{Mountpoint: "/sys/fs/cgroup/memory", Root: "/kubepods/pod1", Subsystems: []string{"memory"}},
+			},
+		},
+		{
+			name: "changed_subsystems",
+			old:  []Mount{cpuMnt},
+			new: []Mount{
+				{Mountpoint: "/sys/fs/cgroup/cpu,cpuacct", Root: "/", Subsystems: []string{"cpu"}},
+			},
+			expChanged: []Mount{
+				{Mountpoint: "/sys/fs/cgroup/cpu,cpuacct", Root: "/", Subsystems: []string{"cpu"}},
+			},
+		},
+		{
+			name: "changed_to_cgroup2",
+			old:  []Mount{memMnt},
+			new: []Mount{
+				{Mountpoint: "/sys/fs/cgroup/memory", Root: "/", CGroupV2: true},
+			},
+			expChanged: []Mount{
+				{Mountpoint: "/sys/fs/cgroup/memory", Root: "/", CGroupV2: true},
+			},
+		},
+		{
+			name: "overmount_last_wins",
+			old:  []Mount{memMnt},
+			new: []Mount{
+				memMnt,
+				{Mountpoint: "/sys/fs/cgroup/memory", Root: "/foo", Subsystems: []string{"memory"}},
+			},
+			expChanged: []Mount{
+				{Mountpoint: "/sys/fs/cgroup/memory", Root: "/foo", Subsystems: []string{"memory"}},
+			},
+		},
+	} {
+		t.Run(tbl.name, func(t *testing.T) {
+			added, removed, changed := DiffMounts(tbl.old, tbl.new)
+			assert.Equal(t, tbl.expAdded, added, "added")
+			assert.Equal(t, tbl.expRemoved, removed, "removed")
+			assert.Equal(t, tbl.expChanged, changed, "changed")
+		})
+	}
+}
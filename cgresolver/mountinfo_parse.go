@@ -17,12 +17,9 @@ type Mount struct {
 	CGroupV2   bool // true if this is a cgroup2 mount
 }
 
-const (
-	mountinfoPath = "/proc/self/mountinfo"
-)
-
 // CGroupMountInfo parses /proc/self/mountinfo and returns info about all cgroup and cgroup2 mounts
 func CGroupMountInfo() ([]Mount, error) {
+	mountinfoPath := procPath("self", "mountinfo")
 	mountinfoContents, mntInfoReadErr := os.ReadFile(mountinfoPath)
 	if mntInfoReadErr != nil {
 		return nil, fmt.Errorf("failed to read contents of %s: %w",
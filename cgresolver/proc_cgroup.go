@@ -20,6 +20,11 @@ type CGProcHierarchy struct {
 	SubsystemsCSV string   // empty for v2; set of controllers/subsystem names for this hierarchy (CSV)
 	Subsystems    []string // set of v1 subsystems/controllers  (HierarchiesCSV split)
 	Path          string   // path relative to mountpoint
+	// AnomalousV2 is set if this is hierarchy 0 (the unified hierarchy),
+	// but a (non-empty) controller list was present anyway, as emitted by
+	// some container runtimes. Such entries are resolved as the unified
+	// hierarchy.
+	AnomalousV2 bool
 }
 
 func (c *CGProcHierarchy) cgPath(mountpoints []Mount) (CGroupPath, error) {
@@ -35,7 +40,12 @@ func (c *CGProcHierarchy) cgPath(mountpoints []Mount) (CGroupPath, error) {
 		if strings.HasPrefix(mp.Root, "/..") {
 			continue
 		}
-		if (mp.CGroupV2 && c.HierarchyID == CGroupV2HierarchyID) || slices.Equal(mp.Subsystems, c.Subsystems) {
+		// Hierarchy 0 is always the unified hierarchy, even if it has
+		// (anomalously) been listed with controllers, so only match it
+		// against cgroup2 mounts.
+		v2Match := mp.CGroupV2 && c.HierarchyID == CGroupV2HierarchyID
+		v1Match := !mp.CGroupV2 && c.HierarchyID != CGroupV2HierarchyID && slices.Equal(mp.Subsystems, c.Subsystems)
+		if v2Match || v1Match {
 			relCGPath, relErr := filepath.Rel(mp.Root, c.Path)
 			if relErr != nil || strings.HasPrefix(relCGPath, "../") {
 				// bind-mount for a different sub-tree of the cgroups v2 hierarchy
@@ -102,13 +112,14 @@ func parseProcPidCgroup(content []byte) ([]CGProcHierarchy, error) {
 			SubsystemsCSV: string(parts[1]),
 			Path:          string(parts[2]),
 			Subsystems:    ss,
+			AnomalousV2:   hID == CGroupV2HierarchyID && len(ss) > 0,
 		})
 	}
 	return out, nil
 }
 
 func resolveProcCGControllers(pid string) ([]CGProcHierarchy, error) {
-	cgPath := filepath.Join("/proc", pid, "cgroup")
+	cgPath := procPath(pid, "cgroup")
 	cgContents, readErr := os.ReadFile(cgPath)
 	if readErr != nil {
 		return nil, fmt.Errorf("failed to read %q: %w", cgPath, readErr)
@@ -159,9 +170,10 @@ type CGroupSubsystem struct {
 
 // ParseReadCGSubsystems reads the /proc/cgroups pseudofile, and returns a slice of subsystem info, including which hierarchies each belongs to.
 func ParseReadCGSubsystems() ([]CGroupSubsystem, error) {
-	procCG, procCGErr := os.ReadFile("/proc/cgroups")
+	procCGPath := procPath("cgroups")
+	procCG, procCGErr := os.ReadFile(procCGPath)
 	if procCGErr != nil {
-		return nil, fmt.Errorf("failed to read /proc/cgroups: %w", procCGErr)
+		return nil, fmt.Errorf("failed to read %s: %w", procCGPath, procCGErr)
 	}
 	return parseCGSubsystems(string(procCG))
 }
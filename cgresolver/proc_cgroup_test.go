@@ -194,6 +194,33 @@ func TestCGPath(t *testing.T) {
 			},
 			expErr: nil,
 		},
+		{
+			name: "anomalous_hierarchy_0_with_controllers",
+			hier: CGProcHierarchy{
+				HierarchyID:   0,
+				SubsystemsCSV: "memory",
+				Subsystems:    []string{"memory"},
+				Path:          "/foobar",
+				AnomalousV2:   true,
+			},
+			mounts: []Mount{{
+				Mountpoint: "/sys/fs/cgroup/memory",
+				Root:       "/",
+				Subsystems: []string{"memory"},
+				CGroupV2:   false,
+			}, {
+				Mountpoint: "/sys/fs/cgroup/unified",
+				Root:       "/",
+				Subsystems: []string{},
+				CGroupV2:   true,
+			}},
+			expPath: CGroupPath{
+				AbsPath:   "/sys/fs/cgroup/unified/foobar",
+				MountPath: "/sys/fs/cgroup/unified",
+				Mode:      CGModeV2,
+			},
+			expErr: nil,
+		},
 	} {
 		tbl := itbl
 		t.Run(tbl.name, func(t *testing.T) {
@@ -241,6 +268,21 @@ func TestParseProcPidCgroup(t *testing.T) {
 			expOut: nil,
 			expErr: errors.New("line 0 has non-integer hierarchy ID (\"fizzlebit\"): strconv.Atoi: parsing \"fizzlebit\": invalid syntax"),
 		},
+		{
+			name: "anomalous_hierarchy_0_with_controllers",
+			contents: `0:cpu,memory:/user.slice/user-1001.slice/session-2.scope
+`, // include a trailing new line
+			expOut: []CGProcHierarchy{
+				{
+					HierarchyID:   0,
+					SubsystemsCSV: "cpu,memory",
+					Subsystems:    []string{"cpu", "memory"},
+					Path:          "/user.slice/user-1001.slice/session-2.scope",
+					AnomalousV2:   true,
+				},
+			},
+			expErr: nil, // no error
+		},
 		{
 			name: "ubuntu_lunar_cgroup2-missing-path-part",
 			contents: `0:
@@ -400,6 +442,9 @@ func TestParseProcPidCgroup(t *testing.T) {
 				if !slices.Equal(cg.Subsystems, expCG.Subsystems) {
 					t.Errorf("%d: mismatched subsystems:\n  got %q\n want %q", i, cg.Subsystems, expCG.Subsystems)
 				}
+				if cg.AnomalousV2 != expCG.AnomalousV2 {
+					t.Errorf("%d: mismatched anomalous-v2 flag: got %t; want %t", i, cg.AnomalousV2, expCG.AnomalousV2)
+				}
 			}
 		})
 	}
@@ -0,0 +1,42 @@
+package cgresolver
+
+import (
+	"path/filepath"
+	"sync/atomic"
+)
+
+// DefaultProcRoot is the path at which procfs is conventionally mounted.
+const DefaultProcRoot = "/proc"
+
+// procRoot holds the path procfs is read from, if overridden by SetProcRoot
+var procRoot atomic.Pointer[string]
+
+// SetProcRoot overrides the path at which procfs is read from
+// (DefaultProcRoot unless set), for all subsequent reads by this package,
+// and by the procstats and cgrouplimits packages. This is useful when
+// running within a chroot whose procfs is mounted elsewhere, or to read a
+// captured copy of /proc.
+// An empty path restores the default.
+// Note: the cgroupfs mountpoints listed in the (relocated) mountinfo file are
+// still interpreted relative to the filesystem root.
+func SetProcRoot(path string) {
+	if path == "" {
+		procRoot.Store(nil)
+		return
+	}
+	procRoot.Store(&path)
+}
+
+// ProcRoot returns the path procfs is read from. (see SetProcRoot)
+func ProcRoot() string {
+	if p := procRoot.Load(); p != nil {
+		return *p
+	}
+	return DefaultProcRoot
+}
+
+// procPath returns the path of the named file within procfs.
+// e.g. procPath("cgroups") returns "/proc/cgroups" by default.
+func procPath(elem ...string) string {
+	return filepath.Join(append([]string{ProcRoot()}, elem...)...)
+}
@@ -0,0 +1,51 @@
+package cgresolver
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSetProcRoot(t *testing.T) {
+	if r := ProcRoot(); r != DefaultProcRoot {
+		t.Fatalf("unexpected initial proc root %q; expected %q", r, DefaultProcRoot)
+	}
+
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, "self"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, contents := range map[string]string{
+		"cgroups":        testV2OnlyProcCgroups,
+		"self/cgroup":    testV2OnlyProcPidCgroup,
+		"self/mountinfo": testV2OnlyMountinfo,
+	} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(contents), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	SetProcRoot(root)
+	t.Cleanup(func() { SetProcRoot("") })
+	if r := ProcRoot(); r != root {
+		t.Errorf("unexpected proc root %q; expected %q", r, root)
+	}
+
+	p, err := SelfSubsystemPath("memory")
+	if err != nil {
+		t.Fatalf("failed to resolve memory cgroup under %s: %s", root, err)
+	}
+	expPath := CGroupPath{
+		AbsPath:   "/sys/fs/cgroup/user.slice/user-1000.slice/session-2.scope",
+		MountPath: "/sys/fs/cgroup",
+		Mode:      CGModeV2,
+	}
+	if p != expPath {
+		t.Errorf("unexpected path:\n  got %+v\n want %+v", p, expPath)
+	}
+
+	SetProcRoot("")
+	if r := ProcRoot(); r != DefaultProcRoot {
+		t.Errorf("unexpected proc root %q after reset; expected %q", r, DefaultProcRoot)
+	}
+}
@@ -0,0 +1,99 @@
+package cgresolver
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// CGroupState is a snapshot of the parsed inputs used to resolve a
+// process's cgroup paths. It round-trips through encoding/json, so it can be
+// captured on one host (see DumpCGroupState) and used to reproduce cgroup
+// resolution elsewhere (see LoadCGroupState).
+type CGroupState struct {
+	// Mounts contains the cgroup and cgroup2 mounts from
+	// /proc/self/mountinfo
+	Mounts []Mount
+	// Subsystems contains the rows of /proc/cgroups
+	Subsystems []CGroupSubsystem
+	// Hierarchies contains the entries of /proc/<pid>/cgroup
+	Hierarchies []CGProcHierarchy
+}
+
+func readCGroupState(src cgSource, procSubDir string) (CGroupState, error) {
+	mounts, mountsErr := src.cgMounts()
+	if mountsErr != nil {
+		return CGroupState{}, fmt.Errorf("failed to parse mountinfo: %w", mountsErr)
+	}
+	subsystems, subsysErr := src.cgSubsystems()
+	if subsysErr != nil {
+		return CGroupState{}, fmt.Errorf("failed to read cgroup subsystems: %w", subsysErr)
+	}
+	hiers, hiersErr := src.procCGroups(procSubDir)
+	if hiersErr != nil {
+		return CGroupState{}, fmt.Errorf("failed to resolve cgroup controllers: %w", hiersErr)
+	}
+	return CGroupState{
+		Mounts:      mounts,
+		Subsystems:  subsystems,
+		Hierarchies: hiers,
+	}, nil
+}
+
+// stateSource is a cgSource backed by a snapshot
+type stateSource struct {
+	state *CGroupState
+}
+
+func (s stateSource) cgSubsystems() ([]CGroupSubsystem, error) {
+	return s.state.Subsystems, nil
+}
+
+func (s stateSource) procCGroups(string) ([]CGProcHierarchy, error) {
+	return s.state.Hierarchies, nil
+}
+
+func (s stateSource) cgMounts() ([]Mount, error) {
+	return s.state.Mounts, nil
+}
+
+// ResolveCGroupPath resolves the CGroupPath for a specific subsystem using
+// only the data in state, without touching the filesystem. It follows the
+// same logic as SelfSubsystemPath.
+func ResolveCGroupPath(state *CGroupState, subsystem string) (CGroupPath, error) {
+	return resolveSubsystemPath(stateSource{state: state}, "", subsystem)
+}
+
+// Resolver resolves cgroup paths for subsystems from a specific source of
+// cgroup information. (e.g. a snapshot loaded by LoadCGroupState)
+type Resolver struct {
+	src        cgSource
+	procSubDir string
+}
+
+// SubsystemPath returns a CGroupPath for the cgroup associated with a
+// specific subsystem.
+func (r *Resolver) SubsystemPath(subsystem string) (CGroupPath, error) {
+	return resolveSubsystemPath(r.src, r.procSubDir, subsystem)
+}
+
+// DumpCGroupState reads and parses /proc/self/mountinfo, /proc/cgroups and
+// /proc/self/cgroup, and serializes the result as JSON. This is intended
+// for inclusion in support bundles, so cgroup resolution on the host it was
+// captured on can be reproduced with LoadCGroupState.
+func DumpCGroupState() ([]byte, error) {
+	state, stateErr := readCGroupState(osCGSource{}, "self")
+	if stateErr != nil {
+		return nil, stateErr
+	}
+	return json.MarshalIndent(&state, "", "\t")
+}
+
+// LoadCGroupState parses the JSON output of DumpCGroupState, and returns a
+// Resolver that resolves paths using that snapshot.
+func LoadCGroupState(b []byte) (*Resolver, error) {
+	state := CGroupState{}
+	if err := json.Unmarshal(b, &state); err != nil {
+		return nil, fmt.Errorf("failed to decode cgroup state: %w", err)
+	}
+	return &Resolver{src: stateSource{state: &state}}, nil
+}
@@ -0,0 +1,66 @@
+package cgresolver
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCGroupStateRoundTrip(t *testing.T) {
+	src := fakeCGSource{
+		procCgroups:   testHybridProcCgroups,
+		procPidCgroup: testHybridProcPidCgroup,
+		mountinfo:     testHybridMountinfo,
+	}
+	state, stateErr := readCGroupState(&src, "self")
+	require.NoError(t, stateErr)
+
+	b, marshalErr := json.Marshal(&state)
+	require.NoError(t, marshalErr)
+
+	r, loadErr := LoadCGroupState(b)
+	require.NoError(t, loadErr)
+
+	for _, subsys := range []string{"memory", "cpu", "pids"} {
+		exp, expErr := ResolveCGroupPath(&state, subsys)
+		require.NoError(t, expErr)
+		p, pathErr := r.SubsystemPath(subsys)
+		require.NoError(t, pathErr)
+		assert.Equal(t, exp, p)
+	}
+	p, pathErr := r.SubsystemPath("memory")
+	require.NoError(t, pathErr)
+	assert.Equal(t, CGroupPath{
+		AbsPath:   "/sys/fs/cgroup/memory/foo/bar",
+		MountPath: "/sys/fs/cgroup/memory",
+		Mode:      CGModeV1,
+	}, p)
+
+	_, missingErr := r.SubsystemPath("rdma")
+	assert.Error(t, missingErr)
+}
+
+func TestLoadCGroupStateInvalid(t *testing.T) {
+	_, err := LoadCGroupState([]byte("{"))
+	assert.Error(t, err)
+}
+
+func TestDumpCGroupStateSelf(t *testing.T) {
+	b, dumpErr := DumpCGroupState()
+	if dumpErr != nil {
+		t.Skipf("unable to read cgroup state: %s", dumpErr)
+	}
+	r, loadErr := LoadCGroupState(b)
+	require.NoError(t, loadErr)
+
+	exp, expErr := SelfSubsystemPath("memory")
+	p, pathErr := r.SubsystemPath("memory")
+	if expErr != nil {
+		assert.Error(t, pathErr)
+		return
+	}
+	require.NoError(t, pathErr)
+	assert.Equal(t, exp, p)
+}
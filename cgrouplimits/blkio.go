@@ -0,0 +1,32 @@
+package cgrouplimits
+
+import "strconv"
+
+// DeviceID identifies a block device by its major and minor device numbers.
+type DeviceID struct {
+	Major uint32
+	Minor uint32
+}
+
+// String formats the device ID as "major:minor" (as used by the cgroup
+// io/blkio files)
+func (d DeviceID) String() string {
+	return strconv.FormatUint(uint64(d.Major), 10) + ":" + strconv.FormatUint(uint64(d.Minor), 10)
+}
+
+// IOStats contains the cumulative block-IO counters for a single device
+// within a cgroup.
+type IOStats struct {
+	// ReadBytes and WriteBytes are the number of bytes read from and
+	// written to the device
+	ReadBytes  int64
+	WriteBytes int64
+	// ReadIOs and WriteIOs are the number of read and write operations
+	// (IOPS, when differenced over an interval)
+	ReadIOs  int64
+	WriteIOs int64
+	// DiscardBytes and DiscardIOs are the number of bytes and operations
+	// discarded (trimmed). (zero on kernels that don't report discards)
+	DiscardBytes int64
+	DiscardIOs   int64
+}
@@ -0,0 +1,212 @@
+//go:build linux
+// +build linux
+
+package cgrouplimits
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+
+	"github.com/vimeo/procstats/cgresolver"
+)
+
+const (
+	// cgroups V1 files
+	cgroupV1BlkioServiceBytesFile = "blkio.throttle.io_service_bytes"
+	cgroupV1BlkioServicedFile     = "blkio.throttle.io_serviced"
+
+	// cgroups V2 files
+	cgroupV2IOStatFile = "io.stat"
+)
+
+func parseDeviceID(s string) (DeviceID, error) {
+	majStr, minStr, ok := strings.Cut(s, ":")
+	if !ok {
+		return DeviceID{}, fmt.Errorf("malformed device %q: missing ':'", s)
+	}
+	maj, majErr := strconv.ParseUint(majStr, 10, 32)
+	if majErr != nil {
+		return DeviceID{}, fmt.Errorf("failed to parse major number of device %q: %w", s, majErr)
+	}
+	min, minErr := strconv.ParseUint(minStr, 10, 32)
+	if minErr != nil {
+		return DeviceID{}, fmt.Errorf("failed to parse minor number of device %q: %w", s, minErr)
+	}
+	return DeviceID{Major: uint32(maj), Minor: uint32(min)}, nil
+}
+
+// parseCG2IOStatLine parses the key=value pairs following the device in an
+// io.stat line.
+func parseCG2IOStatLine(fields []string) (IOStats, error) {
+	out := IOStats{}
+	for _, field := range fields {
+		k, v, ok := strings.Cut(field, "=")
+		if !ok {
+			return IOStats{}, fmt.Errorf("malformed field %q: missing '='", field)
+		}
+		var dst *int64
+		switch k {
+		case "rbytes":
+			dst = &out.ReadBytes
+		case "wbytes":
+			dst = &out.WriteBytes
+		case "rios":
+			dst = &out.ReadIOs
+		case "wios":
+			dst = &out.WriteIOs
+		case "dbytes":
+			dst = &out.DiscardBytes
+		case "dios":
+			dst = &out.DiscardIOs
+		default:
+			// ignore fields from other io controller features
+			// (e.g. io.latency's "use_delay")
+			continue
+		}
+		val, parseErr := strconv.ParseInt(v, 10, 64)
+		if parseErr != nil {
+			return IOStats{}, fmt.Errorf("failed to parse %s value %q: %w", k, v, parseErr)
+		}
+		*dst = val
+	}
+	return out, nil
+}
+
+// parseCG2IOStat parses the contents of a cgroup v2 io.stat file. Malformed
+// lines are skipped, and reported in the returned error (which names the
+// device), alongside the stats for the well-formed lines.
+func parseCG2IOStat(contents []byte) (map[DeviceID]IOStats, error) {
+	out := map[DeviceID]IOStats{}
+	lineErrs := []error(nil)
+	for _, line := range strings.Split(string(contents), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		dev, devErr := parseDeviceID(fields[0])
+		if devErr != nil {
+			lineErrs = append(lineErrs, devErr)
+			continue
+		}
+		stats, lineErr := parseCG2IOStatLine(fields[1:])
+		if lineErr != nil {
+			lineErrs = append(lineErrs, fmt.Errorf("device %s: %w", dev, lineErr))
+			continue
+		}
+		out[dev] = stats
+	}
+	return out, errors.Join(lineErrs...)
+}
+
+// parseCG1BlkioFile parses the contents of a cgroup v1 blkio
+// io_service_bytes or io_serviced file, invoking set for each device's Read,
+// Write and Discard counters. Malformed lines are skipped and reported in the
+// returned error. (which names the device)
+func parseCG1BlkioFile(contents []byte, out map[DeviceID]IOStats,
+	set func(s *IOStats, op string, val int64)) error {
+	lineErrs := []error(nil)
+	for _, line := range strings.Split(string(contents), "\n") {
+		fields := strings.Fields(line)
+		// skip blank lines and the trailing "Total N" summary
+		if len(fields) == 0 || (len(fields) == 2 && fields[0] == "Total") {
+			continue
+		}
+		if len(fields) != 3 {
+			lineErrs = append(lineErrs, fmt.Errorf("malformed line %q: expected 3 fields, got %d",
+				line, len(fields)))
+			continue
+		}
+		dev, devErr := parseDeviceID(fields[0])
+		if devErr != nil {
+			lineErrs = append(lineErrs, devErr)
+			continue
+		}
+		val, parseErr := strconv.ParseInt(fields[2], 10, 64)
+		if parseErr != nil {
+			lineErrs = append(lineErrs, fmt.Errorf("device %s: failed to parse %s value %q: %w",
+				dev, fields[1], fields[2], parseErr))
+			continue
+		}
+		s := out[dev]
+		set(&s, fields[1], val)
+		out[dev] = s
+	}
+	return errors.Join(lineErrs...)
+}
+
+func readCGroupIOStats(f fs.FS, mode cgresolver.CGMode) (map[DeviceID]IOStats, error) {
+	switch mode {
+	case cgresolver.CGModeV1:
+		out := map[DeviceID]IOStats{}
+		bytesContents, bytesReadErr := fs.ReadFile(f, cgroupV1BlkioServiceBytesFile)
+		if bytesReadErr != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", cgroupV1BlkioServiceBytesFile, bytesReadErr)
+		}
+		opsContents, opsReadErr := fs.ReadFile(f, cgroupV1BlkioServicedFile)
+		if opsReadErr != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", cgroupV1BlkioServicedFile, opsReadErr)
+		}
+		bytesErr := parseCG1BlkioFile(bytesContents, out, func(s *IOStats, op string, val int64) {
+			switch op {
+			case "Read":
+				s.ReadBytes = val
+			case "Write":
+				s.WriteBytes = val
+			case "Discard":
+				s.DiscardBytes = val
+			}
+		})
+		if bytesErr != nil {
+			bytesErr = fmt.Errorf("malformed %s entries: %w", cgroupV1BlkioServiceBytesFile, bytesErr)
+		}
+		opsErr := parseCG1BlkioFile(opsContents, out, func(s *IOStats, op string, val int64) {
+			switch op {
+			case "Read":
+				s.ReadIOs = val
+			case "Write":
+				s.WriteIOs = val
+			case "Discard":
+				s.DiscardIOs = val
+			}
+		})
+		if opsErr != nil {
+			opsErr = fmt.Errorf("malformed %s entries: %w", cgroupV1BlkioServicedFile, opsErr)
+		}
+		return out, errors.Join(bytesErr, opsErr)
+	case cgresolver.CGModeV2:
+		contents, readErr := fs.ReadFile(f, cgroupV2IOStatFile)
+		if readErr != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", cgroupV2IOStatFile, readErr)
+		}
+		out, parseErr := parseCG2IOStat(contents)
+		if parseErr != nil {
+			return out, fmt.Errorf("malformed %s entries: %w", cgroupV2IOStatFile, parseErr)
+		}
+		return out, nil
+	default:
+		return nil, fmt.Errorf("unknown cgroup type: %d", mode)
+	}
+}
+
+// GetCgroupIOStats returns the cumulative block-IO counters for each device
+// accessed by the current process's io (blkio under cgroup v1) cgroup.
+// Malformed per-device entries are skipped; if there are any, the stats for
+// the remaining devices are returned along with an error naming the skipped
+// devices.
+func GetCgroupIOStats() (map[DeviceID]IOStats, error) {
+	return GetCgroupIOStatsWithCache(context.Background(), nil)
+}
+
+// GetCgroupIOStatsWithCache is GetCgroupIOStats, but resolves the io cgroup
+// with cgr. (see GetCgroupCPUStatsWithCache)
+func GetCgroupIOStatsWithCache(ctx context.Context, cgr *cgresolver.Cache) (map[DeviceID]IOStats, error) {
+	ioPath, f, resolveErr := resolveCgroupDir(ctx, cacheOrNew(cgr), "blkio")
+	if resolveErr != nil {
+		return nil, resolveErr
+	}
+	return readCGroupIOStats(f, ioPath.Mode)
+}
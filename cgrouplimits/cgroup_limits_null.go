@@ -0,0 +1,30 @@
+//go:build !linux && !windows
+// +build !linux,!windows
+
+package cgrouplimits
+
+import "context"
+
+// GetCgroupCPULimit fetches the Cgroup's CPU limit
+func GetCgroupCPULimit() (float64, error) {
+	return 0.0, ErrCGroupsNotSupported
+}
+
+// GetCgroupCPULimitContext is not implemented on this platform (returns
+// ErrCGroupsNotSupported)
+func GetCgroupCPULimitContext(ctx context.Context) (float64, error) {
+	return 0.0, ErrCGroupsNotSupported
+}
+
+// GetCgroupMemoryLimit looks up the current process's memory cgroup, and
+// returns the memory limit. (on unsupported systems it returns
+// ErrCGroupsNotSupported)
+func GetCgroupMemoryLimit() (int64, error) {
+	return 0, ErrCGroupsNotSupported
+}
+
+// GetCgroupMemoryLimitContext is not implemented on this platform (returns
+// ErrCGroupsNotSupported)
+func GetCgroupMemoryLimitContext(ctx context.Context) (int64, error) {
+	return 0, ErrCGroupsNotSupported
+}
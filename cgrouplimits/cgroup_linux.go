@@ -5,11 +5,13 @@ package cgrouplimits
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io/fs"
 	"math"
 	"os"
+	"path"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -32,23 +34,42 @@ const (
 	cgroupV1CpuSysUsageFile  = "cpuacct.usage_sys"
 	cgroupV1CpuAcctStatFile  = "cpuacct.stat"
 
-	cgroupV1MemLimitFile = "memory.limit_in_bytes"
-	cgroupV1MemUsageFile = "memory.usage_in_bytes"
+	cgroupV1MemLimitFile     = "memory.limit_in_bytes"
+	cgroupV1MemUsageFile     = "memory.usage_in_bytes"
+	cgroupV1MemSoftLimitFile = "memory.soft_limit_in_bytes"
 
 	cgroupV1MemOOMControlFile = "memory.oom_control"
 
+	cgroupV1MemswLimitFile = "memory.memsw.limit_in_bytes"
+	cgroupV1MemswUsageFile = "memory.memsw.usage_in_bytes"
+
+	// pids controller files (identical under cgroups V1 and V2)
+	cgroupPIDsCurrentFile = "pids.current"
+	cgroupPIDsMaxFile     = "pids.max"
+
 	// cgroups V2 files
 	cgroupV2CFSQuotaPeriodFile = "cpu.max"
 	cgroupV2MemLimitFile       = "memory.max"
 	cgroupV2MemEventsFile      = "memory.events"
 	cgroupV2MemCurrentFile     = "memory.current"
+	cgroupV2SwapLimitFile      = "memory.swap.max"
+	cgroupV2SwapCurrentFile    = "memory.swap.current"
+	cgroupV2MemHighFile        = "memory.high"
+	cgroupV2MemLowFile         = "memory.low"
+	cgroupV2MemMinFile         = "memory.min"
 )
 
-func getCGroupCPULimitSingle(cpuPath *cgresolver.CGroupPath) (float64, error) {
-	switch cpuPath.Mode {
-	case cgresolver.CGModeV1:
-		f := os.DirFS(cpuPath.AbsPath)
+// cgroupDirFS returns an fs.FS rooted at the cgroup directory cgPath within
+// root (an fs.FS rooted at the filesystem root).
+func cgroupDirFS(root fs.FS, cgPath *cgresolver.CGroupPath) (fs.FS, error) {
+	return fs.Sub(root, rootFSPath(cgPath.AbsPath, "."))
+}
 
+// getCGroupCPULimitSingle reads the CPU limit of the single cgroup rooted at
+// f (not its ancestors).
+func getCGroupCPULimitSingle(f fs.FS, mode cgresolver.CGMode) (float64, error) {
+	switch mode {
+	case cgresolver.CGModeV1:
 		quotaµs, quotaReadErr := readIntValFile(f, cgroupV1CFSQuotaFile)
 		if quotaReadErr != nil {
 			return -1.0, fmt.Errorf("failed to read quota file %s", quotaReadErr)
@@ -65,8 +86,8 @@ func getCGroupCPULimitSingle(cpuPath *cgresolver.CGroupPath) (float64, error) {
 		}
 		return float64(quotaµs) / float64(periodµs), nil
 	case cgresolver.CGModeV2:
-		maxPath := filepath.Join(cpuPath.AbsPath, cgroupV2CFSQuotaPeriodFile)
-		quotaStr, quotaReadErr := os.ReadFile(maxPath)
+		const maxPath = cgroupV2CFSQuotaPeriodFile
+		quotaStr, quotaReadErr := fs.ReadFile(f, maxPath)
 		if quotaReadErr != nil {
 			return -1.0, fmt.Errorf("failed to read max CPU file %q: %w", maxPath, quotaReadErr)
 		}
@@ -94,23 +115,96 @@ func getCGroupCPULimitSingle(cpuPath *cgresolver.CGroupPath) (float64, error) {
 
 		return float64(limitμs) / float64(periodμs), nil
 	default:
-		return -1.0, fmt.Errorf("unknown cgroup type: %d", cpuPath.Mode)
+		return -1.0, fmt.Errorf("unknown cgroup type: %d", mode)
+	}
+}
+
+// cacheOrNew returns cgr, or a new Cache for the current process if cgr is
+// nil.
+func cacheOrNew(cgr *cgresolver.Cache) *cgresolver.Cache {
+	if cgr == nil {
+		return cgresolver.NewCache()
 	}
+	return cgr
 }
 
-// GetCgroupCPULimit fetches the Cgroup's CPU limit
+// resolveCgroupDir resolves the cgroup for subsystem with cgr, and returns
+// its path along with an fs.FS rooted at its directory.
+func resolveCgroupDir(ctx context.Context, cgr *cgresolver.Cache, subsystem string) (cgresolver.CGroupPath, fs.FS, error) {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return cgresolver.CGroupPath{}, nil, ctxErr
+	}
+	cgPath, cgroupFindErr := cgr.Resolve(subsystem)
+	if cgroupFindErr != nil {
+		return cgresolver.CGroupPath{}, nil, fmt.Errorf("unable to find cgroup directory: %w", cgroupFindErr)
+	}
+	f, subErr := cgroupDirFS(os.DirFS("/"), &cgPath)
+	if subErr != nil {
+		return cgresolver.CGroupPath{}, nil, fmt.Errorf("invalid cgroup path %q: %w", cgPath.AbsPath, subErr)
+	}
+	return cgPath, f, nil
+}
+
+// GetCgroupCPULimit fetches the Cgroup's CPU limit: the most restrictive
+// quota (in CPUs) of the current process's cpu cgroup and its ancestors, or
+// 0 if none of them has a quota.
 func GetCgroupCPULimit() (float64, error) {
-	cpuPath, cgroupFindErr := cgresolver.SelfSubsystemPath("cpu")
+	return GetCgroupCPULimitContext(context.Background())
+}
+
+// GetCgroupCPULimitContext is GetCgroupCPULimit, but gives up (returning
+// ctx.Err()) if ctx is done before the cgroup hierarchy has been walked.
+func GetCgroupCPULimitContext(ctx context.Context) (float64, error) {
+	return cgroupCPULimit(ctx, cgresolver.NewCache())
+}
+
+// GetCgroupCPULimitWithCache is GetCgroupCPULimitContext, but resolves the
+// cpu cgroup with cgr. (see GetCgroupCPUStatsWithCache)
+func GetCgroupCPULimitWithCache(ctx context.Context, cgr *cgresolver.Cache) (float64, error) {
+	return cgroupCPULimit(ctx, cacheOrNew(cgr))
+}
+
+// cgroupCPULimit resolves the cpu cgroup with cgr, and returns its CPU
+// limit.
+func cgroupCPULimit(ctx context.Context, cgr *cgresolver.Cache) (float64, error) {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return -1.0, ctxErr
+	}
+	cpuPath, cgroupFindErr := cgr.Resolve("cpu")
 	if cgroupFindErr != nil {
 		return -1.0, fmt.Errorf("unable to find cgroup directory: %s", cgroupFindErr)
 	}
+	return getCgroupCPULimitFS(ctx, os.DirFS("/"), cpuPath)
+}
+
+// GetCgroupCPULimitFS is GetCgroupCPULimit for the cpu cgroup cpuPath,
+// reading from root (an fs.FS rooted at the filesystem root) rather than the
+// host's filesystem.
+func GetCgroupCPULimitFS(root fs.FS, cpuPath cgresolver.CGroupPath) (float64, error) {
+	return getCgroupCPULimitFS(context.Background(), root, cpuPath)
+}
 
+// ReadCgroupCPULimitFS reads the CPU limit of the single cgroup directory f
+// (ancestors are not consulted) using the file layout of the cgroup version
+// mode. A return value of 0 indicates that the cgroup has no limit.
+func ReadCgroupCPULimitFS(f fs.FS, mode cgresolver.CGMode) (float64, error) {
+	return getCGroupCPULimitSingle(f, mode)
+}
+
+func getCgroupCPULimitFS(ctx context.Context, root fs.FS, cpuPath cgresolver.CGroupPath) (float64, error) {
 	minLimit := math.Inf(+1)
 	allFailed := true
 	leafCGReadErr := error(nil)
 
 	for newDir := true; newDir; cpuPath, newDir = cpuPath.Parent() {
-		cgLim, cgReadErr := getCGroupCPULimitSingle(&cpuPath)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return -1.0, ctxErr
+		}
+		f, subErr := cgroupDirFS(root, &cpuPath)
+		if subErr != nil {
+			return -1, fmt.Errorf("invalid cgroup path %q: %w", cpuPath.AbsPath, subErr)
+		}
+		cgLim, cgReadErr := getCGroupCPULimitSingle(f, cpuPath.Mode)
 		if cgReadErr != nil {
 			if leafCGReadErr == nil && allFailed {
 				leafCGReadErr = cgReadErr
@@ -126,16 +220,93 @@ func GetCgroupCPULimit() (float64, error) {
 	if allFailed {
 		return -1, leafCGReadErr
 	}
+	if math.IsInf(minLimit, +1) {
+		// no level has a quota: use the same "unlimited" value as
+		// getCGroupCPULimitSingle
+		return 0.0, nil
+	}
 	return minLimit, nil
 }
 
 // GetCgroupMemoryLimit looks up the current process's memory cgroup, and
-// returns the memory limit.
+// returns the most restrictive memory limit of it and its ancestors, or -1
+// if none of them has a limit.
+// Limits at or above the host's physical memory (MemTotal in /proc/meminfo;
+// swap doesn't count, as these limits only cover RAM) can't constrain
+// anything, so they're treated as no limit.
 func GetCgroupMemoryLimit() (int64, error) {
-	memPath, cgroupFindErr := cgresolver.SelfSubsystemPath("memory")
+	return GetCgroupMemoryLimitContext(context.Background())
+}
+
+// GetCgroupMemoryLimitDetailed is GetCgroupMemoryLimit, but also returns the
+// cgroup the limit came from: either the current process's memory cgroup
+// itself, or the ancestor it inherits its (tighter) limit from.
+// source is the zero CGroupPath if none of them has a limit.
+func GetCgroupMemoryLimitDetailed() (limit int64, source cgresolver.CGroupPath, err error) {
+	return cgroupMemoryLimit(context.Background(), cgresolver.NewCache())
+}
+
+// GetCgroupMemoryLimitContext is GetCgroupMemoryLimit, but gives up
+// (returning ctx.Err()) if ctx is done before the cgroup hierarchy has been
+// walked.
+func GetCgroupMemoryLimitContext(ctx context.Context) (int64, error) {
+	limit, _, err := cgroupMemoryLimit(ctx, cgresolver.NewCache())
+	return limit, err
+}
+
+// GetCgroupMemoryLimitWithCache is GetCgroupMemoryLimitContext, but resolves
+// the memory cgroup with cgr. (see GetCgroupCPUStatsWithCache)
+func GetCgroupMemoryLimitWithCache(ctx context.Context, cgr *cgresolver.Cache) (int64, error) {
+	limit, _, err := cgroupMemoryLimit(ctx, cacheOrNew(cgr))
+	return limit, err
+}
+
+// GetCgroupMemoryLimitForPID is GetCgroupMemoryLimit for the process with
+// PID pid, rather than the current process.
+func GetCgroupMemoryLimitForPID(pid int) (int64, error) {
+	limit, _, err := cgroupMemoryLimit(context.Background(), cgresolver.NewPIDCache(pid))
+	return limit, err
+}
+
+// cgroupMemoryLimit resolves the memory cgroup with cgr, and returns its
+// memory limit, along with the cgroup that limit was set on.
+func cgroupMemoryLimit(ctx context.Context, cgr *cgresolver.Cache) (int64, cgresolver.CGroupPath, error) {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return -1, cgresolver.CGroupPath{}, ctxErr
+	}
+	memPath, cgroupFindErr := cgr.Resolve("memory")
 	if cgroupFindErr != nil {
-		return -1, fmt.Errorf("unable to find cgroup directory: %s", cgroupFindErr)
+		return -1, cgresolver.CGroupPath{}, fmt.Errorf("unable to find cgroup directory: %s", cgroupFindErr)
+	}
+	return getCgroupMemoryLimitFS(ctx, os.DirFS("/"), memPath, hostMemTotal())
+}
+
+// hostMemTotal returns the host's physical memory (MemTotal) in bytes, or 0
+// if it can't be read.
+// Memory limits at or above it can't constrain anything, so they're treated
+// as unlimited. (memory.max and memory.limit_in_bytes only limit RAM, so swap
+// doesn't count) That's only a refinement, so failing to read it isn't fatal.
+func hostMemTotal() int64 {
+	mi, err := getMemInfo()
+	if err != nil {
+		return 0
 	}
+	return mi.MemTotal
+}
+
+// GetCgroupMemoryLimitFS is GetCgroupMemoryLimit for the memory cgroup
+// memPath, reading from root (an fs.FS rooted at the filesystem root) rather
+// than the host's filesystem.
+func GetCgroupMemoryLimitFS(root fs.FS, memPath cgresolver.CGroupPath) (int64, error) {
+	limit, _, err := getCgroupMemoryLimitFS(context.Background(), root, memPath, 0)
+	return limit, err
+}
+
+// getCgroupMemoryLimitFS walks up from memPath, returning the most
+// restrictive memory limit (-1 if unlimited) and the cgroup it's set on.
+// Limits at or above hostTotal are treated as unlimited (if it's
+// positive).
+func getCgroupMemoryLimitFS(ctx context.Context, root fs.FS, memPath cgresolver.CGroupPath, hostTotal int64) (int64, cgresolver.CGroupPath, error) {
 	memLimitFilename := ""
 	switch memPath.Mode {
 	case cgresolver.CGModeV1:
@@ -143,16 +314,23 @@ func GetCgroupMemoryLimit() (int64, error) {
 	case cgresolver.CGModeV2:
 		memLimitFilename = cgroupV2MemLimitFile
 	default:
-		return -1, fmt.Errorf("unknown cgroup type: %d", memPath.Mode)
+		return -1, cgresolver.CGroupPath{}, fmt.Errorf("unknown cgroup type: %d", memPath.Mode)
 	}
 
 	minLimit := int64(math.MaxInt64)
+	minLimitSource := cgresolver.CGroupPath{}
 
 	allFailed := true
 	leafCGReadErr := error(nil)
 
 	for newDir := true; newDir; memPath, newDir = memPath.Parent() {
-		f := os.DirFS(memPath.AbsPath)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return -1, cgresolver.CGroupPath{}, ctxErr
+		}
+		f, subErr := cgroupDirFS(root, &memPath)
+		if subErr != nil {
+			return -1, cgresolver.CGroupPath{}, fmt.Errorf("invalid cgroup path %q: %w", memPath.AbsPath, subErr)
+		}
 
 		limitBytes, limitReadErr := readIntValFile(f, memLimitFilename)
 		if limitReadErr != nil {
@@ -162,14 +340,268 @@ func GetCgroupMemoryLimit() (int64, error) {
 			continue
 		}
 		allFailed = false
-		if limitBytes > 0 && limitBytes < minLimit {
+		// cgroup v1 reports "no limit" as a huge page-aligned value
+		// rather than "max", so ignore anything above the threshold.
+		if limitBytes > 0 && limitBytes < cgroupV1UnlimitedThreshold && limitBytes < minLimit &&
+			!exceedsHostMemory(limitBytes, hostTotal) {
 			minLimit = limitBytes
+			minLimitSource = memPath
 		}
 	}
 	if allFailed {
-		return -1, leafCGReadErr
+		return -1, cgresolver.CGroupPath{}, leafCGReadErr
 	}
-	return minLimit, nil
+	if minLimit == math.MaxInt64 {
+		// unlimited
+		return -1, cgresolver.CGroupPath{}, nil
+	}
+	return minLimit, minLimitSource, nil
+}
+
+// exceedsHostMemory returns whether the memory limit limitBytes is at or
+// above hostTotal (if known, i.e. positive), so can't constrain anything.
+func exceedsHostMemory(limitBytes, hostTotal int64) bool {
+	return hostTotal > 0 && limitBytes >= hostTotal
+}
+
+// rootFSPath converts the absolute path of a file within a cgroup directory
+// to a path within an fs.FS rooted at the filesystem root.
+func rootFSPath(cgDir, name string) string {
+	return path.Join(strings.TrimPrefix(cgDir, "/"), name)
+}
+
+// readCGroupPIDStats reads pids.current from the cgroup at pidsPath, and the
+// most restrictive pids.max from it and its ancestors. f must be rooted at
+// the filesystem root.
+func readCGroupPIDStats(f fs.FS, pidsPath cgresolver.CGroupPath) (PIDStats, error) {
+	cur, curErr := readIntValFile(f, rootFSPath(pidsPath.AbsPath, cgroupPIDsCurrentFile))
+	if curErr != nil {
+		return PIDStats{}, fmt.Errorf("failed to read cgroup task count: %w", curErr)
+	}
+
+	minLimit := int64(math.MaxInt64)
+	allFailed := true
+	leafCGReadErr := error(nil)
+
+	for newDir := true; newDir; pidsPath, newDir = pidsPath.Parent() {
+		lim, limReadErr := readIntValFile(f, rootFSPath(pidsPath.AbsPath, cgroupPIDsMaxFile))
+		if limReadErr != nil {
+			// The root cgroup has no pids.max file.
+			if leafCGReadErr == nil && allFailed {
+				leafCGReadErr = fmt.Errorf("failed to read cgroup pids limit file: %w", limReadErr)
+			}
+			continue
+		}
+		allFailed = false
+		if lim >= 0 && lim < minLimit {
+			minLimit = lim
+		}
+	}
+	if allFailed {
+		return PIDStats{}, leafCGReadErr
+	}
+	return PIDStats{Current: cur, Limit: minLimit}, nil
+}
+
+// GetCgroupPIDStats returns the number of tasks in the current process's pids
+// cgroup, along with the most restrictive limit on that number from it and
+// its ancestors (math.MaxInt64 if unlimited).
+func GetCgroupPIDStats() (PIDStats, error) {
+	return cgroupPIDStats(cgresolver.NewCache())
+}
+
+// GetCgroupPIDStatsWithCache is GetCgroupPIDStats, but resolves the pids
+// cgroup with cgr. (see GetCgroupCPUStatsWithCache)
+func GetCgroupPIDStatsWithCache(ctx context.Context, cgr *cgresolver.Cache) (PIDStats, error) {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return PIDStats{}, ctxErr
+	}
+	return cgroupPIDStats(cacheOrNew(cgr))
+}
+
+// cgroupPIDStats resolves the pids cgroup with cgr, and returns its task
+// count and limit.
+func cgroupPIDStats(cgr *cgresolver.Cache) (PIDStats, error) {
+	pidsPath, cgroupFindErr := cgr.Resolve("pids")
+	if cgroupFindErr != nil {
+		return PIDStats{}, fmt.Errorf("unable to find cgroup directory: %w", cgroupFindErr)
+	}
+	return readCGroupPIDStats(os.DirFS("/"), pidsPath)
+}
+
+// optionalCGroupPIDStats is cgroupPIDStats, but returns zero PIDStats rather
+// than an error if the pids controller is unavailable: either because there's
+// no (mounted) pids hierarchy to resolve, as is common on cgroup v1 and
+// hybrid hosts, or because its files aren't present (e.g. the v1 root has
+// no pids.current).
+func optionalCGroupPIDStats(root fs.FS, cgr *cgresolver.Cache) (PIDStats, error) {
+	pidsPath, cgroupFindErr := cgr.Resolve("pids")
+	if cgroupFindErr != nil {
+		return PIDStats{}, nil
+	}
+	pids, pidsErr := readCGroupPIDStats(root, pidsPath)
+	if errors.Is(pidsErr, fs.ErrNotExist) {
+		return PIDStats{}, nil
+	}
+	return pids, pidsErr
+}
+
+// cgroupV1UnlimitedThreshold is the threshold above which cgroup v1 limits
+// are considered unlimited. (v1 reports "no limit" as the largest
+// page-aligned int64, rather than "max")
+const cgroupV1UnlimitedThreshold = math.MaxInt64 / 2
+
+// readSwapStats reads the swap usage and limit for a single cgroup.
+func readSwapStats(f fs.FS, mode cgresolver.CGMode) (SwapStats, error) {
+	switch mode {
+	case cgresolver.CGModeV1:
+		// cgroup v1 only accounts for memory+swap together, so
+		// subtract out the memory usage and limit.
+		memswUsage, memswUsageErr := readIntValFile(f, cgroupV1MemswUsageFile)
+		if memswUsageErr != nil {
+			return SwapStats{}, fmt.Errorf("failed to read memory+swap usage: %w", memswUsageErr)
+		}
+		memUsage, memUsageErr := readIntValFile(f, cgroupV1MemUsageFile)
+		if memUsageErr != nil {
+			return SwapStats{}, fmt.Errorf("failed to read memory usage: %w", memUsageErr)
+		}
+		memswLimit, memswLimitErr := readIntValFile(f, cgroupV1MemswLimitFile)
+		if memswLimitErr != nil {
+			return SwapStats{}, fmt.Errorf("failed to read memory+swap limit: %w", memswLimitErr)
+		}
+		memLimit, memLimitErr := readIntValFile(f, cgroupV1MemLimitFile)
+		if memLimitErr != nil {
+			return SwapStats{}, fmt.Errorf("failed to read memory limit: %w", memLimitErr)
+		}
+		swapLimit := int64(math.MaxInt64)
+		if memswLimit < cgroupV1UnlimitedThreshold {
+			swapLimit = memswLimit - memLimit
+		}
+		return SwapStats{
+			SwapUsage: max(memswUsage-memUsage, 0),
+			SwapLimit: swapLimit,
+		}, nil
+	case cgresolver.CGModeV2:
+		usage, usageErr := readIntValFile(f, cgroupV2SwapCurrentFile)
+		if usageErr != nil {
+			return SwapStats{}, fmt.Errorf("failed to read swap usage: %w", usageErr)
+		}
+		limit, limitErr := readIntValFile(f, cgroupV2SwapLimitFile)
+		if limitErr != nil {
+			return SwapStats{}, fmt.Errorf("failed to read swap limit: %w", limitErr)
+		}
+		return SwapStats{SwapUsage: usage, SwapLimit: limit}, nil
+	default:
+		return SwapStats{}, fmt.Errorf("unknown cgroup type: %d", mode)
+	}
+}
+
+// readOptionalSwapStats is readSwapStats, but returns zero SwapStats if swap
+// accounting is unavailable (the relevant files are absent).
+func readOptionalSwapStats(f fs.FS, mode cgresolver.CGMode) (SwapStats, error) {
+	swap, swapErr := readSwapStats(f, mode)
+	if swapErr != nil {
+		if errors.Is(swapErr, fs.ErrNotExist) {
+			return SwapStats{}, nil
+		}
+		return SwapStats{}, fmt.Errorf("failed to read swap stats: %w", swapErr)
+	}
+	return swap, nil
+}
+
+// GetCgroupSwapStats returns the swap usage and limit of the current
+// process's memory cgroup. Only the process's own cgroup is consulted (not
+// its ancestors).
+// If swap accounting is disabled (e.g. booted with swapaccount=0), the
+// relevant files are absent, and the returned error wraps fs.ErrNotExist.
+func GetCgroupSwapStats() (SwapStats, error) {
+	return cgroupSwapStats(context.Background(), cgresolver.NewCache())
+}
+
+// GetCgroupSwapStatsWithCache is GetCgroupSwapStats, but resolves the memory
+// cgroup with cgr. (see GetCgroupCPUStatsWithCache)
+func GetCgroupSwapStatsWithCache(ctx context.Context, cgr *cgresolver.Cache) (SwapStats, error) {
+	return cgroupSwapStats(ctx, cacheOrNew(cgr))
+}
+
+func cgroupSwapStats(ctx context.Context, cgr *cgresolver.Cache) (SwapStats, error) {
+	memPath, f, resolveErr := resolveCgroupDir(ctx, cgr, "memory")
+	if resolveErr != nil {
+		return SwapStats{}, resolveErr
+	}
+	return readSwapStats(f, memPath.Mode)
+}
+
+// readMemoryThresholds reads the memory protections and limits of a single
+// cgroup.
+func readMemoryThresholds(f fs.FS, mode cgresolver.CGMode) (MemoryThresholds, error) {
+	switch mode {
+	case cgresolver.CGModeV1:
+		// cgroup v1 has no memory protections, and the soft limit is
+		// the closest analogue of memory.high.
+		out := MemoryThresholds{}
+		for _, th := range [...]struct {
+			file string
+			out  *int64
+		}{
+			{file: cgroupV1MemSoftLimitFile, out: &out.High},
+			{file: cgroupV1MemLimitFile, out: &out.Max},
+		} {
+			v, readErr := readIntValFile(f, th.file)
+			if readErr != nil {
+				return MemoryThresholds{}, fmt.Errorf("failed to read %q: %w", th.file, readErr)
+			}
+			if v >= cgroupV1UnlimitedThreshold {
+				v = math.MaxInt64
+			}
+			*th.out = v
+		}
+		return out, nil
+	case cgresolver.CGModeV2:
+		out := MemoryThresholds{}
+		for _, th := range [...]struct {
+			file string
+			out  *int64
+		}{
+			{file: cgroupV2MemMinFile, out: &out.Min},
+			{file: cgroupV2MemLowFile, out: &out.Low},
+			{file: cgroupV2MemHighFile, out: &out.High},
+			{file: cgroupV2MemLimitFile, out: &out.Max},
+		} {
+			v, readErr := readIntValFile(f, th.file)
+			if readErr != nil {
+				return MemoryThresholds{}, fmt.Errorf("failed to read %q: %w", th.file, readErr)
+			}
+			*th.out = v
+		}
+		return out, nil
+	default:
+		return MemoryThresholds{}, fmt.Errorf("unknown cgroup type: %d", mode)
+	}
+}
+
+// GetCgroupMemoryThresholds returns the memory protections (memory.min and
+// memory.low) and limits (memory.high and memory.max) of the current
+// process's memory cgroup. Only the process's own cgroup is consulted (not
+// its ancestors).
+// Under cgroup v1, Min and Low are always zero, High is the soft limit
+// (memory.soft_limit_in_bytes) and Max is memory.limit_in_bytes.
+func GetCgroupMemoryThresholds() (MemoryThresholds, error) {
+	return cgroupMemoryThresholds(context.Background(), cgresolver.NewCache())
+}
+
+// GetCgroupMemoryThresholdsWithCache is GetCgroupMemoryThresholds, but
+// resolves the memory cgroup with cgr. (see GetCgroupCPUStatsWithCache)
+func GetCgroupMemoryThresholdsWithCache(ctx context.Context, cgr *cgresolver.Cache) (MemoryThresholds, error) {
+	return cgroupMemoryThresholds(ctx, cacheOrNew(cgr))
+}
+
+func cgroupMemoryThresholds(ctx context.Context, cgr *cgresolver.Cache) (MemoryThresholds, error) {
+	memPath, f, resolveErr := resolveCgroupDir(ctx, cgr, "memory")
+	if resolveErr != nil {
+		return MemoryThresholds{}, resolveErr
+	}
+	return readMemoryThresholds(f, memPath.Mode)
 }
 
 type cg1MemoryStatContents struct {
@@ -277,6 +709,86 @@ type cg2MemoryStatContents struct {
 
 var cg2MemStatFieldIdx = pparser.NewLineKVFileParser(cg2MemoryStatContents{}, " ")
 
+func readCG2MemoryStat(f fs.FS) (cg2MemoryStatContents, error) {
+	mstContents, readErr := fs.ReadFile(f, cgroupMemStatFile)
+	if readErr != nil {
+		return cg2MemoryStatContents{}, fmt.Errorf("failed to read memory.stat: %w", readErr)
+	}
+	cg2Stats := cg2MemoryStatContents{}
+	if parseErr := cg2MemStatFieldIdx.Parse(mstContents, &cg2Stats); parseErr != nil {
+		return cg2MemoryStatContents{}, fmt.Errorf("failed to parse memory.stat: %w", parseErr)
+	}
+	return cg2Stats, nil
+}
+
+// cgroupCG2MemoryStat resolves the memory cgroup with cgr, and reads its
+// memory.stat file. It fails if the memory controller isn't on the cgroup v2
+// hierarchy.
+func cgroupCG2MemoryStat(ctx context.Context, cgr *cgresolver.Cache) (cg2MemoryStatContents, error) {
+	memPath, f, resolveErr := resolveCgroupDir(ctx, cgr, "memory")
+	if resolveErr != nil {
+		return cg2MemoryStatContents{}, resolveErr
+	}
+	if memPath.Mode != cgresolver.CGModeV2 {
+		return cg2MemoryStatContents{}, fmt.Errorf("%w: memory controller is not on the cgroup v2 hierarchy",
+			ErrCGroupsNotSupported)
+	}
+	return readCG2MemoryStat(f)
+}
+
+// GetCgroupPageTableMemory returns the memory used for page tables by the
+// current process's memory cgroup (and descendants), along with the memory
+// used for secondary page tables (e.g. KVM's EPT/NPT and IOMMU page tables).
+// Page-table memory isn't included in RSS, so it can explain usage that's
+// significantly higher than the RSS of the processes in the cgroup would
+// suggest for processes with very large address spaces.
+// secPagetables is zero on kernels that predate sec_pagetables (added in
+// Linux 6.1).
+// This requires cgroup v2; on cgroup v1 it returns an error wrapping
+// ErrCGroupsNotSupported.
+func GetCgroupPageTableMemory() (pagetables, secPagetables int64, err error) {
+	return GetCgroupPageTableMemoryWithCache(context.Background(), nil)
+}
+
+// GetCgroupPageTableMemoryWithCache is GetCgroupPageTableMemory, but resolves
+// the memory cgroup with cgr. (see GetCgroupCPUStatsWithCache)
+func GetCgroupPageTableMemoryWithCache(ctx context.Context, cgr *cgresolver.Cache) (pagetables, secPagetables int64, err error) {
+	cg2Stats, statErr := cgroupCG2MemoryStat(ctx, cacheOrNew(cgr))
+	if statErr != nil {
+		return -1, -1, statErr
+	}
+	return cg2Stats.Pagetables, cg2Stats.SecondaryPagetables, nil
+}
+
+func (c *cg2MemoryStatContents) zswapStats() ZswapStats {
+	return ZswapStats{
+		PoolBytes:    c.Zswap,
+		SwappedBytes: c.Zswapped,
+		PagesIn:      c.ZswpIn,
+		PagesOut:     c.ZswpOut,
+	}
+}
+
+// GetCgroupZswapStats returns the zswap usage and activity of the current
+// process's memory cgroup (and descendants).
+// All values are zero if zswap is disabled (or the kernel predates zswap
+// accounting in memory.stat).
+// This requires cgroup v2; on cgroup v1 it returns an error wrapping
+// ErrCGroupsNotSupported.
+func GetCgroupZswapStats() (ZswapStats, error) {
+	return GetCgroupZswapStatsWithCache(context.Background(), nil)
+}
+
+// GetCgroupZswapStatsWithCache is GetCgroupZswapStats, but resolves the
+// memory cgroup with cgr. (see GetCgroupCPUStatsWithCache)
+func GetCgroupZswapStatsWithCache(ctx context.Context, cgr *cgresolver.Cache) (ZswapStats, error) {
+	cg2Stats, statErr := cgroupCG2MemoryStat(ctx, cacheOrNew(cgr))
+	if statErr != nil {
+		return ZswapStats{}, statErr
+	}
+	return cg2Stats.zswapStats(), nil
+}
+
 type cg2MemEvents struct {
 	Low          int64 `pparser:"low"`
 	High         int64 `pparser:"high"`
@@ -290,12 +802,13 @@ type cg2MemEvents struct {
 
 var cg2MemEventsFieldIdx = pparser.NewLineKVFileParser(cg2MemEvents{}, " ")
 
+// getCGroupMemoryStatsSingle reads the memory stats of the single cgroup
+// rooted at f (absPath is only used for error messages).
 // second return value is the memory limit for this CGroup (-1 is none)
-func getCGroupMemoryStatsSingle(memPath *cgresolver.CGroupPath) (MemoryStats, int64, error) {
-	switch memPath.Mode {
+func getCGroupMemoryStatsSingle(f fs.FS, mode cgresolver.CGMode, absPath string) (MemoryStats, int64, error) {
+	switch mode {
 	case cgresolver.CGModeV1:
-		f := os.DirFS(memPath.AbsPath)
-		ooms, oomErr := getV1CgroupOOMs()
+		ooms, oomErr := readV1CgroupOOMs(f)
 		if oomErr != nil {
 			return MemoryStats{}, -1, fmt.Errorf("failed to look up OOMKills: %s",
 				oomErr)
@@ -305,21 +818,30 @@ func getCGroupMemoryStatsSingle(memPath *cgresolver.CGroupPath) (MemoryStats, in
 		if limitErr != nil {
 			return MemoryStats{}, -1, fmt.Errorf("failed to read limit: %w", limitErr)
 		}
+		if limitBytes >= cgroupV1UnlimitedThreshold {
+			// "no limit" sentinel (the largest page-aligned int64)
+			limitBytes = -1
+		}
 
 		usageBytes, usageErr := readIntValFile(f, cgroupV1MemUsageFile)
 		if usageErr != nil {
 			return MemoryStats{}, -1, fmt.Errorf("failed to read memory usage: %w", usageErr)
 		}
 
-		mstContents, readErr := os.ReadFile(filepath.Join(memPath.AbsPath, cgroupMemStatFile))
+		mstContents, readErr := fs.ReadFile(f, cgroupMemStatFile)
 		if readErr != nil {
 			return MemoryStats{}, -1, fmt.Errorf("failed to read memory.stat file for cgroup (%q): %w",
-				filepath.Join(memPath.AbsPath, cgroupMemStatFile), readErr)
+				filepath.Join(absPath, cgroupMemStatFile), readErr)
 		}
 		cg1Stats := cg1MemoryStatContents{}
 		if parseErr := cg1MemStatFieldIdx.Parse(mstContents, &cg1Stats); parseErr != nil {
 			return MemoryStats{}, -1, fmt.Errorf("failed to parse memory.stat file for cgroup (%q): %w",
-				filepath.Join(memPath.AbsPath, cgroupCpuStatFile), parseErr)
+				filepath.Join(absPath, cgroupMemStatFile), parseErr)
+		}
+
+		swap, swapErr := readOptionalSwapStats(f, mode)
+		if swapErr != nil {
+			return MemoryStats{}, -1, swapErr
 		}
 
 		ms := MemoryStats{
@@ -327,73 +849,169 @@ func getCGroupMemoryStatsSingle(memPath *cgresolver.CGroupPath) (MemoryStats, in
 			Free:      limitBytes - usageBytes,
 			Available: limitBytes - usageBytes + cg1Stats.TotalCache,
 			OOMKills:  int64(ooms),
+			SwapTotal: swap.SwapLimit,
+			SwapUsed:  swap.SwapUsage,
 		}
 		return ms, limitBytes, nil
 	case cgresolver.CGModeV2:
-		f := os.DirFS(memPath.AbsPath)
-		mstContents, memStatErr := fs.ReadFile(f, cgroupMemStatFile)
-		if memStatErr != nil {
-			return MemoryStats{}, -1, fmt.Errorf("failed to read memory.stat: %w", memStatErr)
-		}
-		cg2Stats := cg2MemoryStatContents{}
-		if parseErr := cg2MemStatFieldIdx.Parse(mstContents, &cg2Stats); parseErr != nil {
-			return MemoryStats{}, -1, fmt.Errorf("failed to parse memory.stat file for cgroup (%q): %w",
-				filepath.Join(memPath.AbsPath, cgroupMemStatFile), parseErr)
-		}
-		mevContents, memEventsErr := fs.ReadFile(f, cgroupV2MemEventsFile)
-		if memEventsErr != nil {
-			return MemoryStats{}, -1, fmt.Errorf("failed to read memory.events: %w", memEventsErr)
-		}
-		cg2Events := cg2MemEvents{}
+		return getCGroupV2MemoryStats(f, absPath)
+	default:
+		return MemoryStats{}, -1, fmt.Errorf("unknown cgroup type: %d", mode)
+	}
+}
+
+// getCGroupV2MemoryStats reads the memory stats for the cgroup v2 cgroup
+// rooted at f (absPath is only used for error messages).
+// second return value is the memory limit for this CGroup (-1 is none)
+func getCGroupV2MemoryStats(f fs.FS, absPath string) (MemoryStats, int64, error) {
+	cg2Stats, memStatErr := readCG2MemoryStat(f)
+	if memStatErr != nil {
+		return MemoryStats{}, -1, fmt.Errorf("failed to read memory.stat for cgroup (%q): %w",
+			absPath, memStatErr)
+	}
+	// Some restricted (delegated/rootless) cgroups don't expose
+	// memory.events, so the OOM-kill count is best-effort: leave it
+	// zero if the file can't be read.
+	cg2Events := cg2MemEvents{}
+	if mevContents, memEventsErr := fs.ReadFile(f, cgroupV2MemEventsFile); memEventsErr == nil {
 		if parseErr := cg2MemEventsFieldIdx.Parse(mevContents, &cg2Events); parseErr != nil {
 			return MemoryStats{}, -1, fmt.Errorf("failed to parse memory.events file for cgroup (%q): %w",
-				filepath.Join(memPath.AbsPath, cgroupV2MemEventsFile), parseErr)
+				filepath.Join(absPath, cgroupV2MemEventsFile), parseErr)
 		}
+	}
 
-		usageBytes, usageErr := readIntValFile(f, cgroupV2MemCurrentFile)
-		if usageErr != nil {
-			return MemoryStats{}, -1, fmt.Errorf("failed to parse memory.current file for cgroup : %w", usageErr)
-		}
-		limitBytes, limitReadErr := readIntValFile(f, cgroupV2MemLimitFile)
-		if limitReadErr != nil {
-			if !errors.Is(limitReadErr, fs.ErrNotExist) {
-				return MemoryStats{}, -1, fmt.Errorf("failed to read cgroup memory limit file  %s",
-					limitReadErr)
-			}
-			limitBytes = -1
+	usageBytes, usageErr := readIntValFile(f, cgroupV2MemCurrentFile)
+	if usageErr != nil {
+		return MemoryStats{}, -1, fmt.Errorf("failed to parse memory.current file for cgroup : %w", usageErr)
+	}
+	limitBytes, limitReadErr := readIntValFile(f, cgroupV2MemLimitFile)
+	if limitReadErr != nil {
+		if !errors.Is(limitReadErr, fs.ErrNotExist) {
+			return MemoryStats{}, -1, fmt.Errorf("failed to read cgroup memory limit file  %s",
+				limitReadErr)
 		}
-
-		return MemoryStats{
-			Total: limitBytes,
-			Free:  limitBytes - usageBytes,
-			// TODO: verify that nothing here is getting double-counted
-			// subtract total usage from the limit, and add back some memory-categories that can be evicted.
-			// Notably, cached swap can be evicted immediately, as can any File memory that's not dirty or getting written back.
-			// SlabReclaimable is kernel memory that can be freed under memory pressure.
-			Available: limitBytes - usageBytes + cg2Stats.SwapCached + (cg2Stats.File - cg2Stats.FileDirty - cg2Stats.FileWriteback) + cg2Stats.SlabReclaimable,
-			OOMKills:  cg2Events.OOMGroupKill,
-		}, limitBytes, nil
-	default:
-		return MemoryStats{}, -1, fmt.Errorf("unknown cgroup type: %d", memPath.Mode)
+		limitBytes = -1
+	}
+	if limitBytes == math.MaxInt64 {
+		// "max" (no limit)
+		limitBytes = -1
+	}
+	swap, swapErr := readOptionalSwapStats(f, cgresolver.CGModeV2)
+	if swapErr != nil {
+		return MemoryStats{}, -1, swapErr
 	}
+
+	return MemoryStats{
+		Total: limitBytes,
+		Free:  limitBytes - usageBytes,
+		// TODO: verify that nothing here is getting double-counted
+		// subtract total usage from the limit, and add back some memory-categories that can be evicted.
+		// Notably, cached swap can be evicted immediately, as can any File memory that's not dirty or getting written back.
+		// SlabReclaimable is kernel memory that can be freed under memory pressure.
+		Available: limitBytes - usageBytes + cg2Stats.SwapCached + (cg2Stats.File - cg2Stats.FileDirty - cg2Stats.FileWriteback) + cg2Stats.SlabReclaimable,
+		OOMKills:  cg2Events.OOMGroupKill,
+		SwapTotal: swap.SwapLimit,
+		SwapUsed:  swap.SwapUsage,
+	}, limitBytes, nil
 }
 
 // GetCgroupMemoryStats queries the current process's memory cgroup's memory
 // usage/limits.
+// If neither the cgroup nor any of its ancestors has a memory limit (below
+// the host's physical memory), the host's total memory is used as the limit.
 func GetCgroupMemoryStats() (MemoryStats, error) {
-	memPath, cgroupFindErr := cgresolver.SelfSubsystemPath("memory")
+	return GetCgroupMemoryStatsContext(context.Background())
+}
+
+// GetCgroupMemoryStatsContext is GetCgroupMemoryStats, but gives up
+// (returning ctx.Err()) if ctx is done before the cgroup hierarchy has been
+// walked.
+func GetCgroupMemoryStatsContext(ctx context.Context) (MemoryStats, error) {
+	return cgroupMemoryStats(ctx, cgresolver.NewCache())
+}
+
+// GetCgroupMemoryStatsWithCache is GetCgroupMemoryStatsContext, but resolves
+// the memory cgroup with cgr. (see GetCgroupCPUStatsWithCache)
+func GetCgroupMemoryStatsWithCache(ctx context.Context, cgr *cgresolver.Cache) (MemoryStats, error) {
+	return cgroupMemoryStats(ctx, cacheOrNew(cgr))
+}
+
+// GetCgroupMemoryStatsForPID is GetCgroupMemoryStats for the process with
+// PID pid, rather than the current process.
+func GetCgroupMemoryStatsForPID(pid int) (MemoryStats, error) {
+	return cgroupMemoryStats(context.Background(), cgresolver.NewPIDCache(pid))
+}
+
+// cgroupMemoryStats resolves the memory cgroup with cgr, and returns its
+// memory usage/limits.
+func cgroupMemoryStats(ctx context.Context, cgr *cgresolver.Cache) (MemoryStats, error) {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return MemoryStats{}, ctxErr
+	}
+	memPath, cgroupFindErr := cgr.Resolve("memory")
 	if cgroupFindErr != nil {
 		return MemoryStats{}, fmt.Errorf("unable to find cgroup directory: %s", cgroupFindErr)
 	}
+	ms, msErr := getCgroupMemoryStatsFS(ctx, os.DirFS("/"), memPath, hostMemTotal())
+	if msErr != nil || ms.Total >= 0 {
+		return ms, msErr
+	}
+	// No limit applies, so the host's memory is the effective limit.
+	hostMS, hostErr := HostMemStats()
+	if hostErr != nil {
+		return MemoryStats{}, fmt.Errorf("cgroup memory is unlimited, and failed to read host memory stats: %w", hostErr)
+	}
+	return ms.withTotal(hostMS.Total), nil
+}
+
+// GetCgroupMemoryStatsFS is GetCgroupMemoryStats for the memory cgroup
+// memPath, reading from root (an fs.FS rooted at the filesystem root) rather
+// than the host's filesystem.
+// If neither the cgroup nor any of its ancestors has a memory limit, the
+// leaf cgroup's stats are returned with Total, Free and Available all -1.
+func GetCgroupMemoryStatsFS(root fs.FS, memPath cgresolver.CGroupPath) (MemoryStats, error) {
+	ms, err := getCgroupMemoryStatsFS(context.Background(), root, memPath, 0)
+	if err != nil || ms.Total >= 0 {
+		return ms, err
+	}
+	return ms.withoutLimit(), nil
+}
+
+// ReadCgroupMemoryStatsFS reads the memory stats of the single cgroup
+// directory f (ancestors' limits are not consulted) using the file layout of
+// the cgroup version mode. If the cgroup has no memory limit, Total, Free and
+// Available are all -1 (as with GetCgroupMemoryStatsFS).
+func ReadCgroupMemoryStatsFS(f fs.FS, mode cgresolver.CGMode) (MemoryStats, error) {
+	ms, _, err := getCGroupMemoryStatsSingle(f, mode, ".")
+	if err != nil || ms.Total >= 0 {
+		return ms, err
+	}
+	return ms.withoutLimit(), nil
+}
 
+// getCgroupMemoryStatsFS walks up from memPath, returning the memory stats of
+// the cgroup with the most restrictive memory limit, or if none is limited,
+// the leaf cgroup's stats with a Total of -1. (with Free and Available offset
+// from -1 by the usage, so withTotal can rebase them onto another total)
+// Limits at or above hostTotal are treated as unlimited (if it's
+// positive).
+func getCgroupMemoryStatsFS(ctx context.Context, root fs.FS, memPath cgresolver.CGroupPath, hostTotal int64) (MemoryStats, error) {
 	minLimit := uint64(math.MaxUint64)
 	minLimCGMemStats := MemoryStats{}
+	leafCGMemStats := MemoryStats{}
 	leafCGReadErr := error(nil)
 
 	allFailed := true
 
 	for newDir := true; newDir; memPath, newDir = memPath.Parent() {
-		cgMemStats, cgLim, cgReadErr := getCGroupMemoryStatsSingle(&memPath)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return MemoryStats{}, ctxErr
+		}
+		f, subErr := cgroupDirFS(root, &memPath)
+		if subErr != nil {
+			return MemoryStats{}, fmt.Errorf("invalid cgroup path %q: %w", memPath.AbsPath, subErr)
+		}
+		cgMemStats, cgLim, cgReadErr := getCGroupMemoryStatsSingle(f, memPath.Mode, memPath.AbsPath)
 		if cgReadErr != nil {
 			if leafCGReadErr == nil && allFailed {
 				leafCGReadErr = cgReadErr
@@ -401,8 +1019,11 @@ func GetCgroupMemoryStats() (MemoryStats, error) {
 			continue
 		}
 
+		if allFailed {
+			leafCGMemStats = cgMemStats
+		}
 		allFailed = false
-		if cgLim != -1 && uint64(cgLim) < minLimit {
+		if cgLim != -1 && uint64(cgLim) < minLimit && !exceedsHostMemory(cgLim, hostTotal) {
 			minLimit = uint64(cgLim)
 			minLimCGMemStats = cgMemStats
 		}
@@ -410,6 +1031,11 @@ func GetCgroupMemoryStats() (MemoryStats, error) {
 	if allFailed {
 		return MemoryStats{}, leafCGReadErr
 	}
+	if minLimit == math.MaxUint64 {
+		// unlimited (the leaf may still have a limit beyond the host's
+		// memory)
+		return leafCGMemStats.withTotal(-1), nil
+	}
 	return minLimCGMemStats, nil
 }
 
@@ -422,18 +1048,14 @@ type memCgroupOOMControl struct {
 
 var memCgroupOOMControlFieldIdx = pparser.NewLineKVFileParser(memCgroupOOMControl{}, " ")
 
-// getV1CgroupOOMs looks up the current number of oom kills for the current cgroup.
-func getV1CgroupOOMs() (int32, error) {
-	memPath, cgroupFindErr := cgresolver.SelfSubsystemPath("memory")
-	if cgroupFindErr != nil {
-		return -1, fmt.Errorf("unable to find cgroup directory: %s", cgroupFindErr)
-	}
-	oomControlPath := filepath.Join(memPath.AbsPath, cgroupV1MemOOMControlFile)
-	oomControlBytes, oomControlReadErr := os.ReadFile(oomControlPath)
+// readV1CgroupOOMs looks up the current number of oom kills for the cgroup
+// v1 memory cgroup rooted at f.
+func readV1CgroupOOMs(f fs.FS) (int32, error) {
+	oomControlBytes, oomControlReadErr := fs.ReadFile(f, cgroupV1MemOOMControlFile)
 	if oomControlReadErr != nil {
 		return 0, fmt.Errorf(
 			"failed to read contents of %q: %s",
-			oomControlPath, oomControlReadErr)
+			cgroupV1MemOOMControlFile, oomControlReadErr)
 	}
 	oomc := memCgroupOOMControl{}
 	parseErr := memCgroupOOMControlFieldIdx.Parse(oomControlBytes, &oomc)
@@ -546,69 +1168,184 @@ func CGroupV2CPUUsage(f fs.FS) (CPUStats, error) {
 	cg2Stats := cg2CPUStatContents{}
 	if parseErr := cg2CPUStatContentsFieldIdx.Parse(cstContents, &cg2Stats); parseErr != nil {
 		return CPUStats{}, fmt.Errorf("failed to parse cpu.stat file for cgroup: %w",
-			readErr)
+			parseErr)
 	}
 	return CPUStats{
 		Usage: procstats.CPUTime{
 			Utime: time.Duration(cg2Stats.Userμs) * time.Microsecond,
 			Stime: time.Duration(cg2Stats.Sysμs) * time.Microsecond,
 		},
-		ThrottledTime: time.Duration(cg2Stats.Throttledμs) * time.Microsecond,
+		ThrottledTime:    time.Duration(cg2Stats.Throttledμs) * time.Microsecond,
+		TotalPeriods:     cg2Stats.TotalPeriods,
+		ThrottledPeriods: cg2Stats.ThrottledPeriods,
 	}, nil
 }
 
-func getCGroupCPUStatsSingle(cpuPath *cgresolver.CGroupPath) (CPUStats, float64, error) {
-	lim, limErr := getCGroupCPULimitSingle(cpuPath)
+// readThrottleCounters reads the CFS bandwidth-control counters from the
+// cpu.stat file of the cgroup rooted at f.
+func readThrottleCounters(f fs.FS, mode cgresolver.CGMode) (throttleCounters, error) {
+	cstContents, readErr := fs.ReadFile(f, cgroupCpuStatFile)
+	if readErr != nil {
+		return throttleCounters{}, fmt.Errorf("failed to read cpu.stat file for cgroup: %w", readErr)
+	}
+	switch mode {
+	case cgresolver.CGModeV1:
+		cg1Stats := cg1CPUStatContents{}
+		if parseErr := cg1CPUStatContentsFieldIdx.Parse(cstContents, &cg1Stats); parseErr != nil {
+			return throttleCounters{}, fmt.Errorf("failed to parse cpu.stat file for cgroup: %w", parseErr)
+		}
+		return throttleCounters{
+			TotalPeriods:     cg1Stats.TotalPeriods,
+			ThrottledPeriods: cg1Stats.ThrottledPeriods,
+			ThrottledTime:    time.Duration(cg1Stats.Throttledns) * time.Nanosecond,
+		}, nil
+	case cgresolver.CGModeV2:
+		cg2Stats := cg2CPUStatContents{}
+		if parseErr := cg2CPUStatContentsFieldIdx.Parse(cstContents, &cg2Stats); parseErr != nil {
+			return throttleCounters{}, fmt.Errorf("failed to parse cpu.stat file for cgroup: %w", parseErr)
+		}
+		return throttleCounters{
+			TotalPeriods:     cg2Stats.TotalPeriods,
+			ThrottledPeriods: cg2Stats.ThrottledPeriods,
+			ThrottledTime:    time.Duration(cg2Stats.Throttledμs) * time.Microsecond,
+		}, nil
+	default:
+		return throttleCounters{}, fmt.Errorf("unknown cgroup type: %d", mode)
+	}
+}
+
+// cgroupThrottleCounters resolves the cpu cgroup with cgr (a new Cache if
+// nil), and reads its throttling counters.
+func cgroupThrottleCounters(ctx context.Context, cgr *cgresolver.Cache) (throttleCounters, error) {
+	cpuPath, f, resolveErr := resolveCgroupDir(ctx, cacheOrNew(cgr), "cpu")
+	if resolveErr != nil {
+		return throttleCounters{}, resolveErr
+	}
+	return readThrottleCounters(f, cpuPath.Mode)
+}
+
+// getCGroupCPUStatsSingle reads the CPU stats and limit for the single cgroup
+// rooted at f (not its ancestors). Under cgroup v1, the usage is read from
+// the cpuacct cgroup rooted at cpuAcctF. (absPath is only used for error
+// messages)
+func getCGroupCPUStatsSingle(f, cpuAcctF fs.FS, mode cgresolver.CGMode, absPath string) (CPUStats, float64, error) {
+	lim, limErr := getCGroupCPULimitSingle(f, mode)
 	if limErr != nil {
 		if !errors.Is(limErr, fs.ErrNotExist) {
 			return CPUStats{}, -1, fmt.Errorf("failed to read CPU limit: %w", limErr)
 		}
 		lim = -1.0
 	}
-	switch cpuPath.Mode {
+	switch mode {
 	case cgresolver.CGModeV1:
-		cstContents, readErr := os.ReadFile(filepath.Join(cpuPath.AbsPath, cgroupCpuStatFile))
+		cstContents, readErr := fs.ReadFile(f, cgroupCpuStatFile)
 		if readErr != nil {
 			return CPUStats{}, -1, fmt.Errorf("failed to read cpu.stat file for cgroup (%q): %w",
-				filepath.Join(cpuPath.AbsPath, cgroupCpuStatFile), readErr)
+				filepath.Join(absPath, cgroupCpuStatFile), readErr)
 		}
 		cg1Stats := cg1CPUStatContents{}
 		if parseErr := cg1CPUStatContentsFieldIdx.Parse(cstContents, &cg1Stats); parseErr != nil {
 			return CPUStats{}, -1, fmt.Errorf("failed to parse cpu.stat file for cgroup (%q): %w",
-				filepath.Join(cpuPath.AbsPath, cgroupCpuStatFile), readErr)
+				filepath.Join(absPath, cgroupCpuStatFile), parseErr)
 		}
-		cpuAcctPath, cgroupFindErr := cgresolver.SelfSubsystemPath("cpuacct")
-		if cgroupFindErr != nil {
-			return CPUStats{}, -1, fmt.Errorf("unable to find cgroup directory: %s",
-				cgroupFindErr)
-		}
-		f := os.DirFS(cpuAcctPath.AbsPath)
-		usage, usageErr := CGroupV1CPUUsage(f)
+		usage, usageErr := CGroupV1CPUUsage(cpuAcctF)
 		if usageErr != nil {
 			return CPUStats{}, -1, fmt.Errorf("failed to query usage: %w", usageErr)
 		}
 		return CPUStats{
-			Usage:         usage,
-			ThrottledTime: time.Duration(cg1Stats.Throttledns) * time.Nanosecond,
+			Usage:            usage,
+			ThrottledTime:    time.Duration(cg1Stats.Throttledns) * time.Nanosecond,
+			TotalPeriods:     cg1Stats.TotalPeriods,
+			ThrottledPeriods: cg1Stats.ThrottledPeriods,
 		}, lim, nil
 
 	case cgresolver.CGModeV2:
-		f := os.DirFS(cpuPath.AbsPath)
 		cpuStat, usageErr := CGroupV2CPUUsage(f)
 		return cpuStat, lim, usageErr
 	default:
-		return CPUStats{}, -1, fmt.Errorf("unknown cgroup type: %d", cpuPath.Mode)
+		return CPUStats{}, -1, fmt.Errorf("unknown cgroup type: %d", mode)
 	}
 }
 
 // GetCgroupCPUStats queries the current process's memory cgroup's CPU
 // usage/limits.
 func GetCgroupCPUStats() (CPUStats, error) {
-	cpuPath, cgroupFindErr := cgresolver.SelfSubsystemPath("cpu")
+	return GetCgroupCPUStatsContext(context.Background())
+}
+
+// GetCgroupCPUStatsContext is GetCgroupCPUStats, but gives up (returning
+// ctx.Err()) if ctx is done before the cgroup hierarchy has been walked.
+func GetCgroupCPUStatsContext(ctx context.Context) (CPUStats, error) {
+	return cgroupCPUStats(ctx, cgresolver.NewCache())
+}
+
+// GetCgroupCPUStatsWithCache is GetCgroupCPUStatsContext, but resolves the
+// cgroups with cgr, rather than re-reading /proc/self/cgroup, mountinfo and
+// /proc/cgroups on every call. Callers polling the stats of a process whose
+// cgroup doesn't change can share one Cache across calls (calling
+// Invalidate if the process may have moved).
+// A nil cgr is equivalent to GetCgroupCPUStatsContext.
+func GetCgroupCPUStatsWithCache(ctx context.Context, cgr *cgresolver.Cache) (CPUStats, error) {
+	return cgroupCPUStats(ctx, cacheOrNew(cgr))
+}
+
+// GetCgroupCPUStatsForPID is GetCgroupCPUStats for the process with PID
+// pid, rather than the current process.
+func GetCgroupCPUStatsForPID(pid int) (CPUStats, error) {
+	return cgroupCPUStats(context.Background(), cgresolver.NewPIDCache(pid))
+}
+
+// cgroupCPUStats resolves the cpu (and under cgroup v1, cpuacct) cgroups
+// with cgr (sharing the parsed procfs files across the resolutions), and
+// returns their CPU usage/limits.
+func cgroupCPUStats(ctx context.Context, cgr *cgresolver.Cache) (CPUStats, error) {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return CPUStats{}, ctxErr
+	}
+	cpuPath, cgroupFindErr := cgr.Resolve("cpu")
 	if cgroupFindErr != nil {
 		return CPUStats{}, fmt.Errorf("unable to find cgroup directory: %s",
 			cgroupFindErr)
 	}
+	cpuAcctPath := cpuPath
+	if cpuPath.Mode == cgresolver.CGModeV1 {
+		// cgroup v1 usage comes from the cpuacct controller, which
+		// may be mounted separately
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return CPUStats{}, ctxErr
+		}
+		var acctFindErr error
+		if cpuAcctPath, acctFindErr = cgr.Resolve("cpuacct"); acctFindErr != nil {
+			return CPUStats{}, fmt.Errorf("unable to find cgroup directory: %s",
+				acctFindErr)
+		}
+	}
+	return getCgroupCPUStatsFS(ctx, os.DirFS("/"), cpuPath, cpuAcctPath)
+}
+
+// GetCgroupCPUStatsFS is GetCgroupCPUStats for the cpu cgroup cpuPath,
+// reading from root (an fs.FS rooted at the filesystem root) rather than the
+// host's filesystem.
+// Under cgroup v1, the usage is read from the cpuacct cgroup cpuAcctPath
+// (ignored under cgroup v2).
+func GetCgroupCPUStatsFS(root fs.FS, cpuPath, cpuAcctPath cgresolver.CGroupPath) (CPUStats, error) {
+	return getCgroupCPUStatsFS(context.Background(), root, cpuPath, cpuAcctPath)
+}
+
+// ReadCgroupCPUStatsFS reads the CPU stats of the single cgroup directory f
+// (ancestors are not consulted) using the file layout of the cgroup version
+// mode. Under cgroup v1, the usage is read from the cpuacct cgroup directory
+// cpuAcctF (ignored under cgroup v2, and may be nil).
+func ReadCgroupCPUStatsFS(f, cpuAcctF fs.FS, mode cgresolver.CGMode) (CPUStats, error) {
+	stats, _, err := getCGroupCPUStatsSingle(f, cpuAcctF, mode, ".")
+	return stats, err
+}
+
+func getCgroupCPUStatsFS(ctx context.Context, root fs.FS, cpuPath, cpuAcctPath cgresolver.CGroupPath) (CPUStats, error) {
+	cpuAcctF, acctSubErr := cgroupDirFS(root, &cpuAcctPath)
+	if acctSubErr != nil {
+		return CPUStats{}, fmt.Errorf("invalid cgroup path %q: %w", cpuAcctPath.AbsPath, acctSubErr)
+	}
 	minLimit := math.Inf(+1)
 	minCPUStats := CPUStats{}
 	allFailed := true
@@ -618,7 +1355,14 @@ func GetCgroupCPUStats() (CPUStats, error) {
 	leafCPUStats := CPUStats{}
 
 	for newDir := true; newDir; cpuPath, newDir = cpuPath.Parent() {
-		cgCPUStats, cgLim, cgReadErr := getCGroupCPUStatsSingle(&cpuPath)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return CPUStats{}, ctxErr
+		}
+		f, subErr := cgroupDirFS(root, &cpuPath)
+		if subErr != nil {
+			return CPUStats{}, fmt.Errorf("invalid cgroup path %q: %w", cpuPath.AbsPath, subErr)
+		}
+		cgCPUStats, cgLim, cgReadErr := getCGroupCPUStatsSingle(f, cpuAcctF, cpuPath.Mode, cpuPath.AbsPath)
 		if cgReadErr != nil {
 			if leafCGReadErr == nil && allFailed {
 				leafCGReadErr = cgReadErr
@@ -0,0 +1,1514 @@
+package cgrouplimits
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"maps"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/vimeo/procstats"
+	"github.com/vimeo/procstats/cgresolver"
+)
+
+const testCG2MemoryStat = `anon 1392123904
+file 1076064256
+kernel 87924736
+kernel_stack 3014656
+pagetables 12529664
+sec_pagetables 4096
+percpu 1688
+sock 8192
+vmalloc 20480
+shmem 9785344
+zswap 1048576
+zswapped 4194304
+file_mapped 173375488
+file_dirty 139264
+file_writeback 0
+swapcached 0
+anon_thp 0
+file_thp 0
+shmem_thp 0
+inactive_anon 1371361280
+active_anon 29184000
+inactive_file 672231424
+active_file 394047488
+unevictable 0
+slab_reclaimable 67553608
+slab_unreclaimable 3869672
+slab 71423280
+workingset_refault_anon 0
+workingset_refault_file 0
+workingset_activate_anon 0
+workingset_activate_file 0
+workingset_restore_anon 0
+workingset_restore_file 0
+workingset_nodereclaim 0
+pgscan 0
+pgsteal 0
+pgscan_kswapd 0
+pgscan_direct 0
+pgsteal_kswapd 0
+pgsteal_direct 0
+pgfault 2315307
+pgmajfault 1061
+pgrefill 0
+pgactivate 102236
+pgdeactivate 0
+pglazyfree 0
+pglazyfreed 0
+zswpin 17
+zswpout 1024
+thp_fault_alloc 0
+thp_collapse_alloc 0
+`
+
+func TestReadCG2MemoryStat(t *testing.T) {
+	f := fstest.MapFS{
+		"memory.stat": &fstest.MapFile{Data: []byte(testCG2MemoryStat)},
+	}
+	st, err := readCG2MemoryStat(f)
+	if err != nil {
+		t.Fatalf("failed to read memory.stat: %s", err)
+	}
+	if st.Pagetables != 12529664 {
+		t.Errorf("unexpected pagetables value %d; expected 12529664", st.Pagetables)
+	}
+	if st.SecondaryPagetables != 4096 {
+		t.Errorf("unexpected sec_pagetables value %d; expected 4096", st.SecondaryPagetables)
+	}
+	if zs, expZS := st.zswapStats(), (ZswapStats{
+		PoolBytes:    1048576,
+		SwappedBytes: 4194304,
+		PagesIn:      17,
+		PagesOut:     1024,
+	}); zs != expZS {
+		t.Errorf("unexpected zswap stats %+v; expected %+v", zs, expZS)
+	}
+	if _, err := readCG2MemoryStat(fstest.MapFS{}); err == nil {
+		t.Error("expected error reading missing memory.stat")
+	}
+}
+
+func TestCgroupEffectiveCPUs(t *testing.T) {
+	for _, tbl := range []struct {
+		name    string
+		f       fstest.MapFS
+		mode    cgresolver.CGMode
+		expCPUs []int
+		expErr  error
+	}{
+		{
+			name: "v1_effective",
+			f: fstest.MapFS{
+				"cpuset.cpus":           &fstest.MapFile{Data: []byte("0-7\n")},
+				"cpuset.effective_cpus": &fstest.MapFile{Data: []byte("2-3\n")},
+			},
+			mode:    cgresolver.CGModeV1,
+			expCPUs: []int{2, 3},
+		},
+		{
+			name: "v1_no_effective",
+			f: fstest.MapFS{
+				"cpuset.cpus": &fstest.MapFile{Data: []byte("0,4\n")},
+			},
+			mode:    cgresolver.CGModeV1,
+			expCPUs: []int{0, 4},
+		},
+		{
+			name: "v2_effective",
+			f: fstest.MapFS{
+				"cpuset.cpus":           &fstest.MapFile{Data: []byte("\n")},
+				"cpuset.cpus.effective": &fstest.MapFile{Data: []byte("0-1,6\n")},
+			},
+			mode:    cgresolver.CGModeV2,
+			expCPUs: []int{0, 1, 6},
+		},
+		{
+			name: "v2_ranges_and_singletons",
+			f: fstest.MapFS{
+				"cpuset.cpus.effective": &fstest.MapFile{Data: []byte("0-3,7,9-11\n")},
+			},
+			mode:    cgresolver.CGModeV2,
+			expCPUs: []int{0, 1, 2, 3, 7, 9, 10, 11},
+		},
+		{
+			name: "v1_unconfigured",
+			f: fstest.MapFS{
+				"cpuset.cpus":           &fstest.MapFile{Data: []byte("\n")},
+				"cpuset.effective_cpus": &fstest.MapFile{Data: []byte("\n")},
+			},
+			mode:    cgresolver.CGModeV1,
+			expCPUs: []int{},
+		},
+		{
+			name: "v2_no_cpuset",
+			f: fstest.MapFS{
+				"cpu.max": &fstest.MapFile{Data: []byte("max 100000\n")},
+			},
+			mode:   cgresolver.CGModeV2,
+			expErr: fs.ErrNotExist,
+		},
+	} {
+		t.Run(tbl.name, func(t *testing.T) {
+			cpus, err := cgroupEffectiveCPUs(tbl.f, tbl.mode)
+			if tbl.expErr != nil {
+				if !errors.Is(err, tbl.expErr) {
+					t.Fatalf("unexpected error: %v; expected %v", err, tbl.expErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !slices.Equal(cpus, tbl.expCPUs) {
+				t.Errorf("unexpected CPUs: %v; expected %v", cpus, tbl.expCPUs)
+			}
+		})
+	}
+}
+
+func TestReadCGroupCPUSet(t *testing.T) {
+	for _, tbl := range []struct {
+		name      string
+		f         fstest.MapFS
+		mode      cgresolver.CGMode
+		expCPUSet CPUSet
+		expErr    error
+	}{
+		{
+			name: "v2",
+			f: fstest.MapFS{
+				"cpuset.cpus.effective": &fstest.MapFile{Data: []byte("0-3\n")},
+				"cpuset.mems.effective": &fstest.MapFile{Data: []byte("1\n")},
+			},
+			mode:      cgresolver.CGModeV2,
+			expCPUSet: CPUSet{CPUs: []int{0, 1, 2, 3}, Mems: []int{1}},
+		},
+		{
+			name: "v1_effective",
+			f: fstest.MapFS{
+				"cpuset.cpus":           &fstest.MapFile{Data: []byte("0-7\n")},
+				"cpuset.effective_cpus": &fstest.MapFile{Data: []byte("4\n")},
+				"cpuset.mems":           &fstest.MapFile{Data: []byte("0-1\n")},
+				"cpuset.effective_mems": &fstest.MapFile{Data: []byte("0\n")},
+			},
+			mode:      cgresolver.CGModeV1,
+			expCPUSet: CPUSet{CPUs: []int{4}, Mems: []int{0}},
+		},
+		{
+			name: "v1_unconfigured_no_effective",
+			f: fstest.MapFS{
+				"cpuset.cpus": &fstest.MapFile{Data: []byte("\n")},
+				"cpuset.mems": &fstest.MapFile{Data: []byte("\n")},
+			},
+			mode:      cgresolver.CGModeV1,
+			expCPUSet: CPUSet{CPUs: []int{}, Mems: []int{}},
+		},
+		{
+			name: "v2_no_mems",
+			f: fstest.MapFS{
+				"cpuset.cpus.effective": &fstest.MapFile{Data: []byte("0-3\n")},
+			},
+			mode:   cgresolver.CGModeV2,
+			expErr: fs.ErrNotExist,
+		},
+	} {
+		t.Run(tbl.name, func(t *testing.T) {
+			cpuset, err := readCGroupCPUSet(tbl.f, tbl.mode)
+			if tbl.expErr != nil {
+				if !errors.Is(err, tbl.expErr) {
+					t.Fatalf("unexpected error: %v; expected %v", err, tbl.expErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !slices.Equal(cpuset.CPUs, tbl.expCPUSet.CPUs) || !slices.Equal(cpuset.Mems, tbl.expCPUSet.Mems) {
+				t.Errorf("unexpected cpuset: %+v; expected %+v", cpuset, tbl.expCPUSet)
+			}
+		})
+	}
+}
+
+func TestGetCgroupCPUSetFSAncestor(t *testing.T) {
+	// cgroup v2: the cpuset controller is only enabled down to "a", so
+	// "a/b/c" and "a/b" have no cpuset files of their own.
+	f := fstest.MapFS{
+		"sys/fs/cgroup/cpuset.cpus.effective":   &fstest.MapFile{Data: []byte("0-15\n")},
+		"sys/fs/cgroup/cpuset.mems.effective":   &fstest.MapFile{Data: []byte("0-1\n")},
+		"sys/fs/cgroup/a/cpuset.cpus.effective": &fstest.MapFile{Data: []byte("2-5\n")},
+		"sys/fs/cgroup/a/cpuset.mems.effective": &fstest.MapFile{Data: []byte("1\n")},
+		"sys/fs/cgroup/a/b/c/cpu.max":           &fstest.MapFile{Data: []byte("max 100000\n")},
+		"sys/fs/cgroup/nocpuset/cpu.max":        &fstest.MapFile{Data: []byte("max 100000\n")},
+	}
+	leaf := cgresolver.CGroupPath{
+		AbsPath:   "/sys/fs/cgroup/a/b/c",
+		MountPath: "/sys/fs/cgroup",
+		Mode:      cgresolver.CGModeV2,
+	}
+	ctx := context.Background()
+
+	cpus, cpusErr := getCgroupEffectiveCPUsFS(ctx, f, leaf)
+	if cpusErr != nil {
+		t.Fatalf("unexpected error reading effective CPUs: %s", cpusErr)
+	}
+	if exp := []int{2, 3, 4, 5}; !slices.Equal(cpus, exp) {
+		t.Errorf("unexpected CPUs: %v; expected %v", cpus, exp)
+	}
+	cpuset, cpusetErr := getCgroupCPUSetFS(ctx, f, leaf)
+	if cpusetErr != nil {
+		t.Fatalf("unexpected error reading cpuset: %s", cpusetErr)
+	}
+	if !slices.Equal(cpuset.CPUs, []int{2, 3, 4, 5}) || !slices.Equal(cpuset.Mems, []int{1}) {
+		t.Errorf("unexpected cpuset: %+v", cpuset)
+	}
+
+	// the walk stops at the mountpoint, so a cgroup mounted below the
+	// hierarchy root without a cpuset of its own has none
+	if _, err := getCgroupCPUSetFS(ctx, f, cgresolver.CGroupPath{
+		AbsPath:   "/sys/fs/cgroup/nocpuset",
+		MountPath: "/sys/fs/cgroup/nocpuset",
+		Mode:      cgresolver.CGModeV2,
+	}); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("unexpected error without a cpuset: %v", err)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := getCgroupCPUSetFS(cancelled, f, leaf); !errors.Is(err, context.Canceled) {
+		t.Errorf("unexpected error with a cancelled context: %v", err)
+	}
+}
+
+func TestReadCPUSetFlags(t *testing.T) {
+	for _, tbl := range []struct {
+		name     string
+		f        fstest.MapFS
+		mode     cgresolver.CGMode
+		expFlags CPUSetFlags
+		expErr   error
+	}{
+		{
+			name: "v1_defaults",
+			f: fstest.MapFS{
+				"cpuset.sched_load_balance": &fstest.MapFile{Data: []byte("1\n")},
+				"cpuset.memory_migrate":     &fstest.MapFile{Data: []byte("0\n")},
+				"cpuset.cpu_exclusive":      &fstest.MapFile{Data: []byte("0\n")},
+				"cpuset.mem_exclusive":      &fstest.MapFile{Data: []byte("0\n")},
+			},
+			mode:     cgresolver.CGModeV1,
+			expFlags: CPUSetFlags{SchedLoadBalance: true},
+		},
+		{
+			name: "v1_numa_pinned",
+			f: fstest.MapFS{
+				"cpuset.sched_load_balance": &fstest.MapFile{Data: []byte("0\n")},
+				"cpuset.memory_migrate":     &fstest.MapFile{Data: []byte("1\n")},
+				"cpuset.cpu_exclusive":      &fstest.MapFile{Data: []byte("1\n")},
+				"cpuset.mem_exclusive":      &fstest.MapFile{Data: []byte("1\n")},
+			},
+			mode: cgresolver.CGModeV1,
+			expFlags: CPUSetFlags{
+				SchedLoadBalance: false,
+				MemoryMigrate:    true,
+				CPUExclusive:     true,
+				MemExclusive:     true,
+			},
+		},
+		{
+			name: "v1_no_cpuset",
+			f: fstest.MapFS{
+				"cpu.shares": &fstest.MapFile{Data: []byte("1024\n")},
+			},
+			mode:   cgresolver.CGModeV1,
+			expErr: fs.ErrNotExist,
+		},
+		{
+			name: "v2_member",
+			f: fstest.MapFS{
+				"cpuset.cpus.partition": &fstest.MapFile{Data: []byte("member\n")},
+			},
+			mode: cgresolver.CGModeV2,
+			expFlags: CPUSetFlags{
+				SchedLoadBalance: true,
+				MemoryMigrate:    true,
+				Partition:        "member",
+			},
+		},
+		{
+			name: "v2_root",
+			f: fstest.MapFS{
+				"cpuset.cpus.partition": &fstest.MapFile{Data: []byte("root\n")},
+			},
+			mode: cgresolver.CGModeV2,
+			expFlags: CPUSetFlags{
+				SchedLoadBalance: true,
+				MemoryMigrate:    true,
+				CPUExclusive:     true,
+				Partition:        "root",
+			},
+		},
+		{
+			name: "v2_isolated",
+			f: fstest.MapFS{
+				"cpuset.cpus.partition": &fstest.MapFile{Data: []byte("isolated\n")},
+			},
+			mode: cgresolver.CGModeV2,
+			expFlags: CPUSetFlags{
+				SchedLoadBalance: false,
+				MemoryMigrate:    true,
+				CPUExclusive:     true,
+				Partition:        "isolated",
+			},
+		},
+		{
+			name: "v2_invalid_isolated",
+			f: fstest.MapFS{
+				"cpuset.cpus.partition": &fstest.MapFile{Data: []byte("isolated invalid (Cpu list in cpuset.cpus not exclusive)\n")},
+			},
+			mode: cgresolver.CGModeV2,
+			expFlags: CPUSetFlags{
+				SchedLoadBalance: true,
+				MemoryMigrate:    true,
+				Partition:        "isolated invalid (Cpu list in cpuset.cpus not exclusive)",
+			},
+		},
+		{
+			name: "v2_no_cpuset",
+			f: fstest.MapFS{
+				"cpu.max": &fstest.MapFile{Data: []byte("max 100000\n")},
+			},
+			mode:   cgresolver.CGModeV2,
+			expErr: fs.ErrNotExist,
+		},
+	} {
+		t.Run(tbl.name, func(t *testing.T) {
+			flags, err := readCPUSetFlags(tbl.f, tbl.mode)
+			if tbl.expErr != nil {
+				if !errors.Is(err, tbl.expErr) {
+					t.Fatalf("unexpected error: %v; expected %v", err, tbl.expErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if flags != tbl.expFlags {
+				t.Errorf("unexpected flags: %+v; expected %+v", flags, tbl.expFlags)
+			}
+		})
+	}
+}
+
+func TestGetCGroupV2MemoryStats(t *testing.T) {
+	baseFS := func() fstest.MapFS {
+		return fstest.MapFS{
+			"memory.stat":    &fstest.MapFile{Data: []byte(testCG2MemoryStat)},
+			"memory.current": &fstest.MapFile{Data: []byte("2000000000\n")},
+			"memory.max":     &fstest.MapFile{Data: []byte("4000000000\n")},
+		}
+	}
+	withEvents := baseFS()
+	withEvents["memory.events"] = &fstest.MapFile{Data: []byte("low 0\nhigh 0\nmax 12\noom 3\noom_kill 2\noom_group_kill 1\n")}
+
+	for _, tbl := range []struct {
+		name        string
+		f           fstest.MapFS
+		expOOMKills int64
+	}{
+		{
+			name:        "with_memory_events",
+			f:           withEvents,
+			expOOMKills: 1,
+		},
+		{
+			name:        "missing_memory_events",
+			f:           baseFS(),
+			expOOMKills: 0,
+		},
+	} {
+		t.Run(tbl.name, func(t *testing.T) {
+			ms, limit, err := getCGroupV2MemoryStats(tbl.f, "/sys/fs/cgroup/test")
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if limit != 4000000000 {
+				t.Errorf("unexpected limit %d; expected 4000000000", limit)
+			}
+			if ms.Total != 4000000000 {
+				t.Errorf("unexpected total %d; expected 4000000000", ms.Total)
+			}
+			if ms.Free != 2000000000 {
+				t.Errorf("unexpected free %d; expected 2000000000", ms.Free)
+			}
+			if ms.Available < ms.Free {
+				t.Errorf("unexpected available %d; expected at least %d", ms.Available, ms.Free)
+			}
+			if ms.OOMKills != tbl.expOOMKills {
+				t.Errorf("unexpected OOMKills %d; expected %d", ms.OOMKills, tbl.expOOMKills)
+			}
+		})
+	}
+
+	// memory.current is still required
+	noCurrent := baseFS()
+	delete(noCurrent, "memory.current")
+	if _, _, err := getCGroupV2MemoryStats(noCurrent, "/sys/fs/cgroup/test"); err == nil {
+		t.Error("expected error with missing memory.current")
+	}
+}
+
+func TestGetCgroupMemoryStatsFS(t *testing.T) {
+	f := fstest.MapFS{
+		// v2: the parent's limit is tighter than the leaf's
+		"sys/fs/cgroup/a/memory.stat":      &fstest.MapFile{Data: []byte(testCG2MemoryStat)},
+		"sys/fs/cgroup/a/memory.current":   &fstest.MapFile{Data: []byte("3000000000\n")},
+		"sys/fs/cgroup/a/memory.max":       &fstest.MapFile{Data: []byte("4000000000\n")},
+		"sys/fs/cgroup/a/b/memory.stat":    &fstest.MapFile{Data: []byte(testCG2MemoryStat)},
+		"sys/fs/cgroup/a/b/memory.current": &fstest.MapFile{Data: []byte("2000000000\n")},
+		"sys/fs/cgroup/a/b/memory.max":     &fstest.MapFile{Data: []byte("max\n")},
+		"sys/fs/cgroup/a/b/memory.events":  &fstest.MapFile{Data: []byte("oom_group_kill 2\n")},
+
+		// v1: the leaf is limited
+		"sys/fs/cgroup/memory/memory.limit_in_bytes":     &fstest.MapFile{Data: []byte("9223372036854771712\n")},
+		"sys/fs/cgroup/memory/memory.usage_in_bytes":     &fstest.MapFile{Data: []byte("8000000000\n")},
+		"sys/fs/cgroup/memory/memory.stat":               &fstest.MapFile{Data: []byte("total_cache 100\n")},
+		"sys/fs/cgroup/memory/memory.oom_control":        &fstest.MapFile{Data: []byte("oom_kill_disable 0\nunder_oom 0\noom_kill 0\n")},
+		"sys/fs/cgroup/memory/foo/memory.limit_in_bytes": &fstest.MapFile{Data: []byte("1000000\n")},
+		"sys/fs/cgroup/memory/foo/memory.usage_in_bytes": &fstest.MapFile{Data: []byte("400000\n")},
+		"sys/fs/cgroup/memory/foo/memory.stat":           &fstest.MapFile{Data: []byte("cache 300\ntotal_cache 500\n")},
+		"sys/fs/cgroup/memory/foo/memory.oom_control":    &fstest.MapFile{Data: []byte("oom_kill_disable 0\nunder_oom 0\noom_kill 3\n")},
+		// v1: unlimited all the way up
+		"sys/fs/cgroup/memory/bar/memory.limit_in_bytes": &fstest.MapFile{Data: []byte("9223372036854771712\n")},
+		"sys/fs/cgroup/memory/bar/memory.usage_in_bytes": &fstest.MapFile{Data: []byte("400000\n")},
+		"sys/fs/cgroup/memory/bar/memory.stat":           &fstest.MapFile{Data: []byte("total_cache 500\n")},
+		"sys/fs/cgroup/memory/bar/memory.oom_control":    &fstest.MapFile{Data: []byte("oom_kill_disable 0\nunder_oom 0\noom_kill 0\n")},
+
+		// v2: unlimited (the root cgroup has no memory.max)
+		"sys/fs/cgroup/c/memory.stat":    &fstest.MapFile{Data: []byte(testCG2MemoryStat)},
+		"sys/fs/cgroup/c/memory.current": &fstest.MapFile{Data: []byte("2000000000\n")},
+		"sys/fs/cgroup/c/memory.max":     &fstest.MapFile{Data: []byte("max\n")},
+	}
+	for _, tbl := range []struct {
+		name     string
+		memPath  cgresolver.CGroupPath
+		expStats MemoryStats
+		expLimit int64
+	}{
+		{
+			name: "v2_limited_parent",
+			memPath: cgresolver.CGroupPath{
+				AbsPath: "/sys/fs/cgroup/a/b", MountPath: "/sys/fs/cgroup", Mode: cgresolver.CGModeV2,
+			},
+			expStats: MemoryStats{Total: 4000000000, Free: 1000000000},
+			expLimit: 4000000000,
+		},
+		{
+			name: "v1_limited_leaf",
+			memPath: cgresolver.CGroupPath{
+				AbsPath: "/sys/fs/cgroup/memory/foo", MountPath: "/sys/fs/cgroup/memory", Mode: cgresolver.CGModeV1,
+			},
+			expStats: MemoryStats{Total: 1000000, Free: 600000, Available: 600500, OOMKills: 3},
+			expLimit: 1000000,
+		},
+		{
+			// the v1 "no limit" sentinel must not be mistaken for
+			// a real (8EiB) limit
+			name: "v1_unlimited",
+			memPath: cgresolver.CGroupPath{
+				AbsPath: "/sys/fs/cgroup/memory/bar", MountPath: "/sys/fs/cgroup/memory", Mode: cgresolver.CGModeV1,
+			},
+			expStats: MemoryStats{Total: -1, Free: -1, Available: -1},
+			expLimit: -1,
+		},
+		{
+			name: "v2_unlimited",
+			memPath: cgresolver.CGroupPath{
+				AbsPath: "/sys/fs/cgroup/c", MountPath: "/sys/fs/cgroup", Mode: cgresolver.CGModeV2,
+			},
+			expStats: MemoryStats{Total: -1, Free: -1},
+			expLimit: -1,
+		},
+	} {
+		t.Run(tbl.name, func(t *testing.T) {
+			ms, err := GetCgroupMemoryStatsFS(f, tbl.memPath)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			// the v2 Available value depends on the details of
+			// memory.stat, so just sanity check it.
+			if tbl.memPath.Mode == cgresolver.CGModeV2 {
+				if ms.Available < ms.Free {
+					t.Errorf("unexpected available %d; expected at least %d", ms.Available, ms.Free)
+				}
+				ms.Available = 0
+			}
+			if ms != tbl.expStats {
+				t.Errorf("unexpected stats: %+v; expected %+v", ms, tbl.expStats)
+			}
+			limit, limitErr := GetCgroupMemoryLimitFS(f, tbl.memPath)
+			if limitErr != nil {
+				t.Fatalf("unexpected error reading limit: %s", limitErr)
+			}
+			if limit != tbl.expLimit {
+				t.Errorf("unexpected limit %d; expected %d", limit, tbl.expLimit)
+			}
+		})
+	}
+}
+
+func TestGetCgroupMemoryLimitFSUnlimited(t *testing.T) {
+	f := fstest.MapFS{
+		"sys/fs/cgroup/memory.max":     &fstest.MapFile{Data: []byte("max\n")},
+		"sys/fs/cgroup/a/memory.max":   &fstest.MapFile{Data: []byte("max\n")},
+		"sys/fs/cgroup/a/b/memory.max": &fstest.MapFile{Data: []byte("max\n")},
+	}
+	limit, err := GetCgroupMemoryLimitFS(f, cgresolver.CGroupPath{
+		AbsPath: "/sys/fs/cgroup/a/b", MountPath: "/sys/fs/cgroup", Mode: cgresolver.CGModeV2,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if limit != -1 {
+		t.Errorf("unexpected limit %d; expected -1 (unlimited)", limit)
+	}
+}
+
+func TestGetCgroupMemoryLimitFSSource(t *testing.T) {
+	leafPath := cgresolver.CGroupPath{
+		AbsPath: "/sys/fs/cgroup/a/b", MountPath: "/sys/fs/cgroup", Mode: cgresolver.CGModeV2,
+	}
+	parentPath := cgresolver.CGroupPath{
+		AbsPath: "/sys/fs/cgroup/a", MountPath: "/sys/fs/cgroup", Mode: cgresolver.CGModeV2,
+	}
+	for _, tbl := range []struct {
+		name      string
+		f         fstest.MapFS
+		expLimit  int64
+		expSource cgresolver.CGroupPath
+	}{
+		{
+			name: "parent_tighter",
+			f: fstest.MapFS{
+				"sys/fs/cgroup/a/memory.max":   &fstest.MapFile{Data: []byte("2000000000\n")},
+				"sys/fs/cgroup/a/b/memory.max": &fstest.MapFile{Data: []byte("4000000000\n")},
+			},
+			expLimit:  2000000000,
+			expSource: parentPath,
+		},
+		{
+			name: "leaf_tighter",
+			f: fstest.MapFS{
+				"sys/fs/cgroup/a/memory.max":   &fstest.MapFile{Data: []byte("4000000000\n")},
+				"sys/fs/cgroup/a/b/memory.max": &fstest.MapFile{Data: []byte("2000000000\n")},
+			},
+			expLimit:  2000000000,
+			expSource: leafPath,
+		},
+		{
+			name: "unlimited",
+			f: fstest.MapFS{
+				"sys/fs/cgroup/a/memory.max":   &fstest.MapFile{Data: []byte("max\n")},
+				"sys/fs/cgroup/a/b/memory.max": &fstest.MapFile{Data: []byte("max\n")},
+			},
+			expLimit:  -1,
+			expSource: cgresolver.CGroupPath{},
+		},
+	} {
+		t.Run(tbl.name, func(t *testing.T) {
+			limit, source, err := getCgroupMemoryLimitFS(context.Background(), tbl.f, leafPath, 0)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if limit != tbl.expLimit {
+				t.Errorf("unexpected limit %d; expected %d", limit, tbl.expLimit)
+			}
+			if source != tbl.expSource {
+				t.Errorf("unexpected source %+v; expected %+v", source, tbl.expSource)
+			}
+		})
+	}
+}
+
+func TestCgroupMemoryLimitAboveHostTotal(t *testing.T) {
+	// 8GB of RAM and as much swap
+	procRoot := t.TempDir()
+	if err := os.WriteFile(filepath.Join(procRoot, "meminfo"),
+		[]byte("MemTotal:        7812500 kB\nSwapTotal:       7812500 kB\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cgresolver.SetProcRoot(procRoot)
+	t.Cleanup(func() { cgresolver.SetProcRoot("") })
+	hostTotal := hostMemTotal()
+	if hostTotal != 8000000000 {
+		t.Fatalf("unexpected host memory total %d; expected 8000000000", hostTotal)
+	}
+
+	f := fstest.MapFS{
+		// the leaf's limit is beyond the host's memory, and the
+		// parent's is within it
+		"sys/fs/cgroup/a/memory.stat":      &fstest.MapFile{Data: []byte(testCG2MemoryStat)},
+		"sys/fs/cgroup/a/memory.current":   &fstest.MapFile{Data: []byte("3000000000\n")},
+		"sys/fs/cgroup/a/memory.max":       &fstest.MapFile{Data: []byte("6000000000\n")},
+		"sys/fs/cgroup/a/b/memory.stat":    &fstest.MapFile{Data: []byte(testCG2MemoryStat)},
+		"sys/fs/cgroup/a/b/memory.current": &fstest.MapFile{Data: []byte("2000000000\n")},
+		"sys/fs/cgroup/a/b/memory.max":     &fstest.MapFile{Data: []byte("16000000000\n")},
+		// only limited beyond the host's memory
+		"sys/fs/cgroup/c/memory.stat":    &fstest.MapFile{Data: []byte(testCG2MemoryStat)},
+		"sys/fs/cgroup/c/memory.current": &fstest.MapFile{Data: []byte("2000000000\n")},
+		"sys/fs/cgroup/c/memory.max":     &fstest.MapFile{Data: []byte("8000000000\n")},
+		// beyond the host's RAM, but within RAM plus swap, which the
+		// limit doesn't cover
+		"sys/fs/cgroup/d/memory.stat":    &fstest.MapFile{Data: []byte(testCG2MemoryStat)},
+		"sys/fs/cgroup/d/memory.current": &fstest.MapFile{Data: []byte("2000000000\n")},
+		"sys/fs/cgroup/d/memory.max":     &fstest.MapFile{Data: []byte("12000000000\n")},
+	}
+	ctx := context.Background()
+	for _, tbl := range []struct {
+		name     string
+		absPath  string
+		expLimit int64
+		expFree  int64
+	}{
+		{name: "limited_parent", absPath: "/sys/fs/cgroup/a/b", expLimit: 6000000000, expFree: 3000000000},
+		{name: "unlimited", absPath: "/sys/fs/cgroup/c", expLimit: -1, expFree: -2000000001},
+		{name: "unlimited_within_swap", absPath: "/sys/fs/cgroup/d", expLimit: -1, expFree: -2000000001},
+	} {
+		t.Run(tbl.name, func(t *testing.T) {
+			memPath := cgresolver.CGroupPath{AbsPath: tbl.absPath, MountPath: "/sys/fs/cgroup", Mode: cgresolver.CGModeV2}
+			limit, _, limitErr := getCgroupMemoryLimitFS(ctx, f, memPath, hostTotal)
+			if limitErr != nil {
+				t.Fatalf("unexpected error reading limit: %s", limitErr)
+			}
+			if limit != tbl.expLimit {
+				t.Errorf("unexpected limit %d; expected %d", limit, tbl.expLimit)
+			}
+			ms, msErr := getCgroupMemoryStatsFS(ctx, f, memPath, hostTotal)
+			if msErr != nil {
+				t.Fatalf("unexpected error reading stats: %s", msErr)
+			}
+			if ms.Total != tbl.expLimit || ms.Free != tbl.expFree {
+				t.Errorf("unexpected total/free %d/%d; expected %d/%d",
+					ms.Total, ms.Free, tbl.expLimit, tbl.expFree)
+			}
+		})
+	}
+	// without the host's total, the limits are taken at face value
+	if limit, _, err := getCgroupMemoryLimitFS(ctx, f, cgresolver.CGroupPath{
+		AbsPath: "/sys/fs/cgroup/c", MountPath: "/sys/fs/cgroup", Mode: cgresolver.CGModeV2,
+	}, 0); err != nil || limit != 8000000000 {
+		t.Errorf("unexpected limit %d (err %v); expected 8000000000", limit, err)
+	}
+}
+
+func TestGetCgroupCPUStatsFS(t *testing.T) {
+	f := fstest.MapFS{
+		// v2: both levels are limited, but the leaf's limit is tighter
+		"sys/fs/cgroup/a/cpu.max":    &fstest.MapFile{Data: []byte("400000 100000\n")},
+		"sys/fs/cgroup/a/cpu.stat":   &fstest.MapFile{Data: []byte("usage_usec 9000000\nuser_usec 7000000\nsystem_usec 2000000\n")},
+		"sys/fs/cgroup/a/b/cpu.max":  &fstest.MapFile{Data: []byte("150000 100000\n")},
+		"sys/fs/cgroup/a/b/cpu.stat": &fstest.MapFile{Data: []byte("usage_usec 8000000\nuser_usec 6000000\nsystem_usec 2000000\nnr_periods 2000\nnr_throttled 80\nthrottled_usec 4500000\n")},
+
+		// v1: cpu and cpuacct mounted separately
+		"sys/fs/cgroup/cpu/foo/cpu.cfs_quota_us":       &fstest.MapFile{Data: []byte("50000\n")},
+		"sys/fs/cgroup/cpu/foo/cpu.cfs_period_us":      &fstest.MapFile{Data: []byte("100000\n")},
+		"sys/fs/cgroup/cpu/foo/cpu.stat":               &fstest.MapFile{Data: []byte("nr_periods 3000\nnr_throttled 120\nthrottled_time 4500000000\n")},
+		"sys/fs/cgroup/cpuacct/foo/cpuacct.usage_user": &fstest.MapFile{Data: []byte("3000000000\n")},
+		"sys/fs/cgroup/cpuacct/foo/cpuacct.usage_sys":  &fstest.MapFile{Data: []byte("1000000000\n")},
+	}
+	for _, tbl := range []struct {
+		name        string
+		cpuPath     cgresolver.CGroupPath
+		cpuAcctPath cgresolver.CGroupPath
+		expStats    CPUStats
+		expLimit    float64
+	}{
+		{
+			name: "v2",
+			cpuPath: cgresolver.CGroupPath{
+				AbsPath: "/sys/fs/cgroup/a/b", MountPath: "/sys/fs/cgroup", Mode: cgresolver.CGModeV2,
+			},
+			expStats: CPUStats{
+				Usage:            procstats.CPUTime{Utime: 6 * time.Second, Stime: 2 * time.Second},
+				ThrottledTime:    4500 * time.Millisecond,
+				TotalPeriods:     2000,
+				ThrottledPeriods: 80,
+			},
+			expLimit: 1.5,
+		},
+		{
+			name: "v1_separate_cpuacct",
+			cpuPath: cgresolver.CGroupPath{
+				AbsPath: "/sys/fs/cgroup/cpu/foo", MountPath: "/sys/fs/cgroup/cpu", Mode: cgresolver.CGModeV1,
+			},
+			cpuAcctPath: cgresolver.CGroupPath{
+				AbsPath: "/sys/fs/cgroup/cpuacct/foo", MountPath: "/sys/fs/cgroup/cpuacct", Mode: cgresolver.CGModeV1,
+			},
+			expStats: CPUStats{
+				Usage:            procstats.CPUTime{Utime: 3 * time.Second, Stime: time.Second},
+				ThrottledTime:    4500 * time.Millisecond,
+				TotalPeriods:     3000,
+				ThrottledPeriods: 120,
+			},
+			expLimit: 0.5,
+		},
+	} {
+		t.Run(tbl.name, func(t *testing.T) {
+			stats, err := GetCgroupCPUStatsFS(f, tbl.cpuPath, tbl.cpuAcctPath)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if stats != tbl.expStats {
+				t.Errorf("unexpected stats: %+v; expected %+v", stats, tbl.expStats)
+			}
+			limit, limitErr := GetCgroupCPULimitFS(f, tbl.cpuPath)
+			if limitErr != nil {
+				t.Fatalf("unexpected error reading limit: %s", limitErr)
+			}
+			if limit != tbl.expLimit {
+				t.Errorf("unexpected limit %g; expected %g", limit, tbl.expLimit)
+			}
+		})
+	}
+}
+
+func TestReadCgroupStatsFSSingle(t *testing.T) {
+	for _, tbl := range []struct {
+		name     string
+		f        fstest.MapFS
+		cpuAcctF fstest.MapFS
+		mode     cgresolver.CGMode
+		expCPU   CPUStats
+		expLimit float64
+		expMem   MemoryStats
+	}{
+		{
+			name: "v1",
+			f: fstest.MapFS{
+				"cpu.cfs_quota_us":      &fstest.MapFile{Data: []byte("-1\n")},
+				"cpu.cfs_period_us":     &fstest.MapFile{Data: []byte("100000\n")},
+				"cpu.stat":              &fstest.MapFile{Data: []byte("nr_periods 10\nnr_throttled 0\nthrottled_time 0\n")},
+				"memory.limit_in_bytes": &fstest.MapFile{Data: []byte("1000000\n")},
+				"memory.usage_in_bytes": &fstest.MapFile{Data: []byte("250000\n")},
+				"memory.stat":           &fstest.MapFile{Data: []byte("total_cache 1000\n")},
+				"memory.oom_control":    &fstest.MapFile{Data: []byte("oom_kill_disable 0\nunder_oom 0\noom_kill 1\n")},
+			},
+			cpuAcctF: fstest.MapFS{
+				"cpuacct.usage_user": &fstest.MapFile{Data: []byte("2000000000\n")},
+				"cpuacct.usage_sys":  &fstest.MapFile{Data: []byte("500000000\n")},
+			},
+			mode: cgresolver.CGModeV1,
+			expCPU: CPUStats{
+				Usage:        procstats.CPUTime{Utime: 2 * time.Second, Stime: 500 * time.Millisecond},
+				TotalPeriods: 10,
+			},
+			expLimit: 0,
+			expMem:   MemoryStats{Total: 1000000, Free: 750000, Available: 751000, OOMKills: 1},
+		},
+		{
+			name: "v2",
+			f: fstest.MapFS{
+				"cpu.max":        &fstest.MapFile{Data: []byte("200000 100000\n")},
+				"cpu.stat":       &fstest.MapFile{Data: []byte("usage_usec 3000000\nuser_usec 2000000\nsystem_usec 1000000\nnr_periods 5\nnr_throttled 2\nthrottled_usec 7000\n")},
+				"memory.stat":    &fstest.MapFile{Data: []byte("anon 100\n")},
+				"memory.current": &fstest.MapFile{Data: []byte("600000\n")},
+				"memory.max":     &fstest.MapFile{Data: []byte("1000000\n")},
+				"memory.events":  &fstest.MapFile{Data: []byte("oom_group_kill 4\n")},
+			},
+			mode: cgresolver.CGModeV2,
+			expCPU: CPUStats{
+				Usage:            procstats.CPUTime{Utime: 2 * time.Second, Stime: time.Second},
+				ThrottledTime:    7 * time.Millisecond,
+				TotalPeriods:     5,
+				ThrottledPeriods: 2,
+			},
+			expLimit: 2,
+			expMem:   MemoryStats{Total: 1000000, Free: 400000, Available: 400000, OOMKills: 4},
+		},
+	} {
+		t.Run(tbl.name, func(t *testing.T) {
+			cpuStats, cpuErr := ReadCgroupCPUStatsFS(tbl.f, tbl.cpuAcctF, tbl.mode)
+			if cpuErr != nil {
+				t.Fatalf("unexpected error reading CPU stats: %s", cpuErr)
+			}
+			if cpuStats != tbl.expCPU {
+				t.Errorf("unexpected CPU stats: %+v; expected %+v", cpuStats, tbl.expCPU)
+			}
+			limit, limitErr := ReadCgroupCPULimitFS(tbl.f, tbl.mode)
+			if limitErr != nil {
+				t.Fatalf("unexpected error reading CPU limit: %s", limitErr)
+			}
+			if limit != tbl.expLimit {
+				t.Errorf("unexpected CPU limit %g; expected %g", limit, tbl.expLimit)
+			}
+			ms, memErr := ReadCgroupMemoryStatsFS(tbl.f, tbl.mode)
+			if memErr != nil {
+				t.Fatalf("unexpected error reading memory stats: %s", memErr)
+			}
+			if ms != tbl.expMem {
+				t.Errorf("unexpected memory stats: %+v; expected %+v", ms, tbl.expMem)
+			}
+		})
+	}
+	if _, err := ReadCgroupMemoryStatsFS(fstest.MapFS{}, cgresolver.CGModeV2); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("unexpected error for empty cgroup directory: %v", err)
+	}
+	// without a limit, there's nothing to measure Free and Available
+	// against
+	unlimited, unlimitedErr := ReadCgroupMemoryStatsFS(fstest.MapFS{
+		"memory.stat":    &fstest.MapFile{Data: []byte("anon 0\nfile 4096\n")},
+		"memory.current": &fstest.MapFile{Data: []byte("8192\n")},
+		"memory.max":     &fstest.MapFile{Data: []byte("max\n")},
+	}, cgresolver.CGModeV2)
+	if unlimitedErr != nil {
+		t.Fatalf("unexpected error for unlimited cgroup: %s", unlimitedErr)
+	}
+	if unlimited.Total != -1 || unlimited.Free != -1 || unlimited.Available != -1 {
+		t.Errorf("unexpected unlimited memory stats: %+v; expected -1 total/free/available", unlimited)
+	}
+}
+
+func TestGetCgroupCPULimitFSUnlimited(t *testing.T) {
+	for _, tbl := range []struct {
+		name    string
+		f       fstest.MapFS
+		cpuPath cgresolver.CGroupPath
+	}{
+		{
+			name: "v2",
+			f: fstest.MapFS{
+				"sys/fs/cgroup/a/cpu.max":   &fstest.MapFile{Data: []byte("max 100000\n")},
+				"sys/fs/cgroup/a/b/cpu.max": &fstest.MapFile{Data: []byte("max 100000\n")},
+			},
+			cpuPath: cgresolver.CGroupPath{
+				AbsPath: "/sys/fs/cgroup/a/b", MountPath: "/sys/fs/cgroup", Mode: cgresolver.CGModeV2,
+			},
+		},
+		{
+			name: "v1",
+			f: fstest.MapFS{
+				"sys/fs/cgroup/cpu/cpu.cfs_quota_us":      &fstest.MapFile{Data: []byte("-1\n")},
+				"sys/fs/cgroup/cpu/cpu.cfs_period_us":     &fstest.MapFile{Data: []byte("100000\n")},
+				"sys/fs/cgroup/cpu/foo/cpu.cfs_quota_us":  &fstest.MapFile{Data: []byte("-1\n")},
+				"sys/fs/cgroup/cpu/foo/cpu.cfs_period_us": &fstest.MapFile{Data: []byte("100000\n")},
+			},
+			cpuPath: cgresolver.CGroupPath{
+				AbsPath: "/sys/fs/cgroup/cpu/foo", MountPath: "/sys/fs/cgroup/cpu", Mode: cgresolver.CGModeV1,
+			},
+		},
+	} {
+		t.Run(tbl.name, func(t *testing.T) {
+			limit, err := GetCgroupCPULimitFS(tbl.f, tbl.cpuPath)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if limit != 0 {
+				t.Errorf("unexpected limit %g; expected exactly 0", limit)
+			}
+		})
+	}
+}
+
+func TestCgroupFSWalkCancelled(t *testing.T) {
+	f := fstest.MapFS{
+		"sys/fs/cgroup/a/memory.max": &fstest.MapFile{Data: []byte("4000000000\n")},
+		"sys/fs/cgroup/a/cpu.max":    &fstest.MapFile{Data: []byte("100000 100000\n")},
+	}
+	cgPath := cgresolver.CGroupPath{
+		AbsPath: "/sys/fs/cgroup/a", MountPath: "/sys/fs/cgroup", Mode: cgresolver.CGModeV2,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, _, err := getCgroupMemoryLimitFS(ctx, f, cgPath, 0); !errors.Is(err, context.Canceled) {
+		t.Errorf("unexpected memory limit error: %v", err)
+	}
+	if _, err := getCgroupMemoryStatsFS(ctx, f, cgPath, 0); !errors.Is(err, context.Canceled) {
+		t.Errorf("unexpected memory stats error: %v", err)
+	}
+	if _, err := getCgroupCPULimitFS(ctx, f, cgPath); !errors.Is(err, context.Canceled) {
+		t.Errorf("unexpected CPU limit error: %v", err)
+	}
+	if _, err := getCgroupCPUStatsFS(ctx, f, cgPath, cgPath); !errors.Is(err, context.Canceled) {
+		t.Errorf("unexpected CPU stats error: %v", err)
+	}
+
+	// the same reads succeed with a live context
+	if lim, _, err := getCgroupMemoryLimitFS(context.Background(), f, cgPath, 0); err != nil || lim != 4000000000 {
+		t.Errorf("unexpected memory limit: %d, %v", lim, err)
+	}
+}
+
+// cancelOnOpenFS wraps an fs.FS, calling cancel when the file at path is
+// opened.
+type cancelOnOpenFS struct {
+	fs.FS
+	path   string
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnOpenFS) Open(name string) (fs.File, error) {
+	if name == c.path {
+		c.cancel()
+	}
+	return c.FS.Open(name)
+}
+
+func TestCgroupFSWalkCancelledMidWalk(t *testing.T) {
+	// The parent's limits are tighter than the leaf's, so a walk that
+	// continued past the leaf after cancellation would return them.
+	mapFS := fstest.MapFS{
+		"sys/fs/cgroup/a/memory.max":   &fstest.MapFile{Data: []byte("2000000000\n")},
+		"sys/fs/cgroup/a/cpu.max":      &fstest.MapFile{Data: []byte("100000 100000\n")},
+		"sys/fs/cgroup/a/b/memory.max": &fstest.MapFile{Data: []byte("4000000000\n")},
+		"sys/fs/cgroup/a/b/cpu.max":    &fstest.MapFile{Data: []byte("200000 100000\n")},
+	}
+	cgPath := cgresolver.CGroupPath{
+		AbsPath: "/sys/fs/cgroup/a/b", MountPath: "/sys/fs/cgroup", Mode: cgresolver.CGModeV2,
+	}
+
+	t.Run("memory_limit", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		f := &cancelOnOpenFS{FS: mapFS, path: "sys/fs/cgroup/a/b/memory.max", cancel: cancel}
+		if lim, _, err := getCgroupMemoryLimitFS(ctx, f, cgPath, 0); !errors.Is(err, context.Canceled) {
+			t.Errorf("unexpected memory limit: %d, %v; expected context.Canceled", lim, err)
+		}
+	})
+	t.Run("cpu_limit", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		f := &cancelOnOpenFS{FS: mapFS, path: "sys/fs/cgroup/a/b/cpu.max", cancel: cancel}
+		if lim, err := getCgroupCPULimitFS(ctx, f, cgPath); !errors.Is(err, context.Canceled) {
+			t.Errorf("unexpected CPU limit: %g, %v; expected context.Canceled", lim, err)
+		}
+	})
+}
+
+func TestReadThrottleCounters(t *testing.T) {
+	for _, tbl := range []struct {
+		name        string
+		f           fstest.MapFS
+		mode        cgresolver.CGMode
+		expCounters throttleCounters
+	}{
+		{
+			name: "v1",
+			f: fstest.MapFS{
+				"cpu.stat": &fstest.MapFile{Data: []byte("nr_periods 3000\nnr_throttled 120\nthrottled_time 4500000000\n")},
+			},
+			mode: cgresolver.CGModeV1,
+			expCounters: throttleCounters{
+				TotalPeriods:     3000,
+				ThrottledPeriods: 120,
+				ThrottledTime:    4500 * time.Millisecond,
+			},
+		},
+		{
+			name: "v2",
+			f: fstest.MapFS{
+				"cpu.stat": &fstest.MapFile{Data: []byte("usage_usec 8000000\nuser_usec 6000000\nsystem_usec 2000000\nnr_periods 3000\nnr_throttled 120\nthrottled_usec 4500000\nnr_bursts 0\nburst_usec 0\n")},
+			},
+			mode: cgresolver.CGModeV2,
+			expCounters: throttleCounters{
+				TotalPeriods:     3000,
+				ThrottledPeriods: 120,
+				ThrottledTime:    4500 * time.Millisecond,
+			},
+		},
+		{
+			name: "v2_no_quota",
+			f: fstest.MapFS{
+				"cpu.stat": &fstest.MapFile{Data: []byte("usage_usec 8000000\nuser_usec 6000000\nsystem_usec 2000000\n")},
+			},
+			mode:        cgresolver.CGModeV2,
+			expCounters: throttleCounters{},
+		},
+	} {
+		t.Run(tbl.name, func(t *testing.T) {
+			c, err := readThrottleCounters(tbl.f, tbl.mode)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if c != tbl.expCounters {
+				t.Errorf("unexpected counters: %+v; expected %+v", c, tbl.expCounters)
+			}
+		})
+	}
+	if _, err := readThrottleCounters(fstest.MapFS{}, cgresolver.CGModeV2); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("unexpected error for missing cpu.stat: %v", err)
+	}
+}
+
+func TestReadPSIStats(t *testing.T) {
+	f := fstest.MapFS{
+		"cpu.pressure": &fstest.MapFile{Data: []byte(
+			"some avg10=1.50 avg60=0.75 avg300=0.10 total=12345\n" +
+				"full avg10=0.00 avg60=0.00 avg300=0.00 total=0\n")},
+		"memory.pressure": &fstest.MapFile{Data: []byte(
+			"some avg10=0.00 avg60=0.00 avg300=0.00 total=4000000\n" +
+				"full avg10=0.00 avg60=0.00 avg300=0.00 total=3000000\n")},
+		// no "full" line (as with cpu.pressure on kernels older than
+		// 5.13)
+		"io.pressure": &fstest.MapFile{Data: []byte(
+			"some avg10=12.25 avg60=4.00 avg300=1.00 total=98765432\n")},
+	}
+	stats, err := readPSIStats(f)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expStats := PSIStats{
+		CPU: PSI{
+			Some: PSIMetrics{Avg10: 1.5, Avg60: 0.75, Avg300: 0.1, Total: 12345 * time.Microsecond},
+		},
+		Memory: PSI{
+			Some: PSIMetrics{Total: 4 * time.Second},
+			Full: PSIMetrics{Total: 3 * time.Second},
+		},
+		IO: PSI{
+			Some: PSIMetrics{Avg10: 12.25, Avg60: 4, Avg300: 1, Total: 98765432 * time.Microsecond},
+		},
+	}
+	if stats != expStats {
+		t.Errorf("unexpected PSI stats:\n  got %+v\n want %+v", stats, expStats)
+	}
+
+	delete(f, "io.pressure")
+	if _, err := readPSIStats(f); !errors.Is(err, ErrPSIUnavailable) {
+		t.Errorf("unexpected error for missing io.pressure: %v; expected ErrPSIUnavailable", err)
+	}
+
+	f["io.pressure"] = &fstest.MapFile{Data: []byte("some avg10=x avg60=0.00 avg300=0.00 total=0\n")}
+	if _, err := readPSIStats(f); err == nil || errors.Is(err, ErrPSIUnavailable) {
+		t.Errorf("unexpected error for malformed io.pressure: %v", err)
+	}
+}
+
+// testCPUPressure was captured from a systemd service's cgroup on a 6.1
+// kernel.
+const testCPUPressure = `some avg10=0.31 avg60=0.12 avg300=0.03 total=91427630
+full avg10=0.00 avg60=0.00 avg300=0.00 total=38571
+`
+
+func TestParsePSICPUPressure(t *testing.T) {
+	psi, err := parsePSI([]byte(testCPUPressure))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expPSI := PSI{
+		Some: PSIMetrics{Avg10: 0.31, Avg60: 0.12, Avg300: 0.03, Total: 91427630 * time.Microsecond},
+		Full: PSIMetrics{Total: 38571 * time.Microsecond},
+	}
+	if psi != expPSI {
+		t.Errorf("unexpected PSI:\n  got %+v\n want %+v", psi, expPSI)
+	}
+}
+
+func TestReadCGroupPIDStats(t *testing.T) {
+	f := fstest.MapFS{
+		"sys/fs/cgroup/pids.current":           &fstest.MapFile{Data: []byte("400\n")},
+		"sys/fs/cgroup/a/pids.current":         &fstest.MapFile{Data: []byte("30\n")},
+		"sys/fs/cgroup/a/pids.max":             &fstest.MapFile{Data: []byte("100\n")},
+		"sys/fs/cgroup/a/b/pids.current":       &fstest.MapFile{Data: []byte("12\n")},
+		"sys/fs/cgroup/a/b/pids.max":           &fstest.MapFile{Data: []byte("max\n")},
+		"sys/fs/cgroup/a/b/c/pids.current":     &fstest.MapFile{Data: []byte("7\n")},
+		"sys/fs/cgroup/a/b/c/pids.max":         &fstest.MapFile{Data: []byte("500\n")},
+		"sys/fs/cgroup/unlimited/pids.current": &fstest.MapFile{Data: []byte("3\n")},
+		"sys/fs/cgroup/unlimited/pids.max":     &fstest.MapFile{Data: []byte("max\n")},
+	}
+	for _, tbl := range []struct {
+		name     string
+		absPath  string
+		expStats PIDStats
+	}{
+		{name: "limited_ancestor", absPath: "/sys/fs/cgroup/a/b/c", expStats: PIDStats{Current: 7, Limit: 100}},
+		{name: "limited_leaf", absPath: "/sys/fs/cgroup/a", expStats: PIDStats{Current: 30, Limit: 100}},
+		{name: "unlimited", absPath: "/sys/fs/cgroup/unlimited", expStats: PIDStats{Current: 3, Limit: math.MaxInt64}},
+	} {
+		t.Run(tbl.name, func(t *testing.T) {
+			stats, err := readCGroupPIDStats(f, cgresolver.CGroupPath{
+				AbsPath:   tbl.absPath,
+				MountPath: "/sys/fs/cgroup",
+				Mode:      cgresolver.CGModeV2,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if stats != tbl.expStats {
+				t.Errorf("unexpected stats: %+v; expected %+v", stats, tbl.expStats)
+			}
+		})
+	}
+	// The root cgroup has no pids.max, so there's no limit to read.
+	if _, err := readCGroupPIDStats(f, cgresolver.CGroupPath{
+		AbsPath: "/sys/fs/cgroup", MountPath: "/sys/fs/cgroup", Mode: cgresolver.CGModeV2,
+	}); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("unexpected error for root cgroup: %v", err)
+	}
+}
+
+func TestOptionalCGroupPIDStatsV1NoPIDs(t *testing.T) {
+	// a cgroup v1 host whose kernel has no pids controller
+	procRoot := t.TempDir()
+	if err := os.Mkdir(filepath.Join(procRoot, "self"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, contents := range map[string]string{
+		"cgroups": `#subsys_name	hierarchy	num_cgroups	enabled
+cpu	1	1	1
+cpuacct	1	1	1
+memory	2	1	1
+`,
+		"self/cgroup": `2:memory:/a
+1:cpu,cpuacct:/a
+`,
+		"self/mountinfo": `32 24 0:28 / /sys/fs/cgroup ro,nosuid,nodev,noexec - tmpfs tmpfs ro,mode=755
+33 32 0:29 / /sys/fs/cgroup/cpu,cpuacct rw,nosuid,nodev,noexec,relatime - cgroup cgroup rw,cpu,cpuacct
+34 32 0:30 / /sys/fs/cgroup/memory rw,nosuid,nodev,noexec,relatime - cgroup cgroup rw,memory
+`,
+	} {
+		if err := os.WriteFile(filepath.Join(procRoot, name), []byte(contents), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cgresolver.SetProcRoot(procRoot)
+	t.Cleanup(func() { cgresolver.SetProcRoot("") })
+
+	cgr := cgresolver.NewCache()
+	if _, err := cgr.Resolve("memory"); err != nil {
+		t.Fatalf("failed to resolve memory cgroup: %s", err)
+	}
+	if _, err := cgr.Resolve("pids"); err == nil {
+		t.Fatal("unexpectedly resolved pids cgroup")
+	}
+	// the strict getter still fails (with the resolution error wrapped)
+	if _, err := cgroupPIDStats(cgr); err == nil {
+		t.Error("cgroupPIDStats unexpectedly succeeded")
+	}
+	pids, err := optionalCGroupPIDStats(fstest.MapFS{}, cgr)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if pids != (PIDStats{}) {
+		t.Errorf("unexpected pids stats %+v; expected zero value", pids)
+	}
+}
+
+func TestOptionalCGroupPIDStats(t *testing.T) {
+	procRoot := t.TempDir()
+	if err := os.Mkdir(filepath.Join(procRoot, "self"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, contents := range map[string]string{
+		"self/cgroup":    "0::/a\n",
+		"self/mountinfo": "30 24 0:26 / /sys/fs/cgroup rw,nosuid,nodev,noexec,relatime - cgroup2 cgroup2 rw\n",
+	} {
+		if err := os.WriteFile(filepath.Join(procRoot, name), []byte(contents), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cgresolver.SetProcRoot(procRoot)
+	t.Cleanup(func() { cgresolver.SetProcRoot("") })
+
+	pids, err := optionalCGroupPIDStats(fstest.MapFS{
+		"sys/fs/cgroup/a/pids.current": &fstest.MapFile{Data: []byte("7\n")},
+		"sys/fs/cgroup/a/pids.max":     &fstest.MapFile{Data: []byte("100\n")},
+	}, cgresolver.NewCache())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if exp := (PIDStats{Current: 7, Limit: 100}); pids != exp {
+		t.Errorf("unexpected pids stats %+v; expected %+v", pids, exp)
+	}
+
+	// the pids controller isn't enabled in the cgroup
+	pids, err = optionalCGroupPIDStats(fstest.MapFS{}, cgresolver.NewCache())
+	if err != nil {
+		t.Fatalf("unexpected error without pids files: %s", err)
+	}
+	if pids != (PIDStats{}) {
+		t.Errorf("unexpected pids stats %+v; expected zero value", pids)
+	}
+}
+
+func TestReadSwapStats(t *testing.T) {
+	for _, tbl := range []struct {
+		name     string
+		mode     cgresolver.CGMode
+		f        fstest.MapFS
+		expStats SwapStats
+	}{
+		{
+			name: "v2_limited",
+			mode: cgresolver.CGModeV2,
+			f: fstest.MapFS{
+				"memory.swap.current": &fstest.MapFile{Data: []byte("4096\n")},
+				"memory.swap.max":     &fstest.MapFile{Data: []byte("1048576\n")},
+			},
+			expStats: SwapStats{SwapUsage: 4096, SwapLimit: 1 << 20},
+		},
+		{
+			name: "v2_unlimited",
+			mode: cgresolver.CGModeV2,
+			f: fstest.MapFS{
+				"memory.swap.current": &fstest.MapFile{Data: []byte("0\n")},
+				"memory.swap.max":     &fstest.MapFile{Data: []byte("max\n")},
+			},
+			expStats: SwapStats{SwapUsage: 0, SwapLimit: math.MaxInt64},
+		},
+		{
+			name: "v1_limited",
+			mode: cgresolver.CGModeV1,
+			f: fstest.MapFS{
+				"memory.usage_in_bytes":       &fstest.MapFile{Data: []byte("8192\n")},
+				"memory.limit_in_bytes":       &fstest.MapFile{Data: []byte("16384\n")},
+				"memory.memsw.usage_in_bytes": &fstest.MapFile{Data: []byte("12288\n")},
+				"memory.memsw.limit_in_bytes": &fstest.MapFile{Data: []byte("32768\n")},
+			},
+			expStats: SwapStats{SwapUsage: 4096, SwapLimit: 16384},
+		},
+		{
+			name: "v1_unlimited",
+			mode: cgresolver.CGModeV1,
+			f: fstest.MapFS{
+				"memory.usage_in_bytes":       &fstest.MapFile{Data: []byte("8192\n")},
+				"memory.limit_in_bytes":       &fstest.MapFile{Data: []byte("9223372036854771712\n")},
+				"memory.memsw.usage_in_bytes": &fstest.MapFile{Data: []byte("8192\n")},
+				"memory.memsw.limit_in_bytes": &fstest.MapFile{Data: []byte("9223372036854771712\n")},
+			},
+			expStats: SwapStats{SwapUsage: 0, SwapLimit: math.MaxInt64},
+		},
+	} {
+		t.Run(tbl.name, func(t *testing.T) {
+			stats, err := readSwapStats(tbl.f, tbl.mode)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if stats != tbl.expStats {
+				t.Errorf("unexpected stats: %+v; expected %+v", stats, tbl.expStats)
+			}
+		})
+	}
+	// swap accounting disabled
+	if _, err := readSwapStats(fstest.MapFS{}, cgresolver.CGModeV2); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("unexpected error with swap accounting disabled: %v", err)
+	}
+}
+
+func TestReadCgroupMemoryStatsSwap(t *testing.T) {
+	for _, tbl := range []struct {
+		name    string
+		f       fstest.MapFS
+		mode    cgresolver.CGMode
+		expSwap [2]int64 // total, used
+	}{
+		{
+			name: "v1",
+			f: fstest.MapFS{
+				"memory.limit_in_bytes":       &fstest.MapFile{Data: []byte("16384\n")},
+				"memory.usage_in_bytes":       &fstest.MapFile{Data: []byte("8192\n")},
+				"memory.memsw.limit_in_bytes": &fstest.MapFile{Data: []byte("24576\n")},
+				"memory.memsw.usage_in_bytes": &fstest.MapFile{Data: []byte("12288\n")},
+				"memory.stat":                 &fstest.MapFile{Data: []byte("total_cache 0\n")},
+				"memory.oom_control":          &fstest.MapFile{Data: []byte("oom_kill_disable 0\nunder_oom 0\noom_kill 0\n")},
+			},
+			mode:    cgresolver.CGModeV1,
+			expSwap: [2]int64{8192, 4096},
+		},
+		{
+			name: "v1_no_swap_accounting",
+			f: fstest.MapFS{
+				"memory.limit_in_bytes": &fstest.MapFile{Data: []byte("16384\n")},
+				"memory.usage_in_bytes": &fstest.MapFile{Data: []byte("8192\n")},
+				"memory.stat":           &fstest.MapFile{Data: []byte("total_cache 0\n")},
+				"memory.oom_control":    &fstest.MapFile{Data: []byte("oom_kill_disable 0\nunder_oom 0\noom_kill 0\n")},
+			},
+			mode: cgresolver.CGModeV1,
+		},
+		{
+			name: "v2",
+			f: fstest.MapFS{
+				"memory.stat":         &fstest.MapFile{Data: []byte("anon 0\n")},
+				"memory.current":      &fstest.MapFile{Data: []byte("8192\n")},
+				"memory.max":          &fstest.MapFile{Data: []byte("16384\n")},
+				"memory.swap.current": &fstest.MapFile{Data: []byte("4096\n")},
+				"memory.swap.max":     &fstest.MapFile{Data: []byte("max\n")},
+			},
+			mode:    cgresolver.CGModeV2,
+			expSwap: [2]int64{math.MaxInt64, 4096},
+		},
+		{
+			name: "v2_no_swap_accounting",
+			f: fstest.MapFS{
+				"memory.stat":    &fstest.MapFile{Data: []byte("anon 0\n")},
+				"memory.current": &fstest.MapFile{Data: []byte("8192\n")},
+				"memory.max":     &fstest.MapFile{Data: []byte("16384\n")},
+			},
+			mode: cgresolver.CGModeV2,
+		},
+	} {
+		t.Run(tbl.name, func(t *testing.T) {
+			ms, err := ReadCgroupMemoryStatsFS(tbl.f, tbl.mode)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if ms.Total != 16384 || ms.Free != 8192 {
+				t.Errorf("unexpected memory total/free: %d/%d; expected 16384/8192", ms.Total, ms.Free)
+			}
+			if swap := [2]int64{ms.SwapTotal, ms.SwapUsed}; swap != tbl.expSwap {
+				t.Errorf("unexpected swap total/used: %v; expected %v", swap, tbl.expSwap)
+			}
+		})
+	}
+}
+
+func TestReadMemoryThresholds(t *testing.T) {
+	for _, tbl := range []struct {
+		name  string
+		f     fstest.MapFS
+		mode  cgresolver.CGMode
+		expTh MemoryThresholds
+	}{
+		{
+			name: "v2_high_without_max",
+			f: fstest.MapFS{
+				"memory.min":  &fstest.MapFile{Data: []byte("0\n")},
+				"memory.low":  &fstest.MapFile{Data: []byte("1073741824\n")},
+				"memory.high": &fstest.MapFile{Data: []byte("3221225472\n")},
+				"memory.max":  &fstest.MapFile{Data: []byte("max\n")},
+			},
+			mode:  cgresolver.CGModeV2,
+			expTh: MemoryThresholds{Min: 0, Low: 1 << 30, High: 3 << 30, Max: math.MaxInt64},
+		},
+		{
+			name: "v2_unlimited",
+			f: fstest.MapFS{
+				"memory.min":  &fstest.MapFile{Data: []byte("0\n")},
+				"memory.low":  &fstest.MapFile{Data: []byte("0\n")},
+				"memory.high": &fstest.MapFile{Data: []byte("max\n")},
+				"memory.max":  &fstest.MapFile{Data: []byte("max\n")},
+			},
+			mode:  cgresolver.CGModeV2,
+			expTh: MemoryThresholds{High: math.MaxInt64, Max: math.MaxInt64},
+		},
+		{
+			name: "v1_soft_limit",
+			f: fstest.MapFS{
+				"memory.soft_limit_in_bytes": &fstest.MapFile{Data: []byte("2147483648\n")},
+				"memory.limit_in_bytes":      &fstest.MapFile{Data: []byte("9223372036854771712\n")},
+			},
+			mode:  cgresolver.CGModeV1,
+			expTh: MemoryThresholds{High: 2 << 30, Max: math.MaxInt64},
+		},
+	} {
+		t.Run(tbl.name, func(t *testing.T) {
+			th, err := readMemoryThresholds(tbl.f, tbl.mode)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if th != tbl.expTh {
+				t.Errorf("unexpected thresholds: %+v; expected %+v", th, tbl.expTh)
+			}
+		})
+	}
+
+	// the root cgroup has none of these files
+	if _, err := readMemoryThresholds(fstest.MapFS{}, cgresolver.CGModeV2); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("unexpected error for missing memory.min: %v", err)
+	}
+}
+
+func TestReadCGroupIOStats(t *testing.T) {
+	for _, tbl := range []struct {
+		name     string
+		f        fstest.MapFS
+		mode     cgresolver.CGMode
+		expStats map[DeviceID]IOStats
+		expErr   string
+	}{
+		{
+			name: "v2",
+			f: fstest.MapFS{
+				"io.stat": &fstest.MapFile{Data: []byte(
+					"8:0 rbytes=1024 wbytes=2048 rios=10 wios=20 dbytes=0 dios=0\n" +
+						"259:1 rbytes=4096 wbytes=0 rios=1 wios=0 dbytes=512 dios=2 use_delay=0\n")},
+			},
+			mode: cgresolver.CGModeV2,
+			expStats: map[DeviceID]IOStats{
+				{Major: 8, Minor: 0}:   {ReadBytes: 1024, WriteBytes: 2048, ReadIOs: 10, WriteIOs: 20},
+				{Major: 259, Minor: 1}: {ReadBytes: 4096, ReadIOs: 1, DiscardBytes: 512, DiscardIOs: 2},
+			},
+		},
+		{
+			name: "v2_malformed_device",
+			f: fstest.MapFS{
+				"io.stat": &fstest.MapFile{Data: []byte(
+					"8:0 rbytes=1024 wbytes=2048 rios=10 wios=20 dbytes=0 dios=0\n" +
+						"8:16 rbytes=12x wbytes=0 rios=1 wios=0 dbytes=0 dios=0\n")},
+			},
+			mode: cgresolver.CGModeV2,
+			expStats: map[DeviceID]IOStats{
+				{Major: 8, Minor: 0}: {ReadBytes: 1024, WriteBytes: 2048, ReadIOs: 10, WriteIOs: 20},
+			},
+			expErr: "device 8:16",
+		},
+		{
+			name: "v1",
+			f: fstest.MapFS{
+				"blkio.throttle.io_service_bytes": &fstest.MapFile{Data: []byte(
+					"8:0 Read 1024\n8:0 Write 2048\n8:0 Sync 0\n8:0 Async 3072\n8:0 Discard 0\n8:0 Total 3072\n" +
+						"8:16 Read 4096\n8:16 Write 0\n8:16 Sync 0\n8:16 Async 4096\n8:16 Discard 512\n8:16 Total 4096\n" +
+						"Total 7168\n")},
+				"blkio.throttle.io_serviced": &fstest.MapFile{Data: []byte(
+					"8:0 Read 10\n8:0 Write 20\n8:0 Sync 0\n8:0 Async 30\n8:0 Discard 0\n8:0 Total 30\n" +
+						"8:16 Read 1\n8:16 Write 0\n8:16 Sync 0\n8:16 Async 1\n8:16 Discard 2\n8:16 Total 1\n" +
+						"Total 31\n")},
+			},
+			mode: cgresolver.CGModeV1,
+			expStats: map[DeviceID]IOStats{
+				{Major: 8, Minor: 0}:  {ReadBytes: 1024, WriteBytes: 2048, ReadIOs: 10, WriteIOs: 20},
+				{Major: 8, Minor: 16}: {ReadBytes: 4096, ReadIOs: 1, DiscardBytes: 512, DiscardIOs: 2},
+			},
+		},
+		{
+			name: "v1_malformed_device",
+			f: fstest.MapFS{
+				"blkio.throttle.io_service_bytes": &fstest.MapFile{Data: []byte(
+					"8:0 Read 1024\n8:0 Write 2048\n8:x Read 5\nTotal 3072\n")},
+				"blkio.throttle.io_serviced": &fstest.MapFile{Data: []byte(
+					"8:0 Read 10\n8:0 Write 20\nTotal 30\n")},
+			},
+			mode: cgresolver.CGModeV1,
+			expStats: map[DeviceID]IOStats{
+				{Major: 8, Minor: 0}: {ReadBytes: 1024, WriteBytes: 2048, ReadIOs: 10, WriteIOs: 20},
+			},
+			expErr: `device "8:x"`,
+		},
+	} {
+		t.Run(tbl.name, func(t *testing.T) {
+			stats, err := readCGroupIOStats(tbl.f, tbl.mode)
+			if tbl.expErr == "" && err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if tbl.expErr != "" && (err == nil || !strings.Contains(err.Error(), tbl.expErr)) {
+				t.Errorf("unexpected error: %v; expected one containing %q", err, tbl.expErr)
+			}
+			if !maps.Equal(stats, tbl.expStats) {
+				t.Errorf("unexpected stats:\n  got %+v\n want %+v", stats, tbl.expStats)
+			}
+		})
+	}
+	if _, err := readCGroupIOStats(fstest.MapFS{}, cgresolver.CGModeV2); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("unexpected error for missing io.stat: %v", err)
+	}
+}
@@ -0,0 +1,301 @@
+//go:build !linux
+// +build !linux
+
+package cgrouplimits
+
+import (
+	"context"
+	"io/fs"
+
+	"github.com/vimeo/procstats/cgresolver"
+)
+
+// GetCgroupCPULimitFS is not implemented on non-linux platforms (returns
+// ErrCGroupsNotSupported)
+func GetCgroupCPULimitFS(root fs.FS, cpuPath cgresolver.CGroupPath) (float64, error) {
+	return 0.0, ErrCGroupsNotSupported
+}
+
+// ReadCgroupCPULimitFS is not implemented on non-linux platforms (returns
+// ErrCGroupsNotSupported)
+func ReadCgroupCPULimitFS(f fs.FS, mode cgresolver.CGMode) (float64, error) {
+	return 0.0, ErrCGroupsNotSupported
+}
+
+// GetCgroupCPUStats gets Cgroup CPU Stats
+func GetCgroupCPUStats() (CPUStats, error) {
+	return CPUStats{}, ErrCGroupsNotSupported
+}
+
+// GetCgroupCPUStatsContext is not implemented on non-linux platforms (returns
+// ErrCGroupsNotSupported)
+func GetCgroupCPUStatsContext(ctx context.Context) (CPUStats, error) {
+	return CPUStats{}, ErrCGroupsNotSupported
+}
+
+// GetCgroupCPUStatsForPID is not implemented on non-linux platforms (returns
+// ErrCGroupsNotSupported)
+func GetCgroupCPUStatsForPID(pid int) (CPUStats, error) {
+	return CPUStats{}, ErrCGroupsNotSupported
+}
+
+// GetCgroupCPUStatsFS is not implemented on non-linux platforms (returns
+// ErrCGroupsNotSupported)
+func GetCgroupCPUStatsFS(root fs.FS, cpuPath, cpuAcctPath cgresolver.CGroupPath) (CPUStats, error) {
+	return CPUStats{}, ErrCGroupsNotSupported
+}
+
+// ReadCgroupCPUStatsFS is not implemented on non-linux platforms (returns
+// ErrCGroupsNotSupported)
+func ReadCgroupCPUStatsFS(f, cpuAcctF fs.FS, mode cgresolver.CGMode) (CPUStats, error) {
+	return CPUStats{}, ErrCGroupsNotSupported
+}
+
+// GetCgroupMemoryLimitDetailed is not implemented on non-linux platforms (returns
+// ErrCGroupsNotSupported)
+func GetCgroupMemoryLimitDetailed() (limit int64, source cgresolver.CGroupPath, err error) {
+	return 0, cgresolver.CGroupPath{}, ErrCGroupsNotSupported
+}
+
+// GetCgroupMemoryLimitForPID is not implemented on non-linux platforms (returns
+// ErrCGroupsNotSupported)
+func GetCgroupMemoryLimitForPID(pid int) (int64, error) {
+	return 0, ErrCGroupsNotSupported
+}
+
+// GetCgroupMemoryLimitFS is not implemented on non-linux platforms (returns
+// ErrCGroupsNotSupported)
+func GetCgroupMemoryLimitFS(root fs.FS, memPath cgresolver.CGroupPath) (int64, error) {
+	return 0, ErrCGroupsNotSupported
+}
+
+// GetCgroupMemoryStats queries the current process's memory cgroup's memory
+// usage/limits.
+func GetCgroupMemoryStats() (MemoryStats, error) {
+	return MemoryStats{}, ErrCGroupsNotSupported
+}
+
+// GetCgroupMemoryStatsContext is not implemented on non-linux platforms (returns
+// ErrCGroupsNotSupported)
+func GetCgroupMemoryStatsContext(ctx context.Context) (MemoryStats, error) {
+	return MemoryStats{}, ErrCGroupsNotSupported
+}
+
+// GetCgroupMemoryStatsForPID is not implemented on non-linux platforms (returns
+// ErrCGroupsNotSupported)
+func GetCgroupMemoryStatsForPID(pid int) (MemoryStats, error) {
+	return MemoryStats{}, ErrCGroupsNotSupported
+}
+
+// GetCgroupMemoryStatsFS is not implemented on non-linux platforms (returns
+// ErrCGroupsNotSupported)
+func GetCgroupMemoryStatsFS(root fs.FS, memPath cgresolver.CGroupPath) (MemoryStats, error) {
+	return MemoryStats{}, ErrCGroupsNotSupported
+}
+
+// ReadCgroupMemoryStatsFS is not implemented on non-linux platforms (returns
+// ErrCGroupsNotSupported)
+func ReadCgroupMemoryStatsFS(f fs.FS, mode cgresolver.CGMode) (MemoryStats, error) {
+	return MemoryStats{}, ErrCGroupsNotSupported
+}
+
+// GetCgroupPageTableMemory returns the memory used for page tables by the
+// current process's memory cgroup. (on unsupported systems it returns
+// ErrCGroupsNotSupported)
+func GetCgroupPageTableMemory() (pagetables, secPagetables int64, err error) {
+	return -1, -1, ErrCGroupsNotSupported
+}
+
+// GetCgroupZswapStats returns the zswap usage and activity of the current
+// process's memory cgroup. (on unsupported systems it returns
+// ErrCGroupsNotSupported)
+func GetCgroupZswapStats() (ZswapStats, error) {
+	return ZswapStats{}, ErrCGroupsNotSupported
+}
+
+// CgroupOnlineCPUs returns the number of CPUs the current process's cpuset
+// cgroup allows it to run on. (on unsupported systems it returns
+// ErrCGroupsNotSupported)
+func CgroupOnlineCPUs() (int, error) {
+	return -1, ErrCGroupsNotSupported
+}
+
+// GetCgroupCPUSet returns the set of CPUs the current process's cpuset
+// cgroup allows it to run on. (on unsupported systems it returns
+// ErrCGroupsNotSupported)
+func GetCgroupCPUSet() (CPUSet, error) {
+	return CPUSet{}, ErrCGroupsNotSupported
+}
+
+// GetCgroupCPUSetFlags returns the scheduling and memory-placement flags of
+// the current process's cpuset cgroup. (on unsupported systems it returns
+// ErrCGroupsNotSupported)
+func GetCgroupCPUSetFlags() (CPUSetFlags, error) {
+	return CPUSetFlags{}, ErrCGroupsNotSupported
+}
+
+func cgroupThrottleCounters(ctx context.Context, cgr *cgresolver.Cache) (throttleCounters, error) {
+	return throttleCounters{}, ErrCGroupsNotSupported
+}
+
+// GetCgroupPSI is not implemented on non-linux platforms (returns
+// ErrCGroupsNotSupported)
+func GetCgroupPSI() (PSIStats, error) {
+	return PSIStats{}, ErrCGroupsNotSupported
+}
+
+// GetCgroupCPUPressure is not implemented on non-linux platforms (returns
+// ErrCGroupsNotSupported)
+func GetCgroupCPUPressure() (PSI, error) {
+	return PSI{}, ErrCGroupsNotSupported
+}
+
+// GetCgroupMemoryPressure is not implemented on non-linux platforms (returns
+// ErrCGroupsNotSupported)
+func GetCgroupMemoryPressure() (PSI, error) {
+	return PSI{}, ErrCGroupsNotSupported
+}
+
+// GetCgroupIOPressure is not implemented on non-linux platforms (returns
+// ErrCGroupsNotSupported)
+func GetCgroupIOPressure() (PSI, error) {
+	return PSI{}, ErrCGroupsNotSupported
+}
+
+// GetCgroupPIDStats is not implemented on non-linux platforms (returns
+// ErrCGroupsNotSupported)
+func GetCgroupPIDStats() (PIDStats, error) {
+	return PIDStats{}, ErrCGroupsNotSupported
+}
+
+// GetCgroupIOStats is not implemented on non-linux platforms (returns
+// ErrCGroupsNotSupported)
+func GetCgroupIOStats() (map[DeviceID]IOStats, error) {
+	return nil, ErrCGroupsNotSupported
+}
+
+// GetCgroupSwapStats is not implemented on non-linux platforms (returns
+// ErrCGroupsNotSupported)
+func GetCgroupSwapStats() (SwapStats, error) {
+	return SwapStats{}, ErrCGroupsNotSupported
+}
+
+// GetCgroupMemoryThresholds is not implemented on non-linux platforms
+// (returns ErrCGroupsNotSupported)
+func GetCgroupMemoryThresholds() (MemoryThresholds, error) {
+	return MemoryThresholds{}, ErrCGroupsNotSupported
+}
+
+// GetResourceLimits is not implemented on non-linux platforms (returns
+// ErrCGroupsNotSupported)
+func GetResourceLimits() (ResourceLimits, error) {
+	return ResourceLimits{}, ErrCGroupsNotSupported
+}
+
+// GetCgroupCPULimitWithCache is not implemented on non-linux platforms (returns
+// ErrCGroupsNotSupported)
+func GetCgroupCPULimitWithCache(ctx context.Context, cgr *cgresolver.Cache) (float64, error) {
+	return 0.0, ErrCGroupsNotSupported
+}
+
+// GetCgroupMemoryLimitWithCache is not implemented on non-linux platforms (returns
+// ErrCGroupsNotSupported)
+func GetCgroupMemoryLimitWithCache(ctx context.Context, cgr *cgresolver.Cache) (int64, error) {
+	return 0, ErrCGroupsNotSupported
+}
+
+// GetCgroupMemoryStatsWithCache is not implemented on non-linux platforms (returns
+// ErrCGroupsNotSupported)
+func GetCgroupMemoryStatsWithCache(ctx context.Context, cgr *cgresolver.Cache) (MemoryStats, error) {
+	return MemoryStats{}, ErrCGroupsNotSupported
+}
+
+// GetCgroupCPUStatsWithCache is not implemented on non-linux platforms (returns
+// ErrCGroupsNotSupported)
+func GetCgroupCPUStatsWithCache(ctx context.Context, cgr *cgresolver.Cache) (CPUStats, error) {
+	return CPUStats{}, ErrCGroupsNotSupported
+}
+
+// GetResourceLimitsWithCache is not implemented on non-linux platforms (returns
+// ErrCGroupsNotSupported)
+func GetResourceLimitsWithCache(ctx context.Context, cgr *cgresolver.Cache) (ResourceLimits, error) {
+	return ResourceLimits{}, ErrCGroupsNotSupported
+}
+
+// GetCgroupPIDStatsWithCache is not implemented on non-linux platforms (returns
+// ErrCGroupsNotSupported)
+func GetCgroupPIDStatsWithCache(ctx context.Context, cgr *cgresolver.Cache) (PIDStats, error) {
+	return PIDStats{}, ErrCGroupsNotSupported
+}
+
+// GetCgroupSwapStatsWithCache is not implemented on non-linux platforms (returns
+// ErrCGroupsNotSupported)
+func GetCgroupSwapStatsWithCache(ctx context.Context, cgr *cgresolver.Cache) (SwapStats, error) {
+	return SwapStats{}, ErrCGroupsNotSupported
+}
+
+// GetCgroupMemoryThresholdsWithCache is not implemented on non-linux platforms (returns
+// ErrCGroupsNotSupported)
+func GetCgroupMemoryThresholdsWithCache(ctx context.Context, cgr *cgresolver.Cache) (MemoryThresholds, error) {
+	return MemoryThresholds{}, ErrCGroupsNotSupported
+}
+
+// GetCgroupPageTableMemoryWithCache is not implemented on non-linux platforms (returns
+// ErrCGroupsNotSupported)
+func GetCgroupPageTableMemoryWithCache(ctx context.Context, cgr *cgresolver.Cache) (pagetables, secPagetables int64, err error) {
+	return -1, -1, ErrCGroupsNotSupported
+}
+
+// GetCgroupZswapStatsWithCache is not implemented on non-linux platforms (returns
+// ErrCGroupsNotSupported)
+func GetCgroupZswapStatsWithCache(ctx context.Context, cgr *cgresolver.Cache) (ZswapStats, error) {
+	return ZswapStats{}, ErrCGroupsNotSupported
+}
+
+// GetCgroupPSIWithCache is not implemented on non-linux platforms (returns
+// ErrCGroupsNotSupported)
+func GetCgroupPSIWithCache(ctx context.Context, cgr *cgresolver.Cache) (PSIStats, error) {
+	return PSIStats{}, ErrCGroupsNotSupported
+}
+
+// GetCgroupCPUPressureWithCache is not implemented on non-linux platforms (returns
+// ErrCGroupsNotSupported)
+func GetCgroupCPUPressureWithCache(ctx context.Context, cgr *cgresolver.Cache) (PSI, error) {
+	return PSI{}, ErrCGroupsNotSupported
+}
+
+// GetCgroupMemoryPressureWithCache is not implemented on non-linux platforms (returns
+// ErrCGroupsNotSupported)
+func GetCgroupMemoryPressureWithCache(ctx context.Context, cgr *cgresolver.Cache) (PSI, error) {
+	return PSI{}, ErrCGroupsNotSupported
+}
+
+// GetCgroupIOPressureWithCache is not implemented on non-linux platforms (returns
+// ErrCGroupsNotSupported)
+func GetCgroupIOPressureWithCache(ctx context.Context, cgr *cgresolver.Cache) (PSI, error) {
+	return PSI{}, ErrCGroupsNotSupported
+}
+
+// GetCgroupIOStatsWithCache is not implemented on non-linux platforms (returns
+// ErrCGroupsNotSupported)
+func GetCgroupIOStatsWithCache(ctx context.Context, cgr *cgresolver.Cache) (map[DeviceID]IOStats, error) {
+	return nil, ErrCGroupsNotSupported
+}
+
+// CgroupOnlineCPUsWithCache is not implemented on non-linux platforms (returns
+// ErrCGroupsNotSupported)
+func CgroupOnlineCPUsWithCache(ctx context.Context, cgr *cgresolver.Cache) (int, error) {
+	return -1, ErrCGroupsNotSupported
+}
+
+// GetCgroupCPUSetWithCache is not implemented on non-linux platforms (returns
+// ErrCGroupsNotSupported)
+func GetCgroupCPUSetWithCache(ctx context.Context, cgr *cgresolver.Cache) (CPUSet, error) {
+	return CPUSet{}, ErrCGroupsNotSupported
+}
+
+// GetCgroupCPUSetFlagsWithCache is not implemented on non-linux platforms (returns
+// ErrCGroupsNotSupported)
+func GetCgroupCPUSetFlagsWithCache(ctx context.Context, cgr *cgresolver.Cache) (CPUSetFlags, error) {
+	return CPUSetFlags{}, ErrCGroupsNotSupported
+}
@@ -1,9 +1,18 @@
 package cgrouplimits
 
 import (
+	"context"
+	"errors"
+	"io/fs"
 	"math"
+	"os"
+	"runtime"
+	"slices"
 	"testing"
 	"time"
+
+	"github.com/vimeo/procstats"
+	"github.com/vimeo/procstats/cgresolver"
 )
 
 func TestCgroupCPULimitsRead(t *testing.T) {
@@ -18,8 +27,8 @@ func TestCgroupCPULimitsRead(t *testing.T) {
 	if limit < 0.0 {
 		t.Errorf("unexpectedly negative limit: %g", limit)
 	}
-	if limit > 10000.0 && !math.IsInf(limit, +1) {
-		t.Errorf("unexpectedly large limit (not infinite): %g", limit)
+	if limit > 10000.0 || math.IsInf(limit, +1) {
+		t.Errorf("unexpectedly large limit: %g", limit)
 	}
 }
 
@@ -32,8 +41,8 @@ func TestCgroupMemLimitsRead(t *testing.T) {
 	if err != nil {
 		t.Fatalf("failed to query Memory limit: %s", err)
 	}
-	if limit < 0 {
-		t.Errorf("unexpectedly negative limit: %d", limit)
+	if limit == -1 {
+		t.Skip("no memory limit applies to this process")
 	}
 	if limit < 4096 {
 		t.Errorf("unexpectedly small limit (less than a page): %d", limit)
@@ -60,6 +69,176 @@ func TestCgroupMemStatsRead(t *testing.T) {
 	}
 }
 
+func TestCgroupStatsContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := GetCgroupMemoryStatsContext(ctx)
+	if errors.Is(err, ErrCGroupsNotSupported) {
+		t.Skip("unsupported platform")
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("unexpected memory stats error: %v", err)
+	}
+	if _, err := GetCgroupCPUStatsContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("unexpected CPU stats error: %v", err)
+	}
+	if _, err := GetCgroupMemoryLimitContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("unexpected memory limit error: %v", err)
+	}
+	if _, err := GetCgroupCPULimitContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("unexpected CPU limit error: %v", err)
+	}
+}
+
+func TestCgroupStatsWithCache(t *testing.T) {
+	ctx := context.Background()
+	cgr := cgresolver.NewCache()
+	cpuLimit, err := GetCgroupCPULimitWithCache(ctx, cgr)
+	if errors.Is(err, ErrCGroupsNotSupported) {
+		t.Skip("unsupported platform")
+	}
+	if err != nil {
+		t.Fatalf("failed to query CPU limit: %s", err)
+	}
+	if uncached, err := GetCgroupCPULimit(); err != nil || uncached != cpuLimit {
+		t.Errorf("mismatched CPU limit: %g with a cache; %g (err: %v) without", cpuLimit, uncached, err)
+	}
+
+	memLimit, err := GetCgroupMemoryLimitWithCache(ctx, cgr)
+	if err != nil {
+		t.Fatalf("failed to query memory limit: %s", err)
+	}
+	if uncached, err := GetCgroupMemoryLimit(); err != nil || uncached != memLimit {
+		t.Errorf("mismatched memory limit: %d with a cache; %d (err: %v) without", memLimit, uncached, err)
+	}
+
+	if _, err := GetCgroupCPUStatsWithCache(ctx, cgr); err != nil {
+		t.Errorf("failed to query CPU stats: %s", err)
+	}
+	if _, err := GetCgroupMemoryStatsWithCache(ctx, cgr); err != nil {
+		t.Errorf("failed to query memory stats: %s", err)
+	}
+	// a nil Cache resolves afresh
+	if _, err := GetCgroupMemoryStatsWithCache(ctx, nil); err != nil {
+		t.Errorf("failed to query memory stats with a nil cache: %s", err)
+	}
+}
+
+func TestCgroupGettersWithCache(t *testing.T) {
+	ctx := context.Background()
+	cgr := cgresolver.NewCache()
+	if _, err := GetCgroupMemoryLimitWithCache(ctx, cgr); errors.Is(err, ErrCGroupsNotSupported) {
+		t.Skip("unsupported platform")
+	}
+
+	// Not every getter is supported on every host (e.g. PSI requires
+	// cgroup v2), but the cached variants should succeed or fail along
+	// with the uncached ones, and agree on values that don't change.
+	cachedTh, cachedErr := GetCgroupMemoryThresholdsWithCache(ctx, cgr)
+	th, err := GetCgroupMemoryThresholds()
+	if (cachedErr == nil) != (err == nil) || cachedTh != th {
+		t.Errorf("mismatched memory thresholds: %+v (err: %v) with a cache; %+v (err: %v) without",
+			cachedTh, cachedErr, th, err)
+	}
+	cachedCPUs, cachedErr := CgroupOnlineCPUsWithCache(ctx, cgr)
+	cpus, err := CgroupOnlineCPUs()
+	if (cachedErr == nil) != (err == nil) || cachedCPUs != cpus {
+		t.Errorf("mismatched online CPUs: %d (err: %v) with a cache; %d (err: %v) without",
+			cachedCPUs, cachedErr, cpus, err)
+	}
+	cachedFlags, cachedErr := GetCgroupCPUSetFlagsWithCache(ctx, cgr)
+	flags, err := GetCgroupCPUSetFlags()
+	if (cachedErr == nil) != (err == nil) || cachedFlags != flags {
+		t.Errorf("mismatched cpuset flags: %+v (err: %v) with a cache; %+v (err: %v) without",
+			cachedFlags, cachedErr, flags, err)
+	}
+	for _, g := range []struct {
+		name     string
+		cached   func() error
+		uncached func() error
+	}{
+		{
+			name:     "swap",
+			cached:   func() error { _, err := GetCgroupSwapStatsWithCache(ctx, cgr); return err },
+			uncached: func() error { _, err := GetCgroupSwapStats(); return err },
+		},
+		{
+			name:     "zswap",
+			cached:   func() error { _, err := GetCgroupZswapStatsWithCache(ctx, cgr); return err },
+			uncached: func() error { _, err := GetCgroupZswapStats(); return err },
+		},
+		{
+			name:     "psi",
+			cached:   func() error { _, err := GetCgroupPSIWithCache(ctx, cgr); return err },
+			uncached: func() error { _, err := GetCgroupPSI(); return err },
+		},
+		{
+			name:     "io",
+			cached:   func() error { _, err := GetCgroupIOStatsWithCache(ctx, cgr); return err },
+			uncached: func() error { _, err := GetCgroupIOStats(); return err },
+		},
+		{
+			name:     "cpuset",
+			cached:   func() error { _, err := GetCgroupCPUSetWithCache(ctx, cgr); return err },
+			uncached: func() error { _, err := GetCgroupCPUSet(); return err },
+		},
+	} {
+		if cachedErr, err := g.cached(), g.uncached(); (cachedErr == nil) != (err == nil) {
+			t.Errorf("mismatched %s errors: %v with a cache; %v without", g.name, cachedErr, err)
+		}
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := GetCgroupSwapStatsWithCache(cancelled, cgr); !errors.Is(err, context.Canceled) {
+		t.Errorf("unexpected error with a cancelled context: %v", err)
+	}
+}
+
+func TestCgroupStatsForPIDSelf(t *testing.T) {
+	pid := os.Getpid()
+	pidLimit, err := GetCgroupMemoryLimitForPID(pid)
+	if errors.Is(err, ErrCGroupsNotSupported) {
+		t.Skip("unsupported platform")
+	}
+	if err != nil {
+		t.Fatalf("failed to query memory limit by pid: %s", err)
+	}
+	selfLimit, err := GetCgroupMemoryLimit()
+	if err != nil {
+		t.Fatalf("failed to query memory limit: %s", err)
+	}
+	if pidLimit != selfLimit {
+		t.Errorf("memory limit by pid %d differs from self %d", pidLimit, selfLimit)
+	}
+
+	pidMS, err := GetCgroupMemoryStatsForPID(pid)
+	if err != nil {
+		t.Fatalf("failed to query memory stats by pid: %s", err)
+	}
+	selfMS, err := GetCgroupMemoryStats()
+	if err != nil {
+		t.Fatalf("failed to query memory stats: %s", err)
+	}
+	// usage fluctuates between reads, but the limit is fixed
+	if pidMS.Total != selfMS.Total {
+		t.Errorf("memory total by pid %d differs from self %d", pidMS.Total, selfMS.Total)
+	}
+
+	pidCS, err := GetCgroupCPUStatsForPID(pid)
+	if err != nil {
+		t.Fatalf("failed to query CPU stats by pid: %s", err)
+	}
+	selfCS, err := GetCgroupCPUStats()
+	if err != nil {
+		t.Fatalf("failed to query CPU stats: %s", err)
+	}
+	// CPU usage is cumulative, so the later read can't be smaller
+	if selfCS.Usage.Utime < pidCS.Usage.Utime || selfCS.Usage.Stime < pidCS.Usage.Stime {
+		t.Errorf("CPU usage went backwards: by pid %+v; self %+v", pidCS.Usage, selfCS.Usage)
+	}
+}
+
 func TestCgroupCPUStatsRead(t *testing.T) {
 	stats, err := GetCgroupCPUStats()
 	if err == ErrCGroupsNotSupported {
@@ -85,3 +264,344 @@ func TestCgroupCPUStatsRead(t *testing.T) {
 		t.Errorf("unexpectedly negative throttled time: %s", stats.ThrottledTime)
 	}
 }
+
+func TestCgroupPageTableMemoryRead(t *testing.T) {
+	pt, secPT, err := GetCgroupPageTableMemory()
+	if errors.Is(err, ErrCGroupsNotSupported) {
+		t.Skip("unsupported platform")
+	}
+
+	if err != nil {
+		t.Fatalf("failed to query page-table memory: %s", err)
+	}
+	if pt < 0 {
+		t.Errorf("unexpectedly negative page-table memory: %d", pt)
+	}
+	if secPT < 0 {
+		t.Errorf("unexpectedly negative secondary page-table memory: %d", secPT)
+	}
+}
+
+func TestCgroupZswapStatsRead(t *testing.T) {
+	zs, err := GetCgroupZswapStats()
+	if errors.Is(err, ErrCGroupsNotSupported) {
+		t.Skip("unsupported platform")
+	}
+
+	if err != nil {
+		t.Fatalf("failed to query zswap stats: %s", err)
+	}
+	if zs.PoolBytes < 0 || zs.SwappedBytes < 0 {
+		t.Errorf("unexpectedly negative zswap usage: %+v", zs)
+	}
+}
+
+func TestCgroupPSIRead(t *testing.T) {
+	psi, err := GetCgroupPSI()
+	if errors.Is(err, ErrCGroupsNotSupported) {
+		t.Skip("unsupported platform")
+	}
+	if errors.Is(err, ErrPSIUnavailable) {
+		t.Skip("PSI unavailable")
+	}
+
+	if err != nil {
+		t.Fatalf("failed to query PSI: %s", err)
+	}
+	if psi.Memory.Some.Total < psi.Memory.Full.Total {
+		t.Errorf("memory \"full\" stall time exceeds \"some\": %+v", psi.Memory)
+	}
+}
+
+func TestCgroupPressureRead(t *testing.T) {
+	for name, get := range map[string]func() (PSI, error){
+		"cpu":    GetCgroupCPUPressure,
+		"memory": GetCgroupMemoryPressure,
+		"io":     GetCgroupIOPressure,
+	} {
+		t.Run(name, func(t *testing.T) {
+			psi, err := get()
+			if errors.Is(err, ErrCGroupsNotSupported) {
+				t.Skip("unsupported platform")
+			}
+			if errors.Is(err, ErrPSIUnavailable) {
+				t.Skip("PSI unavailable")
+			}
+			if err != nil {
+				t.Fatalf("failed to query PSI: %s", err)
+			}
+			if psi.Some.Total < psi.Full.Total {
+				t.Errorf("\"full\" stall time exceeds \"some\": %+v", psi)
+			}
+		})
+	}
+}
+
+func TestCgroupMemoryThresholdsRead(t *testing.T) {
+	th, err := GetCgroupMemoryThresholds()
+	if errors.Is(err, ErrCGroupsNotSupported) {
+		t.Skip("unsupported platform")
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		t.Skip("running in the root memory cgroup")
+	}
+	if err != nil {
+		t.Fatalf("failed to query memory thresholds: %s", err)
+	}
+	if th.Min > th.Max || th.Low > th.Max {
+		t.Errorf("memory protection exceeds the limit: %+v", th)
+	}
+}
+
+func TestCgroupPIDStatsRead(t *testing.T) {
+	stats, err := GetCgroupPIDStats()
+	if errors.Is(err, ErrCGroupsNotSupported) {
+		t.Skip("unsupported platform")
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		t.Skip("no pids limit applies to this process")
+	}
+
+	if err != nil {
+		t.Fatalf("failed to query pids stats: %s", err)
+	}
+	// this test runs multiple goroutines, so at least one task is present
+	if stats.Current < 1 {
+		t.Errorf("unexpectedly small task count: %d", stats.Current)
+	}
+	if stats.Limit < 1 {
+		t.Errorf("unexpectedly small task limit: %d", stats.Limit)
+	}
+}
+
+func TestResourceLimitsRead(t *testing.T) {
+	limits, err := GetResourceLimits()
+	if errors.Is(err, ErrCGroupsNotSupported) {
+		t.Skip("unsupported platform")
+	}
+
+	if err != nil {
+		t.Fatalf("failed to query resource limits: %s", err)
+	}
+	if limits.Mode != cgresolver.CGModeV1 && limits.Mode != cgresolver.CGModeV2 {
+		t.Errorf("unexpected cgroup mode: %d", limits.Mode)
+	}
+	if limits.CPU.Usage.Utime < time.Microsecond {
+		t.Errorf("unexpectedly small user usage: %s", limits.CPU.Usage.Utime)
+	}
+	if limits.Memory.Total <= 0 {
+		t.Errorf("unexpectedly non-positive memory limit: %d", limits.Memory.Total)
+	}
+	if limits.Memory.Free > limits.Memory.Total {
+		t.Errorf("free memory %d exceeds limit %d", limits.Memory.Free, limits.Memory.Total)
+	}
+	if limits.PIDs == (PIDStats{}) {
+		// the pids controller is unavailable
+		return
+	}
+	// this test runs multiple goroutines, so at least one task is present
+	if limits.PIDs.Current < 1 {
+		t.Errorf("unexpectedly small task count: %d", limits.PIDs.Current)
+	}
+	if limits.PIDs.Limit < limits.PIDs.Current {
+		t.Errorf("task limit %d below task count %d", limits.PIDs.Limit, limits.PIDs.Current)
+	}
+}
+
+func TestCgroupSwapStatsRead(t *testing.T) {
+	stats, err := GetCgroupSwapStats()
+	if errors.Is(err, ErrCGroupsNotSupported) {
+		t.Skip("unsupported platform")
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		t.Skip("swap accounting disabled")
+	}
+
+	if err != nil {
+		t.Fatalf("failed to query swap stats: %s", err)
+	}
+	if stats.SwapUsage < 0 {
+		t.Errorf("negative swap usage: %d", stats.SwapUsage)
+	}
+	if stats.SwapLimit < 0 {
+		t.Errorf("negative swap limit: %d", stats.SwapLimit)
+	}
+}
+
+func TestCgroupIOStatsRead(t *testing.T) {
+	stats, err := GetCgroupIOStats()
+	if errors.Is(err, ErrCGroupsNotSupported) {
+		t.Skip("unsupported platform")
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		t.Skip("no io/blkio stats available")
+	}
+
+	if err != nil {
+		t.Fatalf("failed to query IO stats: %s", err)
+	}
+	for dev, s := range stats {
+		if s.ReadBytes < 0 || s.WriteBytes < 0 || s.ReadIOs < 0 || s.WriteIOs < 0 {
+			t.Errorf("unexpectedly negative counters for device %s: %+v", dev, s)
+		}
+	}
+}
+
+func TestCgroupOnlineCPUsRead(t *testing.T) {
+	cpus, err := CgroupOnlineCPUs()
+	if err == ErrCGroupsNotSupported {
+		t.Skip("unsupported platform")
+	}
+
+	if err != nil {
+		t.Fatalf("failed to query online CPUs: %s", err)
+	}
+	if cpus < 1 {
+		t.Errorf("unexpectedly small online CPU count: %d", cpus)
+	}
+}
+
+func TestEffectiveCPULimit(t *testing.T) {
+	for _, tbl := range []struct {
+		name         string
+		affinityCPUs int
+		cgroupLimit  float64
+		cgroupErr    error
+		exp          float64
+	}{
+		{name: "restricted_affinity_no_cgroup", affinityCPUs: 2, cgroupErr: ErrCGroupsNotSupported, exp: 2},
+		{name: "restricted_affinity_unlimited_cgroup", affinityCPUs: 2, cgroupLimit: 0, exp: 2},
+		{name: "restricted_affinity_looser_quota", affinityCPUs: 2, cgroupLimit: 3.5, exp: 2},
+		{name: "tighter_quota", affinityCPUs: 8, cgroupLimit: 1.5, exp: 1.5},
+	} {
+		t.Run(tbl.name, func(t *testing.T) {
+			if lim := effectiveCPULimit(tbl.affinityCPUs, tbl.cgroupLimit, tbl.cgroupErr); lim != tbl.exp {
+				t.Errorf("unexpected CPU limit: %g; expected %g", lim, tbl.exp)
+			}
+		})
+	}
+}
+
+func TestCPUSetCPUs(t *testing.T) {
+	for _, tbl := range []struct {
+		name         string
+		affinityCPUs int
+		cpuset       CPUSet
+		cpusetErr    error
+		exp          int
+	}{
+		{name: "no_cpuset", affinityCPUs: 8, cpusetErr: ErrCGroupsNotSupported, exp: 8},
+		{name: "empty_cpuset", affinityCPUs: 8, cpuset: CPUSet{CPUs: []int{}}, exp: 8},
+		{name: "tighter_cpuset", affinityCPUs: 64, cpuset: CPUSet{CPUs: []int{4, 5}}, exp: 2},
+		{name: "tighter_affinity", affinityCPUs: 1, cpuset: CPUSet{CPUs: []int{0, 1, 2, 3}}, exp: 1},
+	} {
+		t.Run(tbl.name, func(t *testing.T) {
+			if n := cpusetCPUs(tbl.affinityCPUs, tbl.cpuset, tbl.cpusetErr); n != tbl.exp {
+				t.Errorf("unexpected CPU count: %d; expected %d", n, tbl.exp)
+			}
+		})
+	}
+}
+
+func TestCgroupCPUSetRead(t *testing.T) {
+	cpuset, err := GetCgroupCPUSet()
+	if errors.Is(err, ErrCGroupsNotSupported) {
+		t.Skip("no cpuset available")
+	}
+	if err != nil {
+		t.Fatalf("failed to query cpuset: %s", err)
+	}
+	if !slices.IsSorted(cpuset.CPUs) {
+		t.Errorf("unsorted cpuset CPUs: %v", cpuset.CPUs)
+	}
+	if !slices.IsSorted(cpuset.Mems) {
+		t.Errorf("unsorted cpuset memory nodes: %v", cpuset.Mems)
+	}
+}
+
+func TestAffinityCPUs(t *testing.T) {
+	n := affinityCPUs()
+	if n < 1 || n > runtime.NumCPU() {
+		t.Errorf("affinity CPU count %d out of range [1, %d]", n, runtime.NumCPU())
+	}
+}
+
+func TestAverageCPUUsage(t *testing.T) {
+	usage, err := AverageCPUUsage()
+	if errors.Is(err, procstats.ErrUnimplementedPlatform) {
+		t.Skip("unsupported platform")
+	}
+
+	if err != nil {
+		t.Fatalf("failed to query average CPU usage: %s", err)
+	}
+	if usage < 0 {
+		t.Errorf("unexpectedly negative average CPU usage: %g", usage)
+	}
+}
+
+func TestHostMemStatsRead(t *testing.T) {
+	ms, err := HostMemStats()
+	if errors.Is(err, ErrUnimplementedPlatform) {
+		t.Skip("unsupported platform")
+	}
+
+	if err != nil {
+		t.Fatalf("failed to query host memory stats: %s", err)
+	}
+	if ms.Total <= 0 {
+		t.Errorf("unexpectedly small total memory: %d", ms.Total)
+	}
+	if ms.Free < 0 || ms.Free > ms.Total {
+		t.Errorf("free memory %d out of range [0, %d]", ms.Free, ms.Total)
+	}
+	if ms.Available < 0 || ms.Available > ms.Total {
+		t.Errorf("available memory %d out of range [0, %d]", ms.Available, ms.Total)
+	}
+}
+
+func TestIsMemoryLimited(t *testing.T) {
+	limited, limit, err := IsMemoryLimited()
+	if errors.Is(err, ErrCGroupsNotSupported) {
+		t.Skip("unsupported platform")
+	}
+
+	if err != nil {
+		t.Fatalf("failed to query memory limit: %s", err)
+	}
+	if limited && limit <= 0 {
+		t.Errorf("unexpected limit %d for limited cgroup", limit)
+	}
+	if !limited && limit != -1 {
+		t.Errorf("unexpected limit %d for unlimited cgroup; expected -1", limit)
+	}
+}
+
+func TestIsCPULimited(t *testing.T) {
+	limited, limit, err := IsCPULimited()
+	if errors.Is(err, ErrCGroupsNotSupported) {
+		t.Skip("unsupported platform")
+	}
+
+	if err != nil {
+		t.Fatalf("failed to query CPU limit: %s", err)
+	}
+	if limited && (limit <= 0 || limit >= float64(runtime.NumCPU())) {
+		t.Errorf("unexpected limit %g for limited cgroup", limit)
+	}
+	if !limited && limit != -1 {
+		t.Errorf("unexpected limit %g for unlimited cgroup; expected -1", limit)
+	}
+}
+
+func TestCgroupCPUSetFlagsRead(t *testing.T) {
+	flags, err := GetCgroupCPUSetFlags()
+	if errors.Is(err, ErrCGroupsNotSupported) {
+		t.Skip("unsupported platform")
+	}
+
+	if err != nil {
+		t.Fatalf("failed to query cpuset flags: %s", err)
+	}
+	t.Logf("cpuset flags: %+v", flags)
+}
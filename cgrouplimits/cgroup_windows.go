@@ -0,0 +1,208 @@
+//go:build windows
+// +build windows
+
+package cgrouplimits
+
+import (
+	"context"
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+// On Windows, containers' resource limits are applied with job objects
+// rather than cgroups, so the cgroup CPU and memory limit getters report the
+// limits of the job object the current process is in (if any).
+
+var (
+	modkernel32 = syscall.NewLazyDLL("kernel32.dll")
+
+	procIsProcessInJob            = modkernel32.NewProc("IsProcessInJob")
+	procQueryInformationJobObject = modkernel32.NewProc("QueryInformationJobObject")
+	procGetActiveProcessorCount   = modkernel32.NewProc("GetActiveProcessorCount")
+)
+
+// JOBOBJECTINFOCLASS values
+const (
+	jobObjectInfoClassExtendedLimit  = 9
+	jobObjectInfoClassCPURateControl = 15
+)
+
+// JOBOBJECT_BASIC_LIMIT_INFORMATION.LimitFlags
+const (
+	jobObjectLimitProcessMemory = 0x100
+	jobObjectLimitJobMemory     = 0x200
+)
+
+// JOBOBJECT_CPU_RATE_CONTROL_INFORMATION.ControlFlags
+const (
+	jobObjectCPURateControlEnable     = 0x1
+	jobObjectCPURateControlHardCap    = 0x4
+	jobObjectCPURateControlMinMaxRate = 0x10
+)
+
+// allProcessorGroups is ALL_PROCESSOR_GROUPS
+const allProcessorGroups = 0xffff
+
+// jobObjectCPURateScale is the value of CpuRate (or MaxRate) corresponding
+// to 100% of the system's processors. (rates are in hundredths of a percent)
+const jobObjectCPURateScale = 10000
+
+// jobObjectBasicLimitInformation mirrors JOBOBJECT_BASIC_LIMIT_INFORMATION
+type jobObjectBasicLimitInformation struct {
+	PerProcessUserTimeLimit int64
+	PerJobUserTimeLimit     int64
+	LimitFlags              uint32
+	MinimumWorkingSetSize   uintptr
+	MaximumWorkingSetSize   uintptr
+	ActiveProcessLimit      uint32
+	Affinity                uintptr
+	PriorityClass           uint32
+	SchedulingClass         uint32
+}
+
+// jobObjectExtendedLimitInformation mirrors
+// JOBOBJECT_EXTENDED_LIMIT_INFORMATION
+type jobObjectExtendedLimitInformation struct {
+	BasicLimitInformation jobObjectBasicLimitInformation
+	// JOBOBJECT_BASIC_LIMIT_INFORMATION is 8-byte aligned (due to its
+	// LARGE_INTEGER fields), which Go doesn't do for int64s on 32-bit
+	// platforms, so pad it out there.
+	_                     [8 - unsafe.Sizeof(uintptr(0))]byte
+	IoInfo                [6]uint64 // IO_COUNTERS
+	ProcessMemoryLimit    uintptr
+	JobMemoryLimit        uintptr
+	PeakProcessMemoryUsed uintptr
+	PeakJobMemoryUsed     uintptr
+}
+
+// jobObjectCPURateControlInformation mirrors
+// JOBOBJECT_CPU_RATE_CONTROL_INFORMATION. Rate is a union of CpuRate, Weight
+// and the MinRate/MaxRate pair (in its low and high words, respectively).
+type jobObjectCPURateControlInformation struct {
+	ControlFlags uint32
+	Rate         uint32
+}
+
+// inJob reports whether the current process is in a job object.
+func inJob() (bool, error) {
+	var in int32
+	r, _, err := procIsProcessInJob.Call(uintptr(currentProcess()), 0, uintptr(unsafe.Pointer(&in)))
+	if r == 0 {
+		return false, fmt.Errorf("IsProcessInJob failed: %w", err)
+	}
+	return in != 0, nil
+}
+
+func currentProcess() syscall.Handle {
+	h, _ := syscall.GetCurrentProcess()
+	return h
+}
+
+// queryJobObject fills out with the infoClass information for the current
+// process's job object.
+func queryJobObject(infoClass uint32, out unsafe.Pointer, size uintptr) error {
+	// a NULL job handle refers to the calling process's job
+	r, _, err := procQueryInformationJobObject.Call(0, uintptr(infoClass), uintptr(out), size, 0)
+	if r == 0 {
+		return fmt.Errorf("QueryInformationJobObject failed: %w", err)
+	}
+	return nil
+}
+
+// GetCgroupCPULimit returns the CPU rate limit (in CPUs) of the job object
+// the current process is in, or 0 if the job has no hard CPU rate limit.
+// If the process isn't in a job object, it returns ErrCGroupsNotSupported.
+func GetCgroupCPULimit() (float64, error) {
+	return GetCgroupCPULimitContext(context.Background())
+}
+
+// GetCgroupCPULimitContext is GetCgroupCPULimit, but returns ctx.Err() if ctx
+// is already done.
+func GetCgroupCPULimitContext(ctx context.Context) (float64, error) {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return -1.0, ctxErr
+	}
+	if in, inErr := inJob(); inErr != nil || !in {
+		return -1.0, notInJobErr(inErr)
+	}
+	info := jobObjectCPURateControlInformation{}
+	if err := queryJobObject(jobObjectInfoClassCPURateControl,
+		unsafe.Pointer(&info), unsafe.Sizeof(info)); err != nil {
+		return -1.0, err
+	}
+	r, _, _ := procGetActiveProcessorCount.Call(allProcessorGroups)
+	if r == 0 {
+		return -1.0, fmt.Errorf("GetActiveProcessorCount failed")
+	}
+	return jobCPURateLimit(info, int(r)), nil
+}
+
+// jobCPURateLimit converts a job's CPU rate control settings to a limit in
+// CPUs, given the number of processors in the system. Weight-based and
+// soft-capped rates don't limit anything, so they result in 0.
+func jobCPURateLimit(info jobObjectCPURateControlInformation, numCPU int) float64 {
+	if info.ControlFlags&jobObjectCPURateControlEnable == 0 {
+		return 0.0
+	}
+	rate := uint32(0)
+	switch {
+	case info.ControlFlags&jobObjectCPURateControlMinMaxRate != 0:
+		rate = info.Rate >> 16 // MaxRate
+	case info.ControlFlags&jobObjectCPURateControlHardCap != 0:
+		rate = info.Rate // CpuRate
+	}
+	if rate == 0 || rate >= jobObjectCPURateScale {
+		return 0.0
+	}
+	return float64(rate) / jobObjectCPURateScale * float64(numCPU)
+}
+
+// GetCgroupMemoryLimit returns the most restrictive of the per-process and
+// per-job memory limits of the job object the current process is in, or -1
+// if the job has neither.
+// If the process isn't in a job object, it returns ErrCGroupsNotSupported.
+func GetCgroupMemoryLimit() (int64, error) {
+	return GetCgroupMemoryLimitContext(context.Background())
+}
+
+// GetCgroupMemoryLimitContext is GetCgroupMemoryLimit, but returns ctx.Err()
+// if ctx is already done.
+func GetCgroupMemoryLimitContext(ctx context.Context) (int64, error) {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return -1, ctxErr
+	}
+	if in, inErr := inJob(); inErr != nil || !in {
+		return -1, notInJobErr(inErr)
+	}
+	info := jobObjectExtendedLimitInformation{}
+	if err := queryJobObject(jobObjectInfoClassExtendedLimit,
+		unsafe.Pointer(&info), unsafe.Sizeof(info)); err != nil {
+		return -1, err
+	}
+	return jobMemoryLimit(info), nil
+}
+
+// jobMemoryLimit returns the tighter of a job's per-process and per-job
+// memory limits (whichever are enabled), or -1 if neither is.
+func jobMemoryLimit(info jobObjectExtendedLimitInformation) int64 {
+	limit := int64(-1)
+	flags := info.BasicLimitInformation.LimitFlags
+	if flags&jobObjectLimitProcessMemory != 0 && info.ProcessMemoryLimit > 0 {
+		limit = int64(info.ProcessMemoryLimit)
+	}
+	if flags&jobObjectLimitJobMemory != 0 && info.JobMemoryLimit > 0 &&
+		(limit == -1 || int64(info.JobMemoryLimit) < limit) {
+		limit = int64(info.JobMemoryLimit)
+	}
+	return limit
+}
+
+// notInJobErr returns the error to report when the current process isn't
+// in a job object (or that couldn't be determined).
+func notInJobErr(inJobErr error) error {
+	if inJobErr != nil {
+		return fmt.Errorf("%w: %w", ErrCGroupsNotSupported, inJobErr)
+	}
+	return ErrCGroupsNotSupported
+}
@@ -0,0 +1,95 @@
+package cgrouplimits
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestJobCPURateLimit(t *testing.T) {
+	for _, tbl := range []struct {
+		name     string
+		info     jobObjectCPURateControlInformation
+		expLimit float64
+	}{
+		{
+			name:     "disabled",
+			info:     jobObjectCPURateControlInformation{ControlFlags: jobObjectCPURateControlHardCap, Rate: 2500},
+			expLimit: 0,
+		},
+		{
+			name: "hard_cap",
+			info: jobObjectCPURateControlInformation{
+				ControlFlags: jobObjectCPURateControlEnable | jobObjectCPURateControlHardCap,
+				Rate:         2500,
+			},
+			expLimit: 2,
+		},
+		{
+			name:     "soft_cap",
+			info:     jobObjectCPURateControlInformation{ControlFlags: jobObjectCPURateControlEnable, Rate: 2500},
+			expLimit: 0,
+		},
+		{
+			name: "min_max",
+			info: jobObjectCPURateControlInformation{
+				ControlFlags: jobObjectCPURateControlEnable | jobObjectCPURateControlMinMaxRate,
+				Rate:         5000<<16 | 1000,
+			},
+			expLimit: 4,
+		},
+		{
+			name: "full_rate",
+			info: jobObjectCPURateControlInformation{
+				ControlFlags: jobObjectCPURateControlEnable | jobObjectCPURateControlHardCap,
+				Rate:         jobObjectCPURateScale,
+			},
+			expLimit: 0,
+		},
+	} {
+		t.Run(tbl.name, func(t *testing.T) {
+			if limit := jobCPURateLimit(tbl.info, 8); limit != tbl.expLimit {
+				t.Errorf("unexpected limit %g; expected %g", limit, tbl.expLimit)
+			}
+		})
+	}
+}
+
+func TestJobMemoryLimit(t *testing.T) {
+	info := jobObjectExtendedLimitInformation{
+		ProcessMemoryLimit: 1 << 30,
+		JobMemoryLimit:     1 << 29,
+	}
+	if limit := jobMemoryLimit(info); limit != -1 {
+		t.Errorf("unexpected limit with no limit flags: %d; expected -1", limit)
+	}
+	info.BasicLimitInformation.LimitFlags = jobObjectLimitProcessMemory
+	if limit := jobMemoryLimit(info); limit != 1<<30 {
+		t.Errorf("unexpected per-process limit: %d; expected %d", limit, 1<<30)
+	}
+	info.BasicLimitInformation.LimitFlags |= jobObjectLimitJobMemory
+	if limit := jobMemoryLimit(info); limit != 1<<29 {
+		t.Errorf("unexpected combined limit: %d; expected %d", limit, 1<<29)
+	}
+}
+
+func TestJobLimitsRead(t *testing.T) {
+	cpuLimit, err := GetCgroupCPULimit()
+	if errors.Is(err, ErrCGroupsNotSupported) {
+		// not running in a job object: no limit applies
+		t.Skip("not in a job object")
+	}
+	if err != nil {
+		t.Fatalf("failed to query CPU limit: %s", err)
+	}
+	if cpuLimit < 0 {
+		t.Errorf("unexpectedly negative CPU limit: %g", cpuLimit)
+	}
+
+	memLimit, err := GetCgroupMemoryLimit()
+	if err != nil {
+		t.Fatalf("failed to query memory limit: %s", err)
+	}
+	if memLimit != -1 && memLimit < 4096 {
+		t.Errorf("unexpectedly small memory limit (less than a page): %d", memLimit)
+	}
+}
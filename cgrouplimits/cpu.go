@@ -4,6 +4,10 @@
 package cgrouplimits
 
 import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
 	"runtime"
 	"time"
 
@@ -11,15 +15,42 @@ import (
 )
 
 // CPU gets any limit from the current cgroup (if on a supported system),
-// and then chooses the limiting limit from runtime.NumCPU() and the
+// and then chooses the limiting limit from the number of CPUs the process's
+// affinity mask allows it to run on, the size of its cpuset and the
 // cgroup-limit.
 func CPU() float64 {
-	runtimeLimit := float64(runtime.NumCPU())
 	cgroupLimit, cgroupErr := GetCgroupCPULimit()
+	cpuset, cpusetErr := GetCgroupCPUSet()
+	return effectiveCPULimit(cpusetCPUs(affinityCPUs(), cpuset, cpusetErr), cgroupLimit, cgroupErr)
+}
+
+// cpusetCPUs returns the smaller of the affinity-derived CPU count and the
+// size of the cpuset. (an empty or unreadable cpuset doesn't constrain
+// anything)
+func cpusetCPUs(affinityCPUs int, cpuset CPUSet, cpusetErr error) int {
+	if cpusetErr != nil || cpuset.Count() == 0 {
+		return affinityCPUs
+	}
+	return min(affinityCPUs, cpuset.Count())
+}
+
+// affinityCPUs returns the number of CPUs the current process may run on,
+// falling back to runtime.NumCPU() if the affinity mask can't be read.
+func affinityCPUs() int {
+	n, err := affinityCPUCount()
+	if err != nil || n <= 0 {
+		return runtime.NumCPU()
+	}
+	return n
+}
+
+// effectiveCPULimit picks the tighter of the affinity-derived CPU count and
+// the cgroup CPU limit.
+func effectiveCPULimit(affinityCPUs int, cgroupLimit float64, cgroupErr error) float64 {
+	runtimeLimit := float64(affinityCPUs)
 	if cgroupErr != nil {
-		// if we got an error, fall back to using the runtime-derived
-		// limit. (under linux this uses CPU affinity so it takes into
-		// account how many cores we can actually run on)
+		// if we got an error, fall back to using the affinity-derived
+		// limit.
 		return runtimeLimit
 	}
 	if cgroupLimit <= 0 || runtimeLimit < cgroupLimit {
@@ -28,25 +59,116 @@ func CPU() float64 {
 	return cgroupLimit
 }
 
+// IsCPULimited reports whether the current process is subject to a cgroup
+// CPU quota that's actually constraining, i.e. one that's smaller than
+// runtime.NumCPU(). If so, the quota (in CPUs) is returned as well; otherwise
+// the limit is -1.
+// On systems without cgroups, this returns ErrCGroupsNotSupported.
+func IsCPULimited() (bool, float64, error) {
+	cgLimit, cgErr := GetCgroupCPULimit()
+	if cgErr != nil {
+		return false, -1, cgErr
+	}
+	if cgLimit <= 0 || math.IsInf(cgLimit, +1) || cgLimit >= float64(runtime.NumCPU()) {
+		return false, -1, nil
+	}
+	return true, cgLimit, nil
+}
+
 // CPUStats encapuslates the CPU Limit, throttling, etc.
 type CPUStats struct {
 	Limit         float64
 	Usage         procstats.CPUTime
 	ThrottledTime time.Duration
+	// TotalPeriods is the number of CFS enforcement periods that have
+	// elapsed with runnable tasks in the cgroup
+	TotalPeriods int64
+	// ThrottledPeriods is the number of enforcement periods in which the
+	// cgroup was throttled (ThrottledPeriods/TotalPeriods is the
+	// throttle ratio)
+	ThrottledPeriods int64
+}
+
+// CPUSet describes the CPUs a cpuset cgroup allows its members to run on,
+// and the (NUMA) memory nodes it allows them to allocate from.
+type CPUSet struct {
+	// CPUs lists the IDs of the CPUs in the cpuset, in ascending order
+	CPUs []int
+	// Mems lists the IDs of the memory nodes in the cpuset, in ascending
+	// order
+	Mems []int
+}
+
+// Count returns the number of CPUs in the cpuset.
+func (c *CPUSet) Count() int {
+	return len(c.CPUs)
+}
+
+// CPUSetFlags encapsulates the scheduling and memory-placement flags of a
+// cpuset cgroup.
+type CPUSetFlags struct {
+	// SchedLoadBalance indicates whether the scheduler load-balances
+	// tasks across the cpuset's CPUs. (under cgroup v2 this is false only
+	// for isolated partitions)
+	SchedLoadBalance bool
+	// MemoryMigrate indicates whether pages are migrated to the new
+	// memory nodes when the cpuset's memory nodes change (always true
+	// under cgroup v2)
+	MemoryMigrate bool
+	// CPUExclusive indicates whether the cpuset has exclusive use of its
+	// CPUs (under cgroup v2, whether it's a valid partition root)
+	CPUExclusive bool
+	// MemExclusive indicates whether the cpuset has exclusive use of its
+	// memory nodes (always false under cgroup v2)
+	MemExclusive bool
+	// Partition is the contents of cpuset.cpus.partition under cgroup v2
+	// (e.g. "member", "root" or "isolated"); empty under cgroup v1
+	Partition string
 }
 
 // CPUStat queries the current system-state for CPU usage and limits.
 // Limit is always filled in, other fields are only present if there's a
 // non-nil error.
-// Currently only works within cgroups with cpu-limits (CS-34)
+// On systems without cgroups, Usage is filled in from the host-level CPU
+// usage (see HostCPUStats), and ThrottledTime is left zero.
 func CPUStat() (CPUStats, error) {
 	cgcpustats, err := GetCgroupCPUStats()
-	// TODO(CS-34): implement a host-level fallback for the non-l-limit
-	// fields that are a useful approximation of the cgroup
-	// usage/throttle-time me fields.
+	if errors.Is(err, ErrCGroupsNotSupported) {
+		hostcpustats, hostErr := HostCPUStats()
+		if hostErr != nil {
+			return CPUStats{Limit: CPU()}, errors.Join(err, hostErr)
+		}
+		hostcpustats.Limit = CPU()
+		return hostcpustats, nil
+	}
 	if err != nil {
 		return CPUStats{Limit: CPU()}, err
 	}
 	cgcpustats.Limit = CPU()
 	return cgcpustats, nil
 }
+
+// AverageCPUUsage returns the current process's average CPU utilization
+// over its lifetime, as a fraction of the effective CPU limit (see CPU()).
+// i.e. the total CPU time consumed by the process (including any children
+// that have been wait(2)ed on) divided by the product of its uptime and the
+// CPU limit.
+// Since this averages over the entire lifetime of the process, it smooths
+// over any bursts, so it's useful for characterizing how CPU-heavy a
+// service is in general, but not for detecting transient spikes.
+func AverageCPUUsage() (float64, error) {
+	pid := os.Getpid()
+	ct, ctErr := procstats.ProcessCPUTime(pid)
+	if ctErr != nil {
+		return -1, fmt.Errorf("failed to read process CPU time: %w", ctErr)
+	}
+	start, startErr := procstats.ProcessStartTime(pid)
+	if startErr != nil {
+		return -1, fmt.Errorf("unable to determine process uptime: %w", startErr)
+	}
+	uptime := time.Since(start)
+	if uptime <= 0 {
+		return -1, fmt.Errorf("unable to determine process uptime: start time %s is not in the past", start)
+	}
+	return ct.Utilization(&procstats.CPUTime{}, uptime) / CPU(), nil
+}
@@ -0,0 +1,337 @@
+//go:build linux
+// +build linux
+
+package cgrouplimits
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/vimeo/procstats/cgresolver"
+	"github.com/vimeo/procstats/pparser"
+)
+
+const (
+	// cgroups V1 files
+	cgroupV1CPUSetEffectiveCPUsFile = "cpuset.effective_cpus"
+	cgroupV1CPUSetCPUsFile          = "cpuset.cpus"
+	cgroupV1CPUSetEffectiveMemsFile = "cpuset.effective_mems"
+	cgroupV1CPUSetMemsFile          = "cpuset.mems"
+	cgroupV1CPUSetLoadBalanceFile   = "cpuset.sched_load_balance"
+	cgroupV1CPUSetMemMigrateFile    = "cpuset.memory_migrate"
+	cgroupV1CPUSetCPUExclusiveFile  = "cpuset.cpu_exclusive"
+	cgroupV1CPUSetMemExclusiveFile  = "cpuset.mem_exclusive"
+
+	// cgroups V2 files
+	cgroupV2CPUSetEffectiveCPUsFile = "cpuset.cpus.effective"
+	cgroupV2CPUSetEffectiveMemsFile = "cpuset.mems.effective"
+	cgroupV2CPUSetPartitionFile     = "cpuset.cpus.partition"
+
+	hostOnlineCPUsPath = "/sys/devices/system/cpu/online"
+)
+
+func readRangeListFile(f fs.FS, path string) ([]int, error) {
+	conts, readErr := fs.ReadFile(f, path)
+	if readErr != nil {
+		return nil, fmt.Errorf("failed to read %q: %w", path, readErr)
+	}
+	l, parseErr := pparser.ParseRangeList(string(conts))
+	if parseErr != nil {
+		return nil, fmt.Errorf("failed to parse %q: %w", path, parseErr)
+	}
+	return l, nil
+}
+
+// cgroupEffectiveCPUs reads the list of CPUs the cpuset cgroup rooted at f
+// may run on. The returned error wraps fs.ErrNotExist if the cgroup doesn't
+// have a cpuset applied.
+func cgroupEffectiveCPUs(f fs.FS, mode cgresolver.CGMode) ([]int, error) {
+	switch mode {
+	case cgresolver.CGModeV1:
+		cpus, readErr := readRangeListFile(f, cgroupV1CPUSetEffectiveCPUsFile)
+		if errors.Is(readErr, fs.ErrNotExist) {
+			// older kernels lack the effective_cpus file
+			return readRangeListFile(f, cgroupV1CPUSetCPUsFile)
+		}
+		return cpus, readErr
+	case cgresolver.CGModeV2:
+		// cpuset.cpus.effective only exists if the cpuset controller
+		// is enabled for this cgroup.
+		return readRangeListFile(f, cgroupV2CPUSetEffectiveCPUsFile)
+	default:
+		return nil, fmt.Errorf("unknown cgroup type: %d", mode)
+	}
+}
+
+// cgroupEffectiveMems reads the list of memory nodes the cpuset cgroup
+// rooted at f may allocate from. The returned error wraps fs.ErrNotExist if
+// the cgroup doesn't have a cpuset applied.
+func cgroupEffectiveMems(f fs.FS, mode cgresolver.CGMode) ([]int, error) {
+	switch mode {
+	case cgresolver.CGModeV1:
+		mems, readErr := readRangeListFile(f, cgroupV1CPUSetEffectiveMemsFile)
+		if errors.Is(readErr, fs.ErrNotExist) {
+			// older kernels lack the effective_mems file
+			return readRangeListFile(f, cgroupV1CPUSetMemsFile)
+		}
+		return mems, readErr
+	case cgresolver.CGModeV2:
+		return readRangeListFile(f, cgroupV2CPUSetEffectiveMemsFile)
+	default:
+		return nil, fmt.Errorf("unknown cgroup type: %d", mode)
+	}
+}
+
+// readCGroupCPUSet reads the effective CPUs and memory nodes of the cpuset
+// cgroup rooted at f. The returned error wraps fs.ErrNotExist if the cgroup
+// doesn't have a cpuset applied.
+func readCGroupCPUSet(f fs.FS, mode cgresolver.CGMode) (CPUSet, error) {
+	cpus, cpusErr := cgroupEffectiveCPUs(f, mode)
+	if cpusErr != nil {
+		return CPUSet{}, cpusErr
+	}
+	mems, memsErr := cgroupEffectiveMems(f, mode)
+	if memsErr != nil {
+		return CPUSet{}, memsErr
+	}
+	return CPUSet{CPUs: cpus, Mems: mems}, nil
+}
+
+// nearestCPUSetFS calls read with the cgroup at cpusetPath within root, or if
+// that has no cpuset files, its nearest ancestor which does. (under cgroup
+// v2, the cpuset files only exist in cgroups with the cpuset controller
+// enabled, while their descendants are constrained by the same cpuset)
+// If none of them has a cpuset applied, the leaf cgroup's error (wrapping
+// fs.ErrNotExist) is returned.
+func nearestCPUSetFS(ctx context.Context, root fs.FS, cpusetPath cgresolver.CGroupPath,
+	read func(f fs.FS, mode cgresolver.CGMode) error) error {
+	leafCGReadErr := error(nil)
+	for newDir := true; newDir; cpusetPath, newDir = cpusetPath.Parent() {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		f, subErr := cgroupDirFS(root, &cpusetPath)
+		if subErr != nil {
+			return fmt.Errorf("invalid cgroup path %q: %w", cpusetPath.AbsPath, subErr)
+		}
+		readErr := read(f, cpusetPath.Mode)
+		if !errors.Is(readErr, fs.ErrNotExist) {
+			return readErr
+		}
+		if leafCGReadErr == nil {
+			leafCGReadErr = readErr
+		}
+	}
+	return leafCGReadErr
+}
+
+// getCgroupEffectiveCPUsFS reads the effective CPUs of the cpuset cgroup at
+// cpusetPath within root (or its nearest ancestor with a cpuset applied).
+func getCgroupEffectiveCPUsFS(ctx context.Context, root fs.FS, cpusetPath cgresolver.CGroupPath) ([]int, error) {
+	cpus := []int(nil)
+	err := nearestCPUSetFS(ctx, root, cpusetPath, func(f fs.FS, mode cgresolver.CGMode) error {
+		c, readErr := cgroupEffectiveCPUs(f, mode)
+		cpus = c
+		return readErr
+	})
+	return cpus, err
+}
+
+// getCgroupCPUSetFS reads the effective cpuset of the cgroup at cpusetPath
+// within root (or its nearest ancestor with a cpuset applied).
+func getCgroupCPUSetFS(ctx context.Context, root fs.FS, cpusetPath cgresolver.CGroupPath) (CPUSet, error) {
+	cpuset := CPUSet{}
+	err := nearestCPUSetFS(ctx, root, cpusetPath, func(f fs.FS, mode cgresolver.CGMode) error {
+		cs, readErr := readCGroupCPUSet(f, mode)
+		cpuset = cs
+		return readErr
+	})
+	return cpuset, err
+}
+
+func hostOnlineCPUs() (int, error) {
+	conts, readErr := os.ReadFile(hostOnlineCPUsPath)
+	if readErr != nil {
+		return -1, fmt.Errorf("failed to read %q: %w", hostOnlineCPUsPath, readErr)
+	}
+	cpus, parseErr := pparser.ParseRangeList(string(conts))
+	if parseErr != nil {
+		return -1, fmt.Errorf("failed to parse %q: %w", hostOnlineCPUsPath, parseErr)
+	}
+	return len(cpus), nil
+}
+
+// CgroupOnlineCPUs returns the number of CPUs the current process's cpuset
+// cgroup allows it to run on (the cardinality of its effective cpuset).
+// Unlike the quota-based CPU limit returned by GetCgroupCPULimit, this is
+// always an integer number of cores, so it's often the better basis for
+// sizing thread pools.
+// Under cgroup v2, if the cpuset controller isn't enabled for the current
+// process's cgroup, the cpuset of its nearest ancestor that has it enabled
+// is used.
+// If the cpuset controller isn't available, or no cpuset applies to the
+// current process's cgroup, the number of online CPUs on the host is
+// returned.
+func CgroupOnlineCPUs() (int, error) {
+	return CgroupOnlineCPUsWithCache(context.Background(), nil)
+}
+
+// CgroupOnlineCPUsWithCache is CgroupOnlineCPUs, but resolves the cpuset
+// cgroup with cgr. (see GetCgroupCPUStatsWithCache)
+func CgroupOnlineCPUsWithCache(ctx context.Context, cgr *cgresolver.Cache) (int, error) {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return -1, ctxErr
+	}
+	cpusetPath, cgroupFindErr := cacheOrNew(cgr).Resolve("cpuset")
+	if cgroupFindErr != nil {
+		return hostOnlineCPUs()
+	}
+	cpus, cpusErr := getCgroupEffectiveCPUsFS(ctx, os.DirFS("/"), cpusetPath)
+	if cpusErr != nil {
+		if errors.Is(cpusErr, fs.ErrNotExist) {
+			return hostOnlineCPUs()
+		}
+		return -1, fmt.Errorf("failed to read cpuset for cgroup %q: %w", cpusetPath.AbsPath, cpusErr)
+	}
+	if len(cpus) == 0 {
+		// An empty cpuset means the cgroup hasn't been configured
+		// (v1), so it can't constrain anything.
+		return hostOnlineCPUs()
+	}
+	return len(cpus), nil
+}
+
+// GetCgroupCPUSet returns the set of CPUs the current process's cpuset
+// cgroup allows it to run on, and the memory nodes it may allocate from (its
+// effective cpuset).
+// Under cgroup v1, an unconfigured cpuset is empty, so the returned CPUSet
+// may have a zero Count; such a cpuset doesn't constrain anything.
+// Under cgroup v2, if the cpuset controller isn't enabled for the current
+// process's cgroup, the cpuset of its nearest ancestor that has it enabled
+// is returned.
+// If the cpuset controller isn't available, or isn't enabled for the current
+// process's cgroup or any of its ancestors, it returns an error wrapping
+// ErrCGroupsNotSupported.
+func GetCgroupCPUSet() (CPUSet, error) {
+	return GetCgroupCPUSetWithCache(context.Background(), nil)
+}
+
+// GetCgroupCPUSetWithCache is GetCgroupCPUSet, but resolves the cpuset cgroup
+// with cgr. (see GetCgroupCPUStatsWithCache)
+func GetCgroupCPUSetWithCache(ctx context.Context, cgr *cgresolver.Cache) (CPUSet, error) {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return CPUSet{}, ctxErr
+	}
+	cpusetPath, cgroupFindErr := cacheOrNew(cgr).Resolve("cpuset")
+	if cgroupFindErr != nil {
+		return CPUSet{}, fmt.Errorf("%w: unable to find cpuset cgroup directory: %s",
+			ErrCGroupsNotSupported, cgroupFindErr)
+	}
+	cpuset, cpusetErr := getCgroupCPUSetFS(ctx, os.DirFS("/"), cpusetPath)
+	if cpusetErr != nil {
+		if errors.Is(cpusetErr, fs.ErrNotExist) {
+			return CPUSet{}, fmt.Errorf("%w: no cpuset applied to cgroup %q: %s",
+				ErrCGroupsNotSupported, cpusetPath.AbsPath, cpusetErr)
+		}
+		return CPUSet{}, fmt.Errorf("failed to read cpuset for cgroup %q: %w", cpusetPath.AbsPath, cpusetErr)
+	}
+	return cpuset, nil
+}
+
+func readBoolValFile(f fs.FS, path string) (bool, error) {
+	v, readErr := readIntValFile(f, path)
+	if readErr != nil {
+		return false, readErr
+	}
+	return v != 0, nil
+}
+
+// readCPUSetFlags reads the flags of the cpuset cgroup rooted at f. The
+// returned error wraps fs.ErrNotExist if the cgroup doesn't have a cpuset
+// applied (or the relevant files don't exist at that level of the
+// hierarchy).
+func readCPUSetFlags(f fs.FS, mode cgresolver.CGMode) (CPUSetFlags, error) {
+	switch mode {
+	case cgresolver.CGModeV1:
+		flags := CPUSetFlags{}
+		for _, fl := range [...]struct {
+			file string
+			out  *bool
+		}{
+			{file: cgroupV1CPUSetLoadBalanceFile, out: &flags.SchedLoadBalance},
+			{file: cgroupV1CPUSetMemMigrateFile, out: &flags.MemoryMigrate},
+			{file: cgroupV1CPUSetCPUExclusiveFile, out: &flags.CPUExclusive},
+			{file: cgroupV1CPUSetMemExclusiveFile, out: &flags.MemExclusive},
+		} {
+			v, readErr := readBoolValFile(f, fl.file)
+			if readErr != nil {
+				return CPUSetFlags{}, readErr
+			}
+			*fl.out = v
+		}
+		return flags, nil
+	case cgresolver.CGModeV2:
+		conts, readErr := fs.ReadFile(f, cgroupV2CPUSetPartitionFile)
+		if readErr != nil {
+			return CPUSetFlags{}, fmt.Errorf("failed to read %q: %w", cgroupV2CPUSetPartitionFile, readErr)
+		}
+		// Invalid partitions are reported as e.g. "root invalid (...)",
+		// and behave like members until they become valid.
+		partition := strings.TrimSpace(string(conts))
+		valid := !strings.Contains(partition, "invalid")
+		return CPUSetFlags{
+			// Isolated partitions aren't load-balanced
+			SchedLoadBalance: !(valid && strings.HasPrefix(partition, "isolated")),
+			// cgroup v2 always migrates memory when
+			// cpuset.mems changes.
+			MemoryMigrate: true,
+			// valid partition roots have exclusive use of their
+			// CPUs
+			CPUExclusive: valid && partition != "member",
+			// there's no v2 equivalent of mem_exclusive
+			MemExclusive: false,
+			Partition:    partition,
+		}, nil
+	default:
+		return CPUSetFlags{}, fmt.Errorf("unknown cgroup type: %d", mode)
+	}
+}
+
+// GetCgroupCPUSetFlags returns the scheduling and memory-placement flags of
+// the current process's cpuset cgroup.
+// Under cgroup v2, the flags are derived from cpuset.cpus.partition.
+// If the cpuset controller isn't available, or isn't enabled for the current
+// process's cgroup, it returns an error wrapping ErrCGroupsNotSupported.
+func GetCgroupCPUSetFlags() (CPUSetFlags, error) {
+	return GetCgroupCPUSetFlagsWithCache(context.Background(), nil)
+}
+
+// GetCgroupCPUSetFlagsWithCache is GetCgroupCPUSetFlags, but resolves the
+// cpuset cgroup with cgr. (see GetCgroupCPUStatsWithCache)
+func GetCgroupCPUSetFlagsWithCache(ctx context.Context, cgr *cgresolver.Cache) (CPUSetFlags, error) {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return CPUSetFlags{}, ctxErr
+	}
+	cpusetPath, cgroupFindErr := cacheOrNew(cgr).Resolve("cpuset")
+	if cgroupFindErr != nil {
+		return CPUSetFlags{}, fmt.Errorf("%w: unable to find cpuset cgroup directory: %s",
+			ErrCGroupsNotSupported, cgroupFindErr)
+	}
+	f, subErr := cgroupDirFS(os.DirFS("/"), &cpusetPath)
+	if subErr != nil {
+		return CPUSetFlags{}, fmt.Errorf("invalid cgroup path %q: %w", cpusetPath.AbsPath, subErr)
+	}
+	flags, flagsErr := readCPUSetFlags(f, cpusetPath.Mode)
+	if flagsErr != nil {
+		if errors.Is(flagsErr, fs.ErrNotExist) {
+			return CPUSetFlags{}, fmt.Errorf("%w: no cpuset applied to cgroup %q: %s",
+				ErrCGroupsNotSupported, cpusetPath.AbsPath, flagsErr)
+		}
+		return CPUSetFlags{}, fmt.Errorf("failed to read cpuset flags for cgroup %q: %w", cpusetPath.AbsPath, flagsErr)
+	}
+	return flags, nil
+}
@@ -10,3 +10,8 @@ var ErrCGroupsNotSupported = errors.New(
 // ErrUnimplementedPlatform is returned on systems for which usage/limits
 // querying has not been implemented.
 var ErrUnimplementedPlatform = errors.New("support for this platform is unimplmented")
+
+// ErrPSIUnavailable is returned when the kernel doesn't expose Pressure Stall
+// Information for a cgroup. (kernels older than 4.20, built without
+// CONFIG_PSI, or booted with psi=0)
+var ErrPSIUnavailable = errors.New("pressure stall information is unavailable")
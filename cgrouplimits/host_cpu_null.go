@@ -0,0 +1,18 @@
+//go:build !linux
+// +build !linux
+
+package cgrouplimits
+
+import "runtime"
+
+// affinityCPUCount returns the number of CPUs the current process may run
+// on. (this platform doesn't expose affinity, so it's runtime.NumCPU())
+func affinityCPUCount() (int, error) {
+	return runtime.NumCPU(), nil
+}
+
+// HostCPUStats returns the host's aggregate CPU usage.
+func HostCPUStats() (CPUStats, error) {
+	// TODO: add a darwin implementation
+	return CPUStats{}, ErrUnimplementedPlatform
+}
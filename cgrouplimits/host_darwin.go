@@ -0,0 +1,70 @@
+//go:build darwin && cgo
+// +build darwin,cgo
+
+package cgrouplimits
+
+// #include <sys/sysctl.h>
+// #include <mach/mach.h>
+//
+// int get_host_mem_info(uint64_t *memsize, uint64_t *swap_total,
+//                       uint64_t *swap_avail, uint64_t *free_bytes,
+//                       uint64_t *inactive_bytes)
+// {
+//     size_t len = sizeof(*memsize);
+//     if (sysctlbyname("hw.memsize", memsize, &len, NULL, 0) != 0) {
+//         return -1;
+//     }
+//     struct xsw_usage swap;
+//     len = sizeof(swap);
+//     if (sysctlbyname("vm.swapusage", &swap, &len, NULL, 0) != 0) {
+//         return -2;
+//     }
+//     *swap_total = swap.xsu_total;
+//     *swap_avail = swap.xsu_avail;
+//
+//     mach_port_t host = mach_host_self();
+//     vm_size_t page_size;
+//     vm_statistics64_data_t vmstat;
+//     mach_msg_type_number_t count = HOST_VM_INFO64_COUNT;
+//     kern_return_t kr = host_page_size(host, &page_size);
+//     if (kr == KERN_SUCCESS) {
+//         kr = host_statistics64(host, HOST_VM_INFO64,
+//                                (host_info64_t)&vmstat, &count);
+//     }
+//     mach_port_deallocate(mach_task_self(), host);
+//     if (kr != KERN_SUCCESS) {
+//         return -3;
+//     }
+//     *free_bytes = (uint64_t)vmstat.free_count * page_size;
+//     *inactive_bytes = (uint64_t)vmstat.inactive_count * page_size;
+//     return 0;
+// }
+import "C"
+
+import "fmt"
+
+// HostMemStats gets the current memory usage from sysctl (hw.memsize and
+// vm.swapusage) and the mach VM statistics, and synthesizes it into a
+// MemoryStats object.
+// Available is approximated as the free and inactive pages, since darwin
+// has no direct equivalent of linux's MemAvailable. OOMKills is always zero.
+func HostMemStats() (MemoryStats, error) {
+	var memsize, swapTotal, swapAvail, freeBytes, inactiveBytes C.uint64_t
+	ret := C.get_host_mem_info(&memsize, &swapTotal, &swapAvail, &freeBytes, &inactiveBytes)
+	switch ret {
+	case 0:
+	case -1:
+		return MemoryStats{}, fmt.Errorf("failed to query hw.memsize sysctl")
+	case -2:
+		return MemoryStats{}, fmt.Errorf("failed to query vm.swapusage sysctl")
+	default:
+		return MemoryStats{}, fmt.Errorf("failed to query host VM statistics")
+	}
+	return MemoryStats{
+		Total:     int64(memsize + swapTotal),
+		Free:      int64(freeBytes + swapAvail),
+		Available: int64(freeBytes + inactiveBytes),
+		SwapTotal: int64(swapTotal),
+		SwapUsed:  int64(swapTotal - swapAvail),
+	}, nil
+}
@@ -4,14 +4,21 @@
 package cgrouplimits
 
 import (
+	"bufio"
+	"bytes"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strconv"
+	"time"
 
+	"github.com/vimeo/procstats"
+	"github.com/vimeo/procstats/cgresolver"
 	"github.com/vimeo/procstats/pparser"
 )
 
 func getMemInfo() (hostMemInfo, error) {
-	const procMemInfo = "/proc/meminfo"
+	procMemInfo := filepath.Join(cgresolver.ProcRoot(), "meminfo")
 	memInfoBytes, procReadErr := os.ReadFile(procMemInfo)
 	if procReadErr != nil {
 		return hostMemInfo{}, fmt.Errorf(
@@ -30,7 +37,7 @@ func getMemInfo() (hostMemInfo, error) {
 
 func getVMStat() (hostVMStat, error) {
 
-	const procVMStat = "/proc/vmstat"
+	procVMStat := filepath.Join(cgresolver.ProcRoot(), "vmstat")
 	vmStatBytes, procReadErr := os.ReadFile(procVMStat)
 	if procReadErr != nil {
 		return hostVMStat{}, fmt.Errorf(
@@ -63,9 +70,102 @@ func HostMemStats() (MemoryStats, error) {
 		Free:      mi.MemFree + mi.SwapFree,
 		Available: mi.MemAvailable,
 		OOMKills:  vms.OomKill,
+		SwapTotal: mi.SwapTotal,
+		SwapUsed:  mi.SwapTotal - mi.SwapFree,
 	}, nil
 }
 
+// userHZ is the unit of the times in /proc/stat (USER_HZ). The kernel fixes
+// this at 100 on all architectures we care about, and it's what
+// sysconf(_SC_CLK_TCK) returns.
+const userHZ = 100
+
+// excerpt from proc(5) man page section on /proc/stat:
+//
+//        cpu  10132153 290696 3084719 46828483 16683 0 25195 0 175628 0
+//               The amount of time, measured in units of USER_HZ
+//               (1/100ths of a second on most architectures, use
+//               sysconf(_SC_CLK_TCK) to obtain the right value), that
+//               the system ("cpu" line) or the specific CPU ("cpuN"
+//               line) spent in various states:
+//               user (1), nice (2), system (3), idle (4), iowait (5),
+//               irq (6), softirq (7), steal (8), guest (9),
+//               guest_nice (10)
+
+// parseHostCPUTime parses the aggregate "cpu" line from the contents of
+// /proc/stat, folding user and nice time into Utime and system, irq and
+// softirq time into Stime.
+func parseHostCPUTime(procStat []byte) (procstats.CPUTime, error) {
+	s := bufio.NewScanner(bytes.NewReader(procStat))
+	for s.Scan() {
+		fields := bytes.Fields(s.Bytes())
+		if len(fields) == 0 || string(fields[0]) != "cpu" {
+			continue
+		}
+		// user, nice, system, idle, iowait, irq, softirq
+		const minFields = 7
+		if len(fields)-1 < minFields {
+			return procstats.CPUTime{}, fmt.Errorf(
+				"insufficient fields in cpu line: %d; expected at least %d",
+				len(fields)-1, minFields)
+		}
+		ticks := [minFields]int64{}
+		for i := range ticks {
+			v, parseErr := strconv.ParseInt(string(fields[i+1]), 10, 64)
+			if parseErr != nil {
+				return procstats.CPUTime{}, fmt.Errorf(
+					"failed to parse column %d of cpu line (%q): %w",
+					i+1, fields[i+1], parseErr)
+			}
+			ticks[i] = v
+		}
+		const tick = time.Second / userHZ
+		user, nice, system, irq, softirq := ticks[0], ticks[1], ticks[2], ticks[5], ticks[6]
+		return procstats.CPUTime{
+			Utime: time.Duration(user+nice) * tick,
+			Stime: time.Duration(system+irq+softirq) * tick,
+		}, nil
+	}
+	if err := s.Err(); err != nil {
+		return procstats.CPUTime{}, err
+	}
+	return procstats.CPUTime{}, fmt.Errorf("no cpu line found in /proc/stat")
+}
+
+// affinityCPUCount returns the number of CPUs in the current process's
+// affinity mask (the Cpus_allowed_list field of /proc/self/status).
+func affinityCPUCount() (int, error) {
+	st, statusErr := procstats.ReadProcStatus(os.Getpid())
+	if statusErr != nil {
+		return -1, statusErr
+	}
+	cpus, cpusErr := st.AllowedCPUs()
+	if cpusErr != nil {
+		return -1, cpusErr
+	}
+	return len(cpus), nil
+}
+
+// HostCPUStats reads the host's aggregate CPU usage from /proc/stat and
+// synthesizes it into a CPUStats object. ThrottledTime is always zero, and
+// Limit is left for the caller to fill in.
+func HostCPUStats() (CPUStats, error) {
+	procStat := filepath.Join(cgresolver.ProcRoot(), "stat")
+	procStatBytes, procReadErr := os.ReadFile(procStat)
+	if procReadErr != nil {
+		return CPUStats{}, fmt.Errorf(
+			"failed to read contents of %q: %s",
+			procStat, procReadErr)
+	}
+	ct, parseErr := parseHostCPUTime(procStatBytes)
+	if parseErr != nil {
+		return CPUStats{}, fmt.Errorf(
+			"failed to parse %q contents: %s",
+			procStat, parseErr)
+	}
+	return CPUStats{Usage: ct}, nil
+}
+
 func parseMemInfo(contentBytes []byte) (hostMemInfo, error) {
 
 	mi := hostMemInfo{UnknownFields: make(map[string]int64)}
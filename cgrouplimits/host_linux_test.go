@@ -3,7 +3,12 @@
 
 package cgrouplimits
 
-import "testing"
+import (
+	"testing"
+	"time"
+
+	"github.com/vimeo/procstats"
+)
 
 func TestParseMemInfo(t *testing.T) {
 	mi, err := parseMemInfo([]byte(testProcMemInfoVal))
@@ -245,3 +250,30 @@ thp_swpout 0
 thp_swpout_fallback 0
 swap_ra 700536
 swap_ra_hit 659514`
+
+func TestParseHostCPUTime(t *testing.T) {
+	const procStat = `cpu  10132153 290696 3084719 46828483 16683 12 25195 0 175628 0
+cpu0 1393280 32966 572056 13343292 6130 0 17875 0 23933 0
+intr 1462898 0 0 0
+ctxt 115315133
+btime 769041601
+`
+	ct, err := parseHostCPUTime([]byte(procStat))
+	if err != nil {
+		t.Fatalf("failed to parse /proc/stat: %s", err)
+	}
+	expected := procstats.CPUTime{
+		Utime: (10132153 + 290696) * 10 * time.Millisecond,
+		Stime: (3084719 + 12 + 25195) * 10 * time.Millisecond,
+	}
+	if ct != expected {
+		t.Errorf("unexpected CPU time: %+v; expected %+v", ct, expected)
+	}
+
+	if _, err := parseHostCPUTime([]byte("cpu  1 2 3\n")); err == nil {
+		t.Error("unexpectedly successful parse of truncated cpu line")
+	}
+	if _, err := parseHostCPUTime([]byte("cpu0 1 2 3 4 5 6 7\n")); err == nil {
+		t.Error("unexpectedly successful parse without aggregate cpu line")
+	}
+}
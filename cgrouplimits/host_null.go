@@ -1,10 +1,10 @@
-//go:build !linux
+//go:build !linux && !(darwin && cgo)
 // +build !linux
+// +build !darwin !cgo
 
 package cgrouplimits
 
 // HostMemStats returns the size of the machine.
 func HostMemStats() (MemoryStats, error) {
-	// TODO: add a darwin implementation
 	return MemoryStats{}, ErrUnimplementedPlatform
 }
@@ -1,5 +1,10 @@
 package cgrouplimits
 
+import (
+	"encoding/json"
+	"fmt"
+)
+
 // MemoryStats encapsulates memory limits, usage and available.
 type MemoryStats struct {
 	// Total memory in the container/system
@@ -14,6 +19,141 @@ type MemoryStats struct {
 	// Number of OOM-kills either within the memory cgroup or on the host
 	// (if available)
 	OOMKills int64
+
+	// SwapTotal is the swap available to the cgroup (its swap limit;
+	// math.MaxInt64 if unlimited) or the host's total swap, in bytes.
+	// Zero if swap accounting is unavailable.
+	SwapTotal int64
+	// SwapUsed is the amount of swap in use by the cgroup or host, in
+	// bytes.
+	SwapUsed int64
+}
+
+// String formats the MemoryStats for logging, with byte counts in IEC
+// units, e.g. "total=4GiB free=1.5GiB available=2GiB oom_kills=0
+// swap_total=1GiB swap_used=4KiB".
+func (m MemoryStats) String() string {
+	return fmt.Sprintf("total=%s free=%s available=%s oom_kills=%d swap_total=%s swap_used=%s",
+		formatBytes(m.Total), formatBytes(m.Free), formatBytes(m.Available), m.OOMKills,
+		formatBytes(m.SwapTotal), formatBytes(m.SwapUsed))
+}
+
+// formatBytes formats a byte count with the largest IEC unit that keeps the
+// value at or above 1. (negative values, such as the -1 used for "no limit",
+// are formatted as raw byte counts)
+func formatBytes(b int64) string {
+	const unit = 1024
+	if b < unit {
+		return fmt.Sprintf("%dB", b)
+	}
+	div, exp := int64(unit), 0
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.3g%ciB", float64(b)/float64(div), "KMGTPE"[exp])
+}
+
+// memoryStatsJSON is the JSON representation of a MemoryStats, with the
+// byte counts as raw integers.
+type memoryStatsJSON struct {
+	TotalBytes     int64 `json:"total_bytes"`
+	FreeBytes      int64 `json:"free_bytes"`
+	AvailableBytes int64 `json:"available_bytes"`
+	OOMKills       int64 `json:"oom_kills"`
+	SwapTotalBytes int64 `json:"swap_total_bytes"`
+	SwapUsedBytes  int64 `json:"swap_used_bytes"`
+}
+
+// MarshalJSON implements json.Marshaler
+func (m MemoryStats) MarshalJSON() ([]byte, error) {
+	return json.Marshal(memoryStatsJSON{
+		TotalBytes:     m.Total,
+		FreeBytes:      m.Free,
+		AvailableBytes: m.Available,
+		OOMKills:       m.OOMKills,
+		SwapTotalBytes: m.SwapTotal,
+		SwapUsedBytes:  m.SwapUsed,
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler, accepting the format emitted by
+// MarshalJSON.
+func (m *MemoryStats) UnmarshalJSON(b []byte) error {
+	j := memoryStatsJSON{}
+	if err := json.Unmarshal(b, &j); err != nil {
+		return err
+	}
+	*m = MemoryStats{
+		Total:     j.TotalBytes,
+		Free:      j.FreeBytes,
+		Available: j.AvailableBytes,
+		OOMKills:  j.OOMKills,
+		SwapTotal: j.SwapTotalBytes,
+		SwapUsed:  j.SwapUsedBytes,
+	}
+	return nil
+}
+
+// withTotal returns a copy of m with Total replaced, and Free and Available
+// adjusted to preserve the used and reclaimable byte counts.
+func (m MemoryStats) withTotal(total int64) MemoryStats {
+	used := m.Total - m.Free
+	reclaimable := m.Available - m.Free
+	m.Total = total
+	m.Free = total - used
+	m.Available = m.Free + reclaimable
+	return m
+}
+
+// SwapStats encapsulates the swap usage and limit of a cgroup.
+// For cgroups, MemoryStats' Total, Free and Available exclude swap; to
+// account for swap-backed memory, add SwapUsage to the used memory
+// (Total - Free), and treat SwapLimit as additional headroom beyond the
+// memory limit (MemoryStats.Total). (these are also reported in
+// MemoryStats' SwapUsed and SwapTotal fields)
+type SwapStats struct {
+	// SwapUsage is the amount of swap used by the cgroup (and
+	// descendants) in bytes
+	SwapUsage int64
+	// SwapLimit is the limit on swap usage in bytes (math.MaxInt64 if
+	// unlimited)
+	SwapLimit int64
+}
+
+// MemoryThresholds encapsulates the memory protections and limits of a
+// cgroup, in bytes.
+type MemoryThresholds struct {
+	// Min is the amount of memory that's never reclaimed from the cgroup
+	// (0 if unprotected)
+	Min int64
+	// Low is the amount of memory that's only reclaimed from the cgroup
+	// if there's nothing reclaimable in unprotected cgroups (0 if
+	// unprotected)
+	Low int64
+	// High is the usage above which the cgroup is throttled and put
+	// under heavy reclaim pressure (math.MaxInt64 if unlimited). Under
+	// cgroup v1, this is the soft limit, which is only enforced when the
+	// host is under memory pressure.
+	High int64
+	// Max is the hard limit above which the OOM killer is invoked
+	// (math.MaxInt64 if unlimited)
+	Max int64
+}
+
+// ZswapStats encapsulates the compressed-swap (zswap) usage of a cgroup.
+type ZswapStats struct {
+	// PoolBytes is the memory consumed by the zswap compression pool
+	PoolBytes int64
+	// SwappedBytes is the uncompressed size of the pages that have been
+	// swapped out to zswap (PoolBytes/SwappedBytes is the effective
+	// compression ratio)
+	SwappedBytes int64
+	// PagesIn is the number of pages moved into memory from zswap
+	PagesIn int64
+	// PagesOut is the number of pages compressed and moved out of memory
+	// to zswap
+	PagesOut int64
 }
 
 // MemStats queries the system for the current cgroup (if available) and total
@@ -44,3 +184,31 @@ func MemStats() (MemoryStats, error) {
 
 	return ms, nil
 }
+
+// IsMemoryLimited reports whether the current process is subject to a
+// cgroup memory limit that's actually constraining, i.e. one that's smaller
+// than the host's total memory (see HostMemStats). If so, the effective
+// limit is returned as well; otherwise the limit is -1.
+// On systems without cgroups, this returns ErrCGroupsNotSupported.
+func IsMemoryLimited() (bool, int64, error) {
+	cgLimit, cgErr := GetCgroupMemoryLimit()
+	if cgErr != nil {
+		return false, -1, cgErr
+	}
+	ms, msErr := HostMemStats()
+	if msErr != nil {
+		return false, -1, msErr
+	}
+	if cgLimit <= 0 || cgLimit >= ms.Total {
+		return false, -1, nil
+	}
+	return true, cgLimit, nil
+}
+
+// withoutLimit returns a copy of m with Total, Free and Available all -1, for
+// a cgroup without a memory limit. (there's no limit to measure Free and
+// Available against)
+func (m MemoryStats) withoutLimit() MemoryStats {
+	m.Total, m.Free, m.Available = -1, -1, -1
+	return m
+}
@@ -0,0 +1,57 @@
+package cgrouplimits
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestMemoryStatsString(t *testing.T) {
+	ms := MemoryStats{
+		Total:     4 << 30,
+		Free:      1536 << 20,
+		Available: 1000,
+		OOMKills:  2,
+		SwapTotal: 1 << 30,
+		SwapUsed:  4096,
+	}
+	if s := ms.String(); s != "total=4GiB free=1.5GiB available=1000B oom_kills=2 swap_total=1GiB swap_used=4KiB" {
+		t.Errorf("unexpected string: %q", s)
+	}
+	if s := (MemoryStats{Total: -1}).String(); s != "total=-1B free=0B available=0B oom_kills=0 swap_total=0B swap_used=0B" {
+		t.Errorf("unexpected string for unlimited: %q", s)
+	}
+}
+
+func TestMemoryStatsJSONRoundTrip(t *testing.T) {
+	ms := MemoryStats{
+		Total:     4000000000,
+		Free:      1999999999,
+		Available: 2500000000,
+		OOMKills:  1,
+		SwapTotal: 1 << 30,
+		SwapUsed:  4096,
+	}
+	b, err := json.Marshal(ms)
+	if err != nil {
+		t.Fatalf("failed to marshal: %s", err)
+	}
+	if exp := `{"total_bytes":4000000000,"free_bytes":1999999999,"available_bytes":2500000000,"oom_kills":1,"swap_total_bytes":1073741824,"swap_used_bytes":4096}`; string(b) != exp {
+		t.Errorf("unexpected JSON: %s; expected %s", b, exp)
+	}
+	out := MemoryStats{}
+	if err := json.Unmarshal(b, &out); err != nil {
+		t.Fatalf("failed to unmarshal: %s", err)
+	}
+	if out != ms {
+		t.Errorf("round-trip mismatch: %+v; expected %+v", out, ms)
+	}
+}
+
+func TestMemoryStatsWithTotal(t *testing.T) {
+	// unlimited: usage 400000, with 500 bytes reclaimable
+	ms := MemoryStats{Total: -1, Free: -400001, Available: -399501, OOMKills: 2, SwapTotal: 10, SwapUsed: 5}
+	exp := MemoryStats{Total: 1000000, Free: 600000, Available: 600500, OOMKills: 2, SwapTotal: 10, SwapUsed: 5}
+	if got := ms.withTotal(1000000); got != exp {
+		t.Errorf("unexpected stats: %+v; expected %+v", got, exp)
+	}
+}
@@ -0,0 +1,125 @@
+package cgrouplimits
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"time"
+)
+
+// Defaults for OOMGuardOptions fields left at their zero-values.
+const (
+	DefaultOOMGuardInterval  = time.Second
+	DefaultOOMGuardThreshold = 0.9
+)
+
+// OOMGuardOptions configures the watchdog started by StartOOMGuard.
+type OOMGuardOptions struct {
+	// Interval is the period between memory usage samples. (defaults to
+	// DefaultOOMGuardInterval)
+	Interval time.Duration
+	// Threshold is the fraction of the effective memory limit in (0, 1]
+	// above which Action is invoked. (defaults to
+	// DefaultOOMGuardThreshold)
+	Threshold float64
+	// Action is invoked with the most recent MemoryStats for every sample
+	// in which usage exceeds Threshold. It is called synchronously from
+	// the sampling goroutine, so slow actions delay the next sample.
+	// (defaults to FreeOSMemory)
+	Action func(MemoryStats)
+	// OnError, if non-nil, is invoked when sampling fails after the
+	// guard has started. Sampling continues at the next interval.
+	OnError func(error)
+
+	// memStats is overridden in tests
+	memStats func() (MemoryStats, error)
+}
+
+// FreeOSMemory is the default OOMGuardOptions Action; it forces a garbage
+// collection and returns as much memory to the operating system as
+// possible (see runtime/debug.FreeOSMemory).
+func FreeOSMemory(MemoryStats) {
+	debug.FreeOSMemory()
+}
+
+func (o OOMGuardOptions) withDefaults() (OOMGuardOptions, error) {
+	if o.Interval < 0 {
+		return o, fmt.Errorf("invalid negative interval: %s", o.Interval)
+	}
+	if o.Interval == 0 {
+		o.Interval = DefaultOOMGuardInterval
+	}
+	if o.Threshold < 0 || o.Threshold > 1 {
+		return o, fmt.Errorf("invalid threshold %g; must be in (0, 1]", o.Threshold)
+	}
+	if o.Threshold == 0 {
+		o.Threshold = DefaultOOMGuardThreshold
+	}
+	if o.Action == nil {
+		o.Action = FreeOSMemory
+	}
+	if o.memStats == nil {
+		o.memStats = MemStats
+	}
+	return o, nil
+}
+
+// check invokes the action if the usage in ms exceeds the threshold
+func (o *OOMGuardOptions) check(ms MemoryStats) {
+	if ms.Total <= 0 {
+		// no usable limit to compare against
+		return
+	}
+	used := ms.Total - ms.Free
+	if float64(used) > o.Threshold*float64(ms.Total) {
+		o.Action(ms)
+	}
+}
+
+func (o *OOMGuardOptions) run(ctx context.Context) {
+	t := time.NewTicker(o.Interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		ms, err := o.memStats()
+		if err != nil {
+			if o.OnError != nil {
+				o.OnError(err)
+			}
+			continue
+		}
+		o.check(ms)
+	}
+}
+
+// StartOOMGuard starts a goroutine that samples the memory usage and
+// effective limit (as reported by MemStats) every opts.Interval, and invokes
+// opts.Action whenever usage exceeds opts.Threshold of that limit. The
+// goroutine exits when ctx is cancelled.
+//
+// The first sample is taken synchronously, so an error is returned (and no
+// goroutine is started) if memory stats are unavailable on this platform or
+// opts is invalid.
+//
+// Note: memory usage is only observed once per interval, so usage may grow by
+// up to the allocation rate times the interval between samples before the
+// guard can react. The threshold must leave at least that much headroom below
+// the limit; shortening the interval allows a higher threshold at the cost of
+// more frequent cgroup reads.
+func StartOOMGuard(ctx context.Context, opts OOMGuardOptions) error {
+	o, optsErr := opts.withDefaults()
+	if optsErr != nil {
+		return optsErr
+	}
+	ms, err := o.memStats()
+	if err != nil {
+		return fmt.Errorf("failed to sample memory usage: %w", err)
+	}
+	o.check(ms)
+	go o.run(ctx)
+	return nil
+}
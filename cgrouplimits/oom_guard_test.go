@@ -0,0 +1,126 @@
+package cgrouplimits
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestOOMGuardThreshold(t *testing.T) {
+	for _, tbl := range []struct {
+		name      string
+		threshold float64
+		stats     MemoryStats
+		expAction bool
+	}{
+		{
+			name:      "below_threshold",
+			threshold: 0.9,
+			stats:     MemoryStats{Total: 1000, Free: 200},
+			expAction: false,
+		},
+		{
+			name:      "above_threshold",
+			threshold: 0.9,
+			stats:     MemoryStats{Total: 1000, Free: 50},
+			expAction: true,
+		},
+		{
+			name:      "default_threshold",
+			threshold: 0,
+			stats:     MemoryStats{Total: 1000, Free: 99},
+			expAction: true,
+		},
+		{
+			name:      "no_limit",
+			threshold: 0.5,
+			stats:     MemoryStats{Total: -1, Free: -1000},
+			expAction: false,
+		},
+	} {
+		t.Run(tbl.name, func(t *testing.T) {
+			acted := false
+			o, err := OOMGuardOptions{
+				Threshold: tbl.threshold,
+				Action:    func(MemoryStats) { acted = true },
+				memStats:  func() (MemoryStats, error) { return tbl.stats, nil },
+			}.withDefaults()
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			o.check(tbl.stats)
+			if acted != tbl.expAction {
+				t.Errorf("unexpected action: %t; expected %t", acted, tbl.expAction)
+			}
+		})
+	}
+}
+
+func TestOOMGuardInvalidOptions(t *testing.T) {
+	for _, opts := range []OOMGuardOptions{
+		{Threshold: 1.5},
+		{Threshold: -0.1},
+		{Interval: -time.Second},
+	} {
+		if _, err := opts.withDefaults(); err == nil {
+			t.Errorf("expected error for options %+v", opts)
+		}
+	}
+}
+
+func TestOOMGuardRun(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sampleErr := errors.New("sample failure")
+	samples := []error{nil, sampleErr, nil}
+	acted := make(chan MemoryStats, len(samples))
+	errs := make(chan error, len(samples))
+	o, optsErr := OOMGuardOptions{
+		Interval: time.Millisecond,
+		Action:   func(ms MemoryStats) { acted <- ms },
+		OnError:  func(err error) { errs <- err },
+		memStats: func() (MemoryStats, error) {
+			if len(samples) == 0 {
+				cancel()
+				return MemoryStats{}, nil
+			}
+			err := samples[0]
+			samples = samples[1:]
+			return MemoryStats{Total: 100, Free: 1}, err
+		},
+	}.withDefaults()
+	if optsErr != nil {
+		t.Fatalf("unexpected error: %s", optsErr)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		o.run(ctx)
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("guard failed to exit after context cancellation")
+	}
+	if len(acted) != 2 {
+		t.Errorf("unexpected number of actions: %d; expected 2", len(acted))
+	}
+	if err := <-errs; err != sampleErr {
+		t.Errorf("unexpected error: %v; expected %v", err, sampleErr)
+	}
+}
+
+func TestStartOOMGuard(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	err := StartOOMGuard(ctx, OOMGuardOptions{Action: func(MemoryStats) {}})
+	if errors.Is(err, ErrCGroupsNotSupported) || errors.Is(err, ErrUnimplementedPlatform) {
+		t.Skip("unsupported platform")
+	}
+	if err != nil {
+		t.Fatalf("failed to start OOM guard: %s", err)
+	}
+}
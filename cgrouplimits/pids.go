@@ -0,0 +1,12 @@
+package cgrouplimits
+
+// PIDStats encapsulates the number of tasks in a pids cgroup, and the limit
+// on that number.
+type PIDStats struct {
+	// Current is the number of tasks (threads) in the cgroup and its
+	// descendants
+	Current int64
+	// Limit is the most restrictive pids.max limit of the cgroup and its
+	// ancestors (math.MaxInt64 if unlimited)
+	Limit int64
+}
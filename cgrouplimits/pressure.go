@@ -0,0 +1,34 @@
+package cgrouplimits
+
+import "time"
+
+// PSIMetrics contains the pressure stall averages and total stall time for
+// one line ("some" or "full") of a PSI pressure file.
+type PSIMetrics struct {
+	// Avg10, Avg60 and Avg300 are the percentage of wall-time in which
+	// tasks were stalled over the trailing 10, 60 and 300 second windows.
+	Avg10  float64
+	Avg60  float64
+	Avg300 float64
+	// Total is the cumulative stall time
+	Total time.Duration
+}
+
+// PSI contains the Pressure Stall Information for a single resource.
+type PSI struct {
+	// Some tracks the time in which at least one task was stalled on the
+	// resource.
+	Some PSIMetrics
+	// Full tracks the time in which all non-idle tasks were stalled on the
+	// resource simultaneously. (zero for the cpu resource on kernels older
+	// than 5.13, which only report "some")
+	Full PSIMetrics
+}
+
+// PSIStats contains the Pressure Stall Information for a cgroup's cpu,
+// memory and io resources.
+type PSIStats struct {
+	CPU    PSI
+	Memory PSI
+	IO     PSI
+}
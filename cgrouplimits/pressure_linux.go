@@ -0,0 +1,205 @@
+//go:build linux
+// +build linux
+
+package cgrouplimits
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/vimeo/procstats/cgresolver"
+)
+
+const (
+	cgroupV2CPUPressureFile    = "cpu.pressure"
+	cgroupV2MemoryPressureFile = "memory.pressure"
+	cgroupV2IOPressureFile     = "io.pressure"
+)
+
+// parsePSILine parses the fields following the "some" or "full" prefix of a
+// pressure file line. e.g. "avg10=0.00 avg60=0.00 avg300=0.00 total=12345"
+func parsePSILine(fields []string) (PSIMetrics, error) {
+	out := PSIMetrics{}
+	for _, field := range fields {
+		k, v, ok := strings.Cut(field, "=")
+		if !ok {
+			return PSIMetrics{}, fmt.Errorf("malformed field %q: missing '='", field)
+		}
+		var dst *float64
+		switch k {
+		case "avg10":
+			dst = &out.Avg10
+		case "avg60":
+			dst = &out.Avg60
+		case "avg300":
+			dst = &out.Avg300
+		case "total":
+			totalμs, parseErr := strconv.ParseInt(v, 10, 64)
+			if parseErr != nil {
+				return PSIMetrics{}, fmt.Errorf("failed to parse total %q: %w", v, parseErr)
+			}
+			out.Total = time.Duration(totalμs) * time.Microsecond
+			continue
+		default:
+			// ignore any fields added by newer kernels
+			continue
+		}
+		avg, parseErr := strconv.ParseFloat(v, 64)
+		if parseErr != nil {
+			return PSIMetrics{}, fmt.Errorf("failed to parse %s %q: %w", k, v, parseErr)
+		}
+		*dst = avg
+	}
+	return out, nil
+}
+
+// parsePSI parses the contents of a cpu.pressure, memory.pressure or
+// io.pressure file.
+func parsePSI(contents []byte) (PSI, error) {
+	out := PSI{}
+	for _, line := range strings.Split(strings.TrimSpace(string(contents)), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		var dst *PSIMetrics
+		switch fields[0] {
+		case "some":
+			dst = &out.Some
+		case "full":
+			dst = &out.Full
+		default:
+			return PSI{}, fmt.Errorf("unknown pressure line type %q", fields[0])
+		}
+		m, lineErr := parsePSILine(fields[1:])
+		if lineErr != nil {
+			return PSI{}, fmt.Errorf("failed to parse %q line: %w", fields[0], lineErr)
+		}
+		*dst = m
+	}
+	return out, nil
+}
+
+// readPSIFile reads and parses the named pressure file, wrapping
+// ErrPSIUnavailable if the kernel doesn't provide it.
+func readPSIFile(f fs.FS, name string) (PSI, error) {
+	contents, readErr := fs.ReadFile(f, name)
+	if readErr != nil {
+		// Kernels booted with psi=0 still have the files, but reads
+		// fail with EOPNOTSUPP.
+		if errors.Is(readErr, fs.ErrNotExist) || errors.Is(readErr, syscall.EOPNOTSUPP) {
+			return PSI{}, fmt.Errorf("%w: failed to read %s: %w", ErrPSIUnavailable, name, readErr)
+		}
+		return PSI{}, fmt.Errorf("failed to read %s: %w", name, readErr)
+	}
+	psi, parseErr := parsePSI(contents)
+	if parseErr != nil {
+		return PSI{}, fmt.Errorf("failed to parse %s: %w", name, parseErr)
+	}
+	return psi, nil
+}
+
+func readPSIStats(f fs.FS) (PSIStats, error) {
+	cpu, cpuErr := readPSIFile(f, cgroupV2CPUPressureFile)
+	if cpuErr != nil {
+		return PSIStats{}, cpuErr
+	}
+	mem, memErr := readPSIFile(f, cgroupV2MemoryPressureFile)
+	if memErr != nil {
+		return PSIStats{}, memErr
+	}
+	io, ioErr := readPSIFile(f, cgroupV2IOPressureFile)
+	if ioErr != nil {
+		return PSIStats{}, ioErr
+	}
+	return PSIStats{CPU: cpu, Memory: mem, IO: io}, nil
+}
+
+// GetCgroupPSI returns the Pressure Stall Information for the cpu, memory
+// and io resources of the current process's cgroup.
+// On kernels that don't expose PSI, it returns an error wrapping
+// ErrPSIUnavailable.
+// This requires cgroup v2; on cgroup v1 it returns an error wrapping
+// ErrCGroupsNotSupported.
+func GetCgroupPSI() (PSIStats, error) {
+	return GetCgroupPSIWithCache(context.Background(), nil)
+}
+
+// GetCgroupPSIWithCache is GetCgroupPSI, but resolves the cgroup with cgr.
+// (see GetCgroupCPUStatsWithCache)
+func GetCgroupPSIWithCache(ctx context.Context, cgr *cgresolver.Cache) (PSIStats, error) {
+	f, resolveErr := resolveCG2Dir(ctx, cacheOrNew(cgr))
+	if resolveErr != nil {
+		return PSIStats{}, resolveErr
+	}
+	return readPSIStats(f)
+}
+
+// resolveCG2Dir resolves the current process's cgroup v2 cgroup with cgr,
+// returning an fs.FS rooted at its directory.
+// The pressure files are core cgroup v2 interface files (present whether or
+// not the corresponding controller is enabled), so the memory controller's
+// cgroup is used to find the cgroup v2 hierarchy.
+func resolveCG2Dir(ctx context.Context, cgr *cgresolver.Cache) (fs.FS, error) {
+	memPath, f, resolveErr := resolveCgroupDir(ctx, cgr, "memory")
+	if resolveErr != nil {
+		return nil, resolveErr
+	}
+	if memPath.Mode != cgresolver.CGModeV2 {
+		return nil, fmt.Errorf("%w: memory controller is not on the cgroup v2 hierarchy",
+			ErrCGroupsNotSupported)
+	}
+	return f, nil
+}
+
+// cgroupPressure reads the named pressure file from the cgroup resolved with
+// cgr (see resolveCG2Dir).
+func cgroupPressure(ctx context.Context, cgr *cgresolver.Cache, name string) (PSI, error) {
+	f, resolveErr := resolveCG2Dir(ctx, cacheOrNew(cgr))
+	if resolveErr != nil {
+		return PSI{}, resolveErr
+	}
+	return readPSIFile(f, name)
+}
+
+// GetCgroupCPUPressure returns the CPU Pressure Stall Information for the
+// current process's cgroup. Errors are as for GetCgroupPSI.
+func GetCgroupCPUPressure() (PSI, error) {
+	return cgroupPressure(context.Background(), nil, cgroupV2CPUPressureFile)
+}
+
+// GetCgroupCPUPressureWithCache is GetCgroupCPUPressure, but resolves the
+// cgroup with cgr. (see GetCgroupCPUStatsWithCache)
+func GetCgroupCPUPressureWithCache(ctx context.Context, cgr *cgresolver.Cache) (PSI, error) {
+	return cgroupPressure(ctx, cgr, cgroupV2CPUPressureFile)
+}
+
+// GetCgroupMemoryPressure returns the memory Pressure Stall Information for
+// the current process's cgroup. Errors are as for GetCgroupPSI.
+func GetCgroupMemoryPressure() (PSI, error) {
+	return cgroupPressure(context.Background(), nil, cgroupV2MemoryPressureFile)
+}
+
+// GetCgroupMemoryPressureWithCache is GetCgroupMemoryPressure, but resolves the
+// cgroup with cgr. (see GetCgroupCPUStatsWithCache)
+func GetCgroupMemoryPressureWithCache(ctx context.Context, cgr *cgresolver.Cache) (PSI, error) {
+	return cgroupPressure(ctx, cgr, cgroupV2MemoryPressureFile)
+}
+
+// GetCgroupIOPressure returns the IO Pressure Stall Information for the
+// current process's cgroup. Errors are as for GetCgroupPSI.
+func GetCgroupIOPressure() (PSI, error) {
+	return cgroupPressure(context.Background(), nil, cgroupV2IOPressureFile)
+}
+
+// GetCgroupIOPressureWithCache is GetCgroupIOPressure, but resolves the
+// cgroup with cgr. (see GetCgroupCPUStatsWithCache)
+func GetCgroupIOPressureWithCache(ctx context.Context, cgr *cgresolver.Cache) (PSI, error) {
+	return cgroupPressure(ctx, cgr, cgroupV2IOPressureFile)
+}
@@ -0,0 +1,15 @@
+package cgrouplimits
+
+import "github.com/vimeo/procstats/cgresolver"
+
+// ResourceLimits aggregates the CPU, memory and PID usage/limits of a
+// process's cgroups.
+type ResourceLimits struct {
+	// Mode is the cgroup version of the memory cgroup. (on hybrid hosts,
+	// other controllers may be in the other hierarchy)
+	Mode   cgresolver.CGMode
+	CPU    CPUStats
+	Memory MemoryStats
+	// PIDs is the zero value if the pids controller is unavailable
+	PIDs PIDStats
+}
@@ -0,0 +1,58 @@
+//go:build linux
+// +build linux
+
+package cgrouplimits
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/vimeo/procstats/cgresolver"
+)
+
+// GetResourceLimits gathers the CPU, memory and PID usage/limits of the
+// current process's cgroups in one pass. The cgroup paths for all the
+// subsystems are resolved from a single read of the relevant procfs files,
+// rather than once per getter as with GetCgroupCPUStats, GetCgroupMemoryStats
+// and GetCgroupPIDStats.
+// If the pids controller isn't available (no pids hierarchy is mounted, or
+// its files aren't present), PIDs is left as the zero value.
+func GetResourceLimits() (ResourceLimits, error) {
+	return GetResourceLimitsWithCache(context.Background(), nil)
+}
+
+// GetResourceLimitsWithCache is GetResourceLimits, but resolves the cgroups
+// with cgr (a new Cache if nil), so repeated calls can skip parsing the
+// procfs files altogether, and gives up (returning ctx.Err()) if ctx is done
+// before the cgroup hierarchies have been walked.
+func GetResourceLimitsWithCache(ctx context.Context, cgr *cgresolver.Cache) (ResourceLimits, error) {
+	cgr = cacheOrNew(cgr)
+
+	memPath, memFindErr := cgr.Resolve("memory")
+	if memFindErr != nil {
+		return ResourceLimits{}, fmt.Errorf("unable to find cgroup directory: %w", memFindErr)
+	}
+	cpu, cpuErr := cgroupCPUStats(ctx, cgr)
+	if cpuErr != nil {
+		return ResourceLimits{}, fmt.Errorf("failed to read cgroup CPU stats: %w", cpuErr)
+	}
+	mem, memErr := cgroupMemoryStats(ctx, cgr)
+	if memErr != nil {
+		return ResourceLimits{}, fmt.Errorf("failed to read cgroup memory stats: %w", memErr)
+	}
+	// The pids controller is frequently not enabled, which shouldn't
+	// keep us from returning everything else. (the memory resolution
+	// above has already read the procfs files, so a failure to resolve
+	// pids isn't a procfs read error)
+	pids, pidsErr := optionalCGroupPIDStats(os.DirFS("/"), cgr)
+	if pidsErr != nil {
+		return ResourceLimits{}, fmt.Errorf("failed to read cgroup pids stats: %w", pidsErr)
+	}
+	return ResourceLimits{
+		Mode:   memPath.Mode,
+		CPU:    cpu,
+		Memory: mem,
+		PIDs:   pids,
+	}, nil
+}
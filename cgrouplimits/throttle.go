@@ -0,0 +1,115 @@
+package cgrouplimits
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/vimeo/procstats/cgresolver"
+)
+
+// throttleCounters contains the cumulative CFS bandwidth-control counters
+// from a cgroup's cpu.stat file.
+type throttleCounters struct {
+	TotalPeriods     int64
+	ThrottledPeriods int64
+	ThrottledTime    time.Duration
+}
+
+// ThrottleRate describes how heavily the current process's cgroup was
+// throttled between two calls to ThrottleTracker.Update.
+type ThrottleRate struct {
+	// Interval is the wall-time elapsed since the previous sample
+	Interval time.Duration
+	// ThrottledPeriodsPerSecond is the number of enforcement periods in
+	// which the cgroup was throttled, per second of wall-time
+	ThrottledPeriodsPerSecond float64
+	// ThrottledTime is the time tasks in the cgroup spent throttled
+	// since the previous sample
+	ThrottledTime time.Duration
+	// Reset is true if the counters went backwards since the previous
+	// sample (e.g. the cgroup was recreated), in which case the deltas
+	// are relative to zero rather than the previous sample.
+	Reset bool
+}
+
+// ThrottleTracker tracks CPU throttling of the current process's cpu
+// cgroup across calls to Update.
+// A ThrottleTracker is not safe for concurrent use; it's intended to be
+// owned by a single sampling goroutine.
+type ThrottleTracker struct {
+	prev     throttleCounters
+	prevTime time.Time
+
+	// overridden in tests
+	readCounters func() (throttleCounters, error)
+	now          func() time.Time
+}
+
+// NewThrottleTracker constructs a ThrottleTracker, taking an initial sample
+// of the throttling counters to serve as a baseline for the first call to
+// Update. It returns an error if the counters can't be read. (e.g.
+// ErrCGroupsNotSupported on non-linux platforms)
+func NewThrottleTracker() (*ThrottleTracker, error) {
+	return NewThrottleTrackerWithCache(nil)
+}
+
+// NewThrottleTrackerWithCache is NewThrottleTracker, but resolves the cpu
+// cgroup with cgr on each sample, rather than re-reading /proc/self/cgroup,
+// mountinfo and /proc/cgroups every time. (see GetCgroupCPUStatsWithCache)
+// A nil cgr resolves the cgroup afresh on each sample.
+func NewThrottleTrackerWithCache(cgr *cgresolver.Cache) (*ThrottleTracker, error) {
+	return newThrottleTracker(func() (throttleCounters, error) {
+		return cgroupThrottleCounters(context.Background(), cgr)
+	}, time.Now)
+}
+
+func newThrottleTracker(readCounters func() (throttleCounters, error), now func() time.Time) (*ThrottleTracker, error) {
+	t := ThrottleTracker{
+		readCounters: readCounters,
+		now:          now,
+	}
+	c, err := readCounters()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read initial throttling counters: %w", err)
+	}
+	t.prev = c
+	t.prevTime = now()
+	return &t, nil
+}
+
+// Update reads the current throttling counters, and returns the throttling
+// rate since the previous call to Update (or the construction of the
+// ThrottleTracker).
+// If reading the counters fails, the previous sample is retained, so the
+// next successful Update covers the entire interval.
+func (t *ThrottleTracker) Update() (ThrottleRate, error) {
+	c, err := t.readCounters()
+	if err != nil {
+		return ThrottleRate{}, fmt.Errorf("failed to read throttling counters: %w", err)
+	}
+	now := t.now()
+
+	base := t.prev
+	reset := c.TotalPeriods < base.TotalPeriods ||
+		c.ThrottledPeriods < base.ThrottledPeriods ||
+		c.ThrottledTime < base.ThrottledTime
+	if reset {
+		// The counters went backwards, so this is a new cgroup (with
+		// counters starting from zero).
+		base = throttleCounters{}
+	}
+	interval := now.Sub(t.prevTime)
+	t.prev = c
+	t.prevTime = now
+
+	r := ThrottleRate{
+		Interval:      interval,
+		ThrottledTime: c.ThrottledTime - base.ThrottledTime,
+		Reset:         reset,
+	}
+	if interval > 0 {
+		r.ThrottledPeriodsPerSecond = float64(c.ThrottledPeriods-base.ThrottledPeriods) / interval.Seconds()
+	}
+	return r, nil
+}
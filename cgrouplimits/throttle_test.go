@@ -0,0 +1,88 @@
+package cgrouplimits
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestThrottleTracker(t *testing.T) {
+	samples := []throttleCounters{
+		{TotalPeriods: 100, ThrottledPeriods: 10, ThrottledTime: time.Second},
+		{TotalPeriods: 120, ThrottledPeriods: 30, ThrottledTime: 3 * time.Second},
+		// counters reset (cgroup recreated)
+		{TotalPeriods: 5, ThrottledPeriods: 4, ThrottledTime: 200 * time.Millisecond},
+		{TotalPeriods: 25, ThrottledPeriods: 4, ThrottledTime: 200 * time.Millisecond},
+	}
+	readErr := errors.New("read failure")
+	failNext := false
+	sampleIdx := 0
+	readCounters := func() (throttleCounters, error) {
+		if failNext {
+			failNext = false
+			return throttleCounters{}, readErr
+		}
+		c := samples[sampleIdx]
+		sampleIdx++
+		return c, nil
+	}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := start
+	clock := func() time.Time { return now }
+
+	tt, err := newThrottleTracker(readCounters, clock)
+	if err != nil {
+		t.Fatalf("failed to construct tracker: %s", err)
+	}
+
+	now = now.Add(2 * time.Second)
+	r, err := tt.Update()
+	if err != nil {
+		t.Fatalf("failed to update: %s", err)
+	}
+	if exp := (ThrottleRate{Interval: 2 * time.Second, ThrottledPeriodsPerSecond: 10, ThrottledTime: 2 * time.Second}); r != exp {
+		t.Errorf("unexpected rate: %+v; expected %+v", r, exp)
+	}
+
+	// a failed read retains the previous sample
+	failNext = true
+	now = now.Add(time.Second)
+	if _, err := tt.Update(); !errors.Is(err, readErr) {
+		t.Errorf("unexpected error: %v; expected %v", err, readErr)
+	}
+
+	now = now.Add(time.Second)
+	r, err = tt.Update()
+	if err != nil {
+		t.Fatalf("failed to update: %s", err)
+	}
+	if exp := (ThrottleRate{Interval: 2 * time.Second, ThrottledPeriodsPerSecond: 2, ThrottledTime: 200 * time.Millisecond, Reset: true}); r != exp {
+		t.Errorf("unexpected rate after reset: %+v; expected %+v", r, exp)
+	}
+
+	now = now.Add(4 * time.Second)
+	r, err = tt.Update()
+	if err != nil {
+		t.Fatalf("failed to update: %s", err)
+	}
+	if exp := (ThrottleRate{Interval: 4 * time.Second}); r != exp {
+		t.Errorf("unexpected rate with no throttling: %+v; expected %+v", r, exp)
+	}
+}
+
+func TestNewThrottleTrackerSelf(t *testing.T) {
+	tt, err := NewThrottleTracker()
+	if errors.Is(err, ErrCGroupsNotSupported) {
+		t.Skip("unsupported platform")
+	}
+	if err != nil {
+		t.Fatalf("failed to construct tracker: %s", err)
+	}
+	r, err := tt.Update()
+	if err != nil {
+		t.Fatalf("failed to update: %s", err)
+	}
+	if r.ThrottledPeriodsPerSecond < 0 || r.ThrottledTime < 0 {
+		t.Errorf("unexpected negative throttling rate: %+v", r)
+	}
+}
@@ -0,0 +1,109 @@
+package procstats
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// CPUSampler periodically samples the CPU time consumed by a process, and
+// tracks its CPU utilization over the most recent interval.
+type CPUSampler struct {
+	pid      int
+	interval time.Duration
+
+	// overridden in tests
+	cpuTime func(pid int) (CPUTime, error)
+	now     func() time.Time
+
+	// done is closed when the sampling goroutine exits
+	done chan struct{}
+
+	mu       sync.Mutex
+	last     CPUTime
+	lastTime time.Time
+	util     float64
+	err      error
+}
+
+// NewCPUSampler constructs a CPUSampler for the process with PID pid, which
+// takes a sample every interval once started. interval must be positive.
+func NewCPUSampler(pid int, interval time.Duration) (*CPUSampler, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("invalid non-positive interval: %s", interval)
+	}
+	return &CPUSampler{
+		pid:      pid,
+		interval: interval,
+		cpuTime:  ProcessCPUTime,
+		now:      time.Now,
+		done:     make(chan struct{}),
+	}, nil
+}
+
+// Start starts a goroutine that takes an initial sample immediately, and
+// then samples every interval until ctx is cancelled.
+// Start must be called at most once.
+func (s *CPUSampler) Start(ctx context.Context) {
+	go s.run(ctx)
+}
+
+func (s *CPUSampler) run(ctx context.Context) {
+	defer close(s.done)
+	s.sample()
+
+	t := time.NewTicker(s.interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		s.sample()
+	}
+}
+
+func (s *CPUSampler) sample() {
+	ct, err := s.cpuTime(s.pid)
+	now := s.now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		s.err = fmt.Errorf("failed to sample CPU time for pid %d: %w", s.pid, err)
+		return
+	}
+	s.err = nil
+	if !s.lastTime.IsZero() {
+		s.util = ct.Utilization(&s.last, now.Sub(s.lastTime))
+	}
+	s.last, s.lastTime = ct, now
+}
+
+// Utilization returns the average number of cores used by the process
+// between the two most recent samples. (see CPUTime.Utilization)
+// It returns 0 until two samples have been taken.
+func (s *CPUSampler) Utilization() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.util
+}
+
+// Last returns the cumulative CPUTime from the most recent successful
+// sample.
+func (s *CPUSampler) Last() CPUTime {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.last
+}
+
+// Err returns the error from the most recent sample, or nil if it
+// succeeded. Utilization and Last continue to reflect the most recent
+// successful samples.
+func (s *CPUSampler) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
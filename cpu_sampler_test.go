@@ -0,0 +1,93 @@
+package procstats
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestCPUSamplerFake(t *testing.T) {
+	samples := []CPUTime{
+		{Utime: time.Second},
+		{Utime: 2 * time.Second, Stime: time.Second},
+	}
+	sampleErr := errors.New("sample failed")
+	start := time.Unix(1700000000, 0)
+	calls := 0
+
+	s, err := NewCPUSampler(1234, time.Hour)
+	if err != nil {
+		t.Fatalf("failed to construct sampler: %s", err)
+	}
+	s.cpuTime = func(pid int) (CPUTime, error) {
+		if pid != 1234 {
+			t.Errorf("unexpected pid: %d; expected 1234", pid)
+		}
+		calls++
+		if calls > len(samples) {
+			return CPUTime{}, sampleErr
+		}
+		return samples[calls-1], nil
+	}
+	s.now = func() time.Time { return start.Add(time.Duration(calls) * 4 * time.Second) }
+
+	s.sample()
+	if u := s.Utilization(); u != 0 {
+		t.Errorf("unexpected utilization after one sample: %g; expected 0", u)
+	}
+	s.sample()
+	// 2s of CPU time over 4s of wall-time
+	if u := s.Utilization(); u != 0.5 {
+		t.Errorf("unexpected utilization: %g; expected 0.5", u)
+	}
+	if l := s.Last(); l != samples[1] {
+		t.Errorf("unexpected last sample: %+v; expected %+v", l, samples[1])
+	}
+	s.sample()
+	if err := s.Err(); !errors.Is(err, sampleErr) {
+		t.Errorf("unexpected error: %v; expected %v", err, sampleErr)
+	}
+	if u := s.Utilization(); u != 0.5 {
+		t.Errorf("utilization changed after failed sample: %g; expected 0.5", u)
+	}
+}
+
+func TestCPUSamplerInvalidInterval(t *testing.T) {
+	for _, interval := range []time.Duration{0, -time.Second} {
+		if s, err := NewCPUSampler(1234, interval); err == nil {
+			t.Errorf("unexpectedly constructed sampler with interval %s: %+v", interval, s)
+		}
+	}
+}
+
+func TestCPUSamplerSelf(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	s, err := NewCPUSampler(os.Getpid(), 5*time.Millisecond)
+	if err != nil {
+		t.Fatalf("failed to construct sampler: %s", err)
+	}
+	s.Start(ctx)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for s.Last() == (CPUTime{}) && time.Now().Before(deadline) {
+		// burn a little CPU so there's something to observe
+		for i := 0; i < 1000000; i++ {
+		}
+	}
+	time.Sleep(20 * time.Millisecond)
+	if err := s.Err(); err != nil {
+		t.Fatalf("failed to sample: %s", err)
+	}
+	if u := s.Utilization(); u < 0 {
+		t.Errorf("unexpectedly negative utilization: %g", u)
+	}
+
+	cancel()
+	select {
+	case <-s.done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("sampler didn't stop after context cancellation")
+	}
+}
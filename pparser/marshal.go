@@ -0,0 +1,165 @@
+package pparser
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Marshal is the inverse of Parse: it renders in as lines of
+// key-value pairs, using each field's canonical key-name (the first name in
+// its pparser tag, or the field name). Fields are emitted in struct order,
+// followed by the entries of the UnknownFields map (if any), sorted by key.
+// Numeric values are rendered in decimal (or in the base specified by the
+// field's `base`/`hex` tag option), without any unit-suffix, so values parsed
+// with a multiplier (e.g. kB) are rendered in bytes. time.Duration fields are
+// rendered in the field's unit. Slice elements are separated by spaces, and
+// array elements by commas. Nil pointer fields are omitted.
+// The output of Marshal may be parsed by Parse to obtain an equal value; values
+// that Parse couldn't recover exactly are rejected with an error. These are
+// durations that aren't a whole number of the field's unit, strings with
+// leading or trailing whitespace or embedded newlines, string elements of
+// slices or arrays that are empty or contain their separator, and
+// UnknownFields keys that are empty, contain whitespace or the split-key, or
+// would be parsed into a named field.
+func (p *LineKVFileParser[T]) Marshal(in *T) ([]byte, error) {
+	inVal := reflect.ValueOf(in).Elem()
+
+	sep := p.splitKey
+	if strings.TrimSpace(sep) != "" {
+		sep += " "
+	}
+
+	buf := bytes.Buffer{}
+	for i := 0; i < p.structType.NumField(); i++ {
+		opts := p.fieldOpts[i]
+		if opts.name == "" {
+			continue
+		}
+		fv := inVal.Field(i)
+		if fv.Kind() == reflect.Pointer {
+			// nil pointers indicate absent keys
+			if fv.IsNil() {
+				continue
+			}
+			fv = fv.Elem()
+		}
+		val, fmtErr := formatValue(fv, opts)
+		if fmtErr != nil {
+			return nil, fmt.Errorf("failed to format field %q: %w", opts.name, fmtErr)
+		}
+		buf.WriteString(opts.name + sep + val + "\n")
+	}
+
+	if p.unknownFieldsIdx == -1 {
+		return buf.Bytes(), nil
+	}
+	unknownFields := inVal.Field(p.unknownFieldsIdx)
+	keys := make([]string, 0, unknownFields.Len())
+	for _, k := range unknownFields.MapKeys() {
+		keys = append(keys, k.String())
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if keyErr := p.checkUnknownKey(k); keyErr != nil {
+			return nil, fmt.Errorf("invalid unknown field key %q: %w", k, keyErr)
+		}
+		val, fmtErr := formatValue(
+			unknownFields.MapIndex(reflect.ValueOf(k)), fieldTagOpts{base: 10})
+		if fmtErr != nil {
+			return nil, fmt.Errorf("failed to format unknown field %q: %w", k, fmtErr)
+		}
+		buf.WriteString(k + sep + val + "\n")
+	}
+
+	return buf.Bytes(), nil
+}
+
+// checkUnknownKey verifies that Parse would populate the UnknownFields entry
+// for k from a line written with k as its key.
+func (p *LineKVFileParser[T]) checkUnknownKey(k string) error {
+	if k == "" {
+		return fmt.Errorf("empty key")
+	}
+	// Parse splits at the first split-key, and lines at newlines
+	// (which are whitespace)
+	if strings.ContainsFunc(k, unicode.IsSpace) || strings.Contains(k, p.splitKey) {
+		return fmt.Errorf("key contains whitespace or the split-key %q", p.splitKey)
+	}
+	parsedKey := k
+	if p.opts.keyRE != nil {
+		parsedKey = p.opts.keyRE.ReplaceAllString(k, p.opts.keyRepl)
+		if parsedKey != k {
+			return fmt.Errorf("key would be rewritten to %q", parsedKey)
+		}
+	}
+	if _, known := p.idx[parsedKey]; known {
+		return fmt.Errorf("key collides with a named field")
+	}
+	return nil
+}
+
+// formatValue renders a single field's value, according to its kind and tag
+// options.
+func formatValue(v reflect.Value, opts fieldTagOpts) (string, error) {
+	if v.Type() == durationType && opts.unit != 0 {
+		d := time.Duration(v.Int())
+		if d%opts.unit != 0 {
+			return "", fmt.Errorf("duration %s is not a whole number of %s", d, opts.unit)
+		}
+		return strconv.FormatInt(int64(d/opts.unit), 10), nil
+	}
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		sep, isSep := " ", unicode.IsSpace
+		if v.Kind() == reflect.Array {
+			sep, isSep = ",", func(r rune) bool { return r == ',' || unicode.IsSpace(r) }
+		}
+		elems := make([]string, v.Len())
+		for i := range elems {
+			elem, elemErr := formatElem(v.Index(i), opts.base)
+			if elemErr != nil {
+				return "", fmt.Errorf("element %d: %w", i, elemErr)
+			}
+			// Parse splits string elements at separators (dropping
+			// empty ones), so they must be non-empty tokens.
+			if v.Index(i).Kind() == reflect.String && (elem == "" || strings.ContainsFunc(elem, isSep)) {
+				return "", fmt.Errorf("element %d: string %q is empty or contains a separator", i, elem)
+			}
+			elems[i] = elem
+		}
+		return strings.Join(elems, sep), nil
+	case reflect.String:
+		// Parse trims the value, and stops at the end of the line
+		if str := v.String(); str != strings.TrimSpace(str) || strings.Contains(str, "\n") {
+			return "", fmt.Errorf("string %q has leading or trailing whitespace or a newline", str)
+		}
+		return v.String(), nil
+	default:
+		return formatElem(v, opts.base)
+	}
+}
+
+// formatElem renders a scalar value (or an element of a slice or array),
+// with integers in the specified base.
+func formatElem(v reflect.Value, base int) (string, error) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), base), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), base), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits()), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.String:
+		return v.String(), nil
+	default:
+		return "", fmt.Errorf("unhandled field kind: %s", v.Kind())
+	}
+}
@@ -0,0 +1,134 @@
+package pparser
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMarshal(t *testing.T) {
+	type testStruct struct {
+		Name          string
+		Size          int64
+		Ratio         float64
+		Enabled       bool
+		Mask          uint64           `pparser:"SigCgt,hex"`
+		Wait          time.Duration    `pparser:"wait_usec,unit=usec"`
+		IDs           []uint32         `pparser:"Uid"`
+		Nodes         [2]uint32        `pparser:"Mems_allowed,hex,mems"`
+		Ignored       string           `pparser:"skip"`
+		UnknownFields map[string]int64 `pparser:"skip,unknown"`
+	}
+	p := NewLineKVFileParser(testStruct{}, ":")
+
+	in := testStruct{
+		Name:          "vim",
+		Size:          4096,
+		Ratio:         0.25,
+		Enabled:       true,
+		Mask:          0x1ef824eff,
+		Wait:          1500 * time.Microsecond,
+		IDs:           []uint32{1000, 1001},
+		Nodes:         [2]uint32{0, 0xff},
+		Ignored:       "not emitted",
+		UnknownFields: map[string]int64{"Zeta": 2, "Alpha": 1},
+	}
+	out, err := p.Marshal(&in)
+	if err != nil {
+		t.Fatalf("failed to marshal: %s", err)
+	}
+	const expOut = "Name: vim\n" +
+		"Size: 4096\n" +
+		"Ratio: 0.25\n" +
+		"Enabled: true\n" +
+		"SigCgt: 1ef824eff\n" +
+		"wait_usec: 1500\n" +
+		"Uid: 1000 1001\n" +
+		"Mems_allowed: 0,ff\n" +
+		"Alpha: 1\n" +
+		"Zeta: 2\n"
+	if string(out) != expOut {
+		t.Errorf("unexpected output:\n  got %q\n want %q", out, expOut)
+	}
+
+	roundTrip := testStruct{}
+	if err := p.Parse(out, &roundTrip); err != nil {
+		t.Fatalf("failed to parse marshaled output: %s", err)
+	}
+	in.Ignored = ""
+	if !reflect.DeepEqual(in, roundTrip) {
+		t.Errorf("round-trip mismatch:\n  got %+v\n want %+v", roundTrip, in)
+	}
+}
+
+func TestMarshalWhitespaceSplitKey(t *testing.T) {
+	type memStat struct {
+		Anon          int64            `pparser:"anon,rss"`
+		UnknownFields map[string]int64 `pparser:"skip,unknown"`
+	}
+	p := NewLineKVFileParser(memStat{}, " ")
+	in := memStat{}
+	if err := p.Parse([]byte("rss 4096\nfile 8192\n"), &in); err != nil {
+		t.Fatalf("failed to parse: %s", err)
+	}
+	out, err := p.Marshal(&in)
+	if err != nil {
+		t.Fatalf("failed to marshal: %s", err)
+	}
+	const expOut = "anon 4096\nfile 8192\n"
+	if string(out) != expOut {
+		t.Errorf("unexpected output:\n  got %q\n want %q", out, expOut)
+	}
+}
+
+func TestMarshalUnrepresentable(t *testing.T) {
+	type testStruct struct {
+		Name          string            `pparser:"Name,Command"`
+		Wait          time.Duration     `pparser:"wait_msec,unit=ms"`
+		Tags          []string          `pparser:"Tags"`
+		Pairs         [2]string         `pparser:"Pairs"`
+		UnknownFields map[string]string `pparser:"skip,unknown"`
+	}
+	p := NewLineKVFileParser(testStruct{}, ":")
+	valid := testStruct{
+		Name:  "vim",
+		Wait:  3 * time.Millisecond,
+		Tags:  []string{"a", "b"},
+		Pairs: [2]string{"x", "y"},
+
+		UnknownFields: map[string]string{"Extra": "z"},
+	}
+	if _, err := p.Marshal(&valid); err != nil {
+		t.Fatalf("failed to marshal representable value: %s", err)
+	}
+
+	for _, tbl := range []struct {
+		name   string
+		mutate func(*testStruct)
+	}{
+		// integer division by the unit would truncate to 1ms
+		{name: "fractional_duration", mutate: func(s *testStruct) { s.Wait = 1500 * time.Microsecond }},
+		// would be re-split into "a", "b" and "c"
+		{name: "slice_elem_whitespace", mutate: func(s *testStruct) { s.Tags = []string{"a", "b c"} }},
+		// would be dropped when re-split
+		{name: "slice_elem_empty", mutate: func(s *testStruct) { s.Tags = []string{"a", ""} }},
+		{name: "array_elem_comma", mutate: func(s *testStruct) { s.Pairs = [2]string{"x,z", "y"} }},
+		{name: "string_trailing_space", mutate: func(s *testStruct) { s.Name = "vim " }},
+		{name: "string_newline", mutate: func(s *testStruct) { s.Name = "vim\nTags: x" }},
+		// would be split at the first ":", as "a" with value "b: z"
+		{name: "unknown_key_split_key", mutate: func(s *testStruct) { s.UnknownFields = map[string]string{"a:b": "z"} }},
+		{name: "unknown_key_whitespace", mutate: func(s *testStruct) { s.UnknownFields = map[string]string{"a b": "z"} }},
+		{name: "unknown_key_empty", mutate: func(s *testStruct) { s.UnknownFields = map[string]string{"": "z"} }},
+		// would populate Name rather than UnknownFields
+		{name: "unknown_key_field_name", mutate: func(s *testStruct) { s.UnknownFields = map[string]string{"Name": "z"} }},
+		{name: "unknown_key_field_alias", mutate: func(s *testStruct) { s.UnknownFields = map[string]string{"Command": "z"} }},
+	} {
+		t.Run(tbl.name, func(t *testing.T) {
+			in := valid
+			tbl.mutate(&in)
+			if out, err := p.Marshal(&in); err == nil {
+				t.Errorf("unexpectedly marshaled unrepresentable value as %q", out)
+			}
+		})
+	}
+}
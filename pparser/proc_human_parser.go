@@ -4,14 +4,22 @@
 package pparser
 
 import (
-	"bytes"
+	"bufio"
+	"errors"
 	"fmt"
 	"io"
 	"reflect"
+	"regexp"
 	"strconv"
 	"strings"
+	"time"
+	"unicode"
 )
 
+// ErrLineTooLong indicates that a line exceeded the limit configured with
+// WithMaxLineLength.
+var ErrLineTooLong = errors.New("line exceeds maximum length")
+
 // NoUnknownFieldsFieldErr indicates that a field name didn't match the value
 // in the struct.
 type NoUnknownFieldsFieldErr struct {
@@ -24,9 +32,100 @@ func (n NoUnknownFieldsFieldErr) Error() string {
 		n.fieldName, n.value)
 }
 
-// fieldIndex generates an index of field index to field-name, and the offset
-// of the unknown fields field if present.
-func fieldIndex(t interface{}) (map[string]int, int, reflect.Kind) {
+// durationUnits maps the values accepted by the `unit` tag option to the
+// corresponding time.Duration multiplier.
+var durationUnits = map[string]time.Duration{
+	"ns":   time.Nanosecond,
+	"us":   time.Microsecond,
+	"usec": time.Microsecond,
+	"µs":   time.Microsecond,
+	"ms":   time.Millisecond,
+	"s":    time.Second,
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// fieldTagOpts contains the options parsed from the pparser struct tag for
+// a field.
+type fieldTagOpts struct {
+	// name is the canonical key-name for the field (empty for fields
+	// without one, such as skipped fields)
+	name string
+	// unit is the multiplier for time.Duration fields (nanoseconds if
+	// unspecified); zero for all other fields
+	unit time.Duration
+	// base is the numeric base for integer fields, and the integer elements
+	// of slice and fixed-size array fields (10 if unspecified)
+	base int
+	// aliases are additional key-names that populate the field
+	aliases []string
+}
+
+// parseFieldTag splits a pparser struct tag into the key-name and any
+// options. (options are of the form `key=value` or bare flags like `hex`,
+// following the name; any other bare words are aliases for the key-name)
+func parseFieldTag(field reflect.StructField, tag string) (string, fieldTagOpts) {
+	name, optsStr, _ := strings.Cut(tag, ",")
+	opts := fieldTagOpts{base: 10}
+	// pointer fields are populated according to their element type
+	ftype := derefType(field.Type)
+	if ftype == durationType {
+		opts.unit = time.Nanosecond
+	}
+	if optsStr == "" {
+		return name, opts
+	}
+	for _, opt := range strings.Split(optsStr, ",") {
+		k, v, _ := strings.Cut(opt, "=")
+		switch k {
+		case "unit":
+			if ftype != durationType {
+				panic(fmt.Sprintf("unit option on field %q of non-time.Duration type %s",
+					field.Name, field.Type))
+			}
+			unit, ok := durationUnits[v]
+			if !ok {
+				panic(fmt.Sprintf("unknown unit %q on field %q", v, field.Name))
+			}
+			opts.unit = unit
+		case "base", "hex":
+			switch ftype.Kind() {
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+				reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+				reflect.Slice, reflect.Array:
+			default:
+				panic(fmt.Sprintf("%s option on field %q of non-integer type %s",
+					k, field.Name, field.Type))
+			}
+			if k == "hex" {
+				if v != "" {
+					panic(fmt.Sprintf("hex option on field %q takes no value", field.Name))
+				}
+				opts.base = 16
+				continue
+			}
+			base, baseErr := strconv.Atoi(v)
+			if baseErr != nil || base < 2 || base > 36 {
+				panic(fmt.Sprintf("invalid base %q on field %q", v, field.Name))
+			}
+			opts.base = base
+		default:
+			if strings.Contains(opt, "=") {
+				panic(fmt.Sprintf("unknown pparser tag option %q on field %q", opt, field.Name))
+			}
+			if opt == "" {
+				panic(fmt.Sprintf("empty alias in pparser tag on field %q", field.Name))
+			}
+			opts.aliases = append(opts.aliases, opt)
+		}
+	}
+	return name, opts
+}
+
+// fieldIndex generates an index of field index to field-name, the offset
+// of the unknown fields field if present, and the tag options for each
+// field (indexed by field-index).
+func fieldIndex(t interface{}) (map[string]int, int, reflect.Kind, []fieldTagOpts) {
 
 	fieldIndex := map[string]int{}
 	unknownIdx := -1
@@ -37,6 +136,7 @@ func fieldIndex(t interface{}) (map[string]int, int, reflect.Kind) {
 		panic(fmt.Sprintf("concrete type must be passed to NewLineKVFileParser, got %s",
 			objType))
 	}
+	fieldOpts := make([]fieldTagOpts, objType.NumField())
 	for i := 0; i < objType.NumField(); i++ {
 
 		field := objType.Field(i)
@@ -57,14 +157,67 @@ func fieldIndex(t interface{}) (map[string]int, int, reflect.Kind) {
 			if limitsTag == "skip" {
 				continue
 			}
-			fieldIndex[limitsTag] = i
+			name, opts := parseFieldTag(field, limitsTag)
+			opts.name = name
+			fieldIndex[name] = i
+			for _, alias := range opts.aliases {
+				fieldIndex[alias] = i
+			}
+			fieldOpts[i] = opts
 		} else {
+			_, opts := parseFieldTag(field, "")
+			opts.name = field.Name
 			fieldIndex[field.Name] = i
+			fieldOpts[i] = opts
 		}
 	}
 
-	return fieldIndex, unknownIdx, unknownKind
+	return fieldIndex, unknownIdx, unknownKind, fieldOpts
+
+}
+
+// ParserOption configures optional behavior of a LineKVFileParser.
+type ParserOption func(*parserOptions)
+
+type parserOptions struct {
+	maxLineLen          int
+	ignoreUnknownFields bool
+	keyRE               *regexp.Regexp
+	keyRepl             string
+}
+
+// WithMaxLineLength bounds the length of any single line (excluding the
+// trailing newline) to n bytes. Parsing fails with an error wrapping
+// ErrLineTooLong if a line exceeds that limit, rather than buffering an
+// arbitrarily-long line from a malformed (or non-proc) file.
+// A non-positive n leaves line-length unbounded, which is the default.
+func WithMaxLineLength(n int) ParserOption {
+	return func(o *parserOptions) {
+		o.maxLineLen = n
+	}
+}
+
+// IgnoreUnknownFields drops any keys that don't match a field in the struct,
+// when the struct has no `pparser:"skip,unknown"` map to collect them.
+// By default, such keys result in a NoUnknownFieldsFieldErr.
+func IgnoreUnknownFields() ParserOption {
+	return func(o *parserOptions) {
+		o.ignoreUnknownFields = true
+	}
+}
 
+// WithKeyNormalization rewrites each key before looking up the corresponding
+// field, replacing matches of re with repl (expanded as with
+// regexp.Regexp.ReplaceAllString). Keys in the UnknownFields map are
+// normalized as well.
+// e.g. WithKeyNormalization(regexp.MustCompile(`\((\w+)\)`), "_$1") maps
+// the /proc/meminfo key "Active(anon)" to a field tagged "Active_anon".
+// By default, keys are looked up exactly as they appear in the file.
+func WithKeyNormalization(re *regexp.Regexp, repl string) ParserOption {
+	return func(o *parserOptions) {
+		o.keyRE = re
+		o.keyRepl = repl
+	}
 }
 
 // NewLineKVFileParser constructs a new LineKVFileParser instance for the type
@@ -73,21 +226,58 @@ func fieldIndex(t interface{}) (map[string]int, int, reflect.Kind) {
 // tag.
 // Fields with the `pparser:"skip"` tag will be ignored. Any other value for
 // the pparser field tag is interpreted as a preferred name for that field's key
-// in the file.
+// in the file, optionally followed by comma-separated options and aliases.
+// Aliases are alternate key-names (e.g. from other kernel versions) that
+// populate the same field. e.g. `pparser:"anon,rss"`.
+// time.Duration fields are parsed as integers in the unit specified by the
+// `unit` option (one of ns, us, usec, µs, ms or s; nanoseconds if
+// unspecified). e.g. `pparser:"throttled_usec,unit=usec"`.
+// Slice fields are populated from whitespace-separated values, and
+// fixed-size array fields from comma (or whitespace) separated values, which
+// must match the array's length. Numeric elements of either may each carry a
+// unit-suffix.
+// Integer fields (and the integer elements of slice and array fields) are
+// parsed in the base given by the `base` option (10 if unspecified); `hex` is
+// shorthand for `base=16`.
+// e.g. `pparser:"SigBlk,hex"` for a uint64 field or
+// `pparser:"Mems_allowed,base=16"` for a [32]uint32 field.
+// Pointer fields (e.g. *int64) are populated according to their element type,
+// and are only allocated if their key is present, so a nil pointer
+// distinguishes an absent key from a zero value.
 // LineKVFileParser instances returned by NewLineKVFileParser contain an
 // embedded index to make parsing a bit less inefficient. The `t` argument must
 // be of the concrete struct-type, not a pointer to that type.
+// Behavior may be further customized by passing ParserOptions.
 // Note: this is intended to be called once at startup for a type (usually
 // within an `init()` func or as a package-level variable declaration).
-func NewLineKVFileParser[T any](t T, splitKey string) *LineKVFileParser[T] {
-	idx, unknownIdx, unknownKind := fieldIndex(t)
+func NewLineKVFileParser[T any](t T, splitKey string, opts ...ParserOption) *LineKVFileParser[T] {
+	idx, unknownIdx, unknownKind, fieldOpts := fieldIndex(t)
+
+	o := parserOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	// Resolve the (dereferenced) type and kind of each field once up front,
+	// rather than going through reflect.Type.Field() for every line parsed.
+	structType := reflect.TypeOf(t)
+	fieldTypes := make([]reflect.Type, structType.NumField())
+	fieldKinds := make([]reflect.Kind, structType.NumField())
+	for i := range fieldTypes {
+		fieldTypes[i] = derefType(structType.Field(i).Type)
+		fieldKinds[i] = fieldTypes[i].Kind()
+	}
 
 	return &LineKVFileParser[T]{
 		idx:              idx,
 		splitKey:         splitKey,
 		unknownFieldsIdx: unknownIdx,
 		unknownKind:      unknownKind,
-		structType:       reflect.TypeOf(t),
+		structType:       structType,
+		fieldTypes:       fieldTypes,
+		fieldKinds:       fieldKinds,
+		fieldOpts:        fieldOpts,
+		opts:             o,
 	}
 
 }
@@ -100,21 +290,141 @@ type LineKVFileParser[T any] struct {
 	unknownFieldsIdx int
 	unknownKind      reflect.Kind
 	structType       reflect.Type
+	fieldTypes       []reflect.Type
+	fieldKinds       []reflect.Kind
+	fieldOpts        []fieldTagOpts
+	opts             parserOptions
+}
+
+// Fields returns a mapping from each key-name recognized by the parser
+// (including any aliases) to the struct field it populates. The returned map
+// is a copy, and may be freely modified by the caller.
+// Skipped fields and the UnknownFields map are not included.
+func (p *LineKVFileParser[T]) Fields() map[string]reflect.StructField {
+	out := make(map[string]reflect.StructField, len(p.idx))
+	for name, fieldIdx := range p.idx {
+		out[name] = p.structType.Field(fieldIdx)
+	}
+	return out
+}
+
+// readLine reads the next line (including the trailing newline, if present)
+// from r, enforcing the maximum line-length (if any) as the line is
+// accumulated, so an over-long line is never buffered in its entirety.
+func (p *LineKVFileParser[T]) readLine(r *bufio.Reader) (string, error) {
+	if p.opts.maxLineLen <= 0 {
+		return r.ReadString('\n')
+	}
+	var line []byte
+	for {
+		frag, err := r.ReadSlice('\n')
+		line = append(line, frag...)
+		lineLen := len(line)
+		if lineLen > 0 && line[lineLen-1] == '\n' {
+			lineLen--
+		}
+		if lineLen > p.opts.maxLineLen {
+			return "", p.lineTooLongErr()
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		return string(line), err
+	}
+}
+
+// nextLine splits the next line (including the trailing newline, if present)
+// off of contents, returning it and the remaining contents, enforcing the
+// maximum line-length (if any).
+// Lines are substrings of contents, so splitting doesn't allocate.
+func (p *LineKVFileParser[T]) nextLine(contents string) (string, string, error) {
+	line, rest := contents, ""
+	if nl := strings.IndexByte(contents, '\n'); nl >= 0 {
+		line, rest = contents[:nl+1], contents[nl+1:]
+	}
+	if p.opts.maxLineLen > 0 && len(strings.TrimSuffix(line, "\n")) > p.opts.maxLineLen {
+		return "", "", p.lineTooLongErr()
+	}
+	return line, rest, nil
+}
+
+func (p *LineKVFileParser[T]) lineTooLongErr() error {
+	return fmt.Errorf("%w: line exceeds limit of %d bytes",
+		ErrLineTooLong, p.opts.maxLineLen)
+}
+
+// unitMultipliers maps the unit-suffixes recognized on numeric values to
+// their multipliers.
+// Note: the kernel uses "kB" for kibibytes (e.g. in /proc/meminfo), so kB is
+// 1024-based for compatibility, while MB and GB are 1000-based.
+var unitMultipliers = []struct {
+	suffix string
+	mul    int64
+}{
+	{suffix: "kB", mul: 1 << 10},
+	{suffix: "MB", mul: 1000 * 1000},
+	{suffix: "GB", mul: 1000 * 1000 * 1000},
+	{suffix: "KiB", mul: 1 << 10},
+	{suffix: "MiB", mul: 1 << 20},
+	{suffix: "GiB", mul: 1 << 30},
 }
 
 func trimStringWithMultiplier(s string) (string, int64) {
-	if strings.HasSuffix(s, "kB") {
-		return strings.TrimSpace(strings.TrimSuffix(s, "kB")), 1024
+	for _, um := range unitMultipliers {
+		if strings.HasSuffix(s, um.suffix) {
+			return strings.TrimSpace(strings.TrimSuffix(s, um.suffix)), um.mul
+		}
 	}
 	return s, 1
 }
 
+// isUnitSuffix returns true if tok is one of the unit-suffixes in
+// unitMultipliers.
+func isUnitSuffix(tok string) bool {
+	for _, um := range unitMultipliers {
+		if tok == um.suffix {
+			return true
+		}
+	}
+	return false
+}
+
 func (p *LineKVFileParser[T]) fieldKind(fieldName string) reflect.Kind {
 	fieldIndex, knownField := p.idx[fieldName]
 	if !knownField {
 		return p.unknownKind
 	}
-	return p.structType.Field(fieldIndex).Type.Kind()
+	return p.fieldKinds[fieldIndex]
+}
+
+// derefType returns the element type of t if it's a pointer type, and t
+// otherwise.
+func derefType(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Pointer {
+		return t.Elem()
+	}
+	return t
+}
+
+// fieldType returns the type of the value populated for the field at
+// fieldIndex. (the element type for pointer fields)
+func (p *LineKVFileParser[T]) fieldType(fieldIndex int) reflect.Type {
+	return p.fieldTypes[fieldIndex]
+}
+
+// fieldTarget returns the settable value for the field at fieldIndex. For
+// pointer fields, this allocates a new value (if nil) and returns the
+// pointed-to value, so pointer fields are only non-nil if their key is
+// present.
+func (p *LineKVFileParser[T]) fieldTarget(outVal *reflect.Value, fieldIndex int) reflect.Value {
+	f := outVal.Field(fieldIndex)
+	if f.Kind() != reflect.Pointer {
+		return f
+	}
+	if f.IsNil() {
+		f.Set(reflect.New(f.Type().Elem()))
+	}
+	return f.Elem()
 }
 
 func (p *LineKVFileParser[T]) setIntField(
@@ -142,14 +452,15 @@ func (p *LineKVFileParser[T]) setIntField(
 
 		return nil
 	}
-	f = outVal.Field(fieldIndex)
+	// check for overflow before allocating any pointer field
+	f = reflect.Zero(p.fieldType(fieldIndex))
 	if f.OverflowInt(fieldValue) {
 		return fmt.Errorf(
 			"unable to populate field %q due to"+
 				" overflow %d not representable by type %s",
 			fieldName, fieldValue, f.Type().Kind())
 	}
-	f.SetInt(fieldValue)
+	p.fieldTarget(outVal, fieldIndex).SetInt(fieldValue)
 
 	return nil
 }
@@ -179,14 +490,15 @@ func (p *LineKVFileParser[T]) setUintField(
 
 		return nil
 	}
-	f = outVal.Field(fieldIndex)
+	// check for overflow before allocating any pointer field
+	f = reflect.Zero(p.fieldType(fieldIndex))
 	if f.OverflowUint(fieldValue) {
 		return fmt.Errorf(
 			"unable to populate field %q due to"+
 				" overflow %d not representable by type %s",
 			fieldName, fieldValue, f.Type().Kind())
 	}
-	f.SetUint(fieldValue)
+	p.fieldTarget(outVal, fieldIndex).SetUint(fieldValue)
 
 	return nil
 }
@@ -216,17 +528,233 @@ func (p *LineKVFileParser[T]) setFloatField(
 
 		return nil
 	}
-	f = outVal.Field(fieldIndex)
+	// check for overflow before allocating any pointer field
+	f = reflect.Zero(p.fieldType(fieldIndex))
 	if f.OverflowFloat(fieldValue) {
 		return fmt.Errorf(
 			"unable to populate field %q due to"+
 				" overflow %g not representable by type %s",
 			fieldName, fieldValue, f.Type().Kind())
 	}
-	f.SetFloat(fieldValue)
+	p.fieldTarget(outVal, fieldIndex).SetFloat(fieldValue)
 
 	return nil
 }
+
+// fieldBase returns the numeric base for the named field (10 for unknown
+// fields).
+func (p *LineKVFileParser[T]) fieldBase(fieldName string) int {
+	fieldIndex, knownField := p.idx[fieldName]
+	if !knownField {
+		return 10
+	}
+	return p.fieldOpts[fieldIndex].base
+}
+
+// durationFieldUnit returns the unit for the named field if it's a known
+// time.Duration field, and zero otherwise.
+func (p *LineKVFileParser[T]) durationFieldUnit(fieldName string) time.Duration {
+	fieldIndex, knownField := p.idx[fieldName]
+	if !knownField {
+		return 0
+	}
+	return p.fieldOpts[fieldIndex].unit
+}
+
+func (p *LineKVFileParser[T]) setDurationField(
+	outVal *reflect.Value, fieldName string, fieldValue int64, unit time.Duration) error {
+	d := time.Duration(fieldValue) * unit
+	if fieldValue != 0 && d/unit != time.Duration(fieldValue) {
+		return fmt.Errorf(
+			"unable to populate field %q due to"+
+				" overflow %d%s not representable by time.Duration",
+			fieldName, fieldValue, unit)
+	}
+	p.fieldTarget(outVal, p.idx[fieldName]).SetInt(int64(d))
+
+	return nil
+}
+
+func (p *LineKVFileParser[T]) setBoolField(
+	outVal *reflect.Value, fieldName string, fieldValue bool) error {
+	fieldIndex, knownField := p.idx[fieldName]
+	var f reflect.Value
+	if !knownField {
+		if p.unknownFieldsIdx == -1 {
+			panic("invariant failure: bool-specific " +
+				"function called with no field to handle it")
+		}
+		unknownFields := outVal.Field(p.unknownFieldsIdx)
+		if unknownFields.IsNil() {
+			unknownFields.Set(reflect.MakeMap(unknownFields.Type()))
+		}
+		insVal := reflect.New(unknownFields.Type().Elem()).Elem()
+		insVal.SetBool(fieldValue)
+		unknownFields.SetMapIndex(reflect.ValueOf(fieldName), insVal)
+
+		return nil
+	}
+	f = p.fieldTarget(outVal, fieldIndex)
+	f.SetBool(fieldValue)
+
+	return nil
+}
+
+// splitElems splits a slice or array field's value into its element tokens
+// at any rune for which isSep returns true. For numeric element kinds, a
+// standalone unit-suffix token (e.g. "kB") is folded into the preceding
+// element, so each element may carry its own multiplier suffix.
+// (e.g. "4 kB 8 MiB")
+func splitElems(s string, elemKind reflect.Kind, isSep func(rune) bool) []string {
+	toks := strings.FieldsFunc(s, isSep)
+	switch elemKind {
+	case reflect.String, reflect.Bool:
+		return toks
+	}
+	out := toks[:0]
+	for _, tok := range toks {
+		if isUnitSuffix(tok) && len(out) > 0 {
+			out[len(out)-1] += tok
+			continue
+		}
+		out = append(out, tok)
+	}
+	return out
+}
+
+// parseSliceElem parses a single token into v (an element of a slice or
+// array), according to v's kind. Integer elements are parsed in the
+// specified base. Numeric elements with a unit-suffix (e.g. "kB") are scaled
+// accordingly.
+func parseSliceElem(v reflect.Value, tok string, base int) error {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		trimmed, mul := trimStringWithMultiplier(tok)
+		val, err := strconv.ParseInt(trimmed, base, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		if (val*mul)/mul != val || v.OverflowInt(val*mul) {
+			return fmt.Errorf("overflow: %s not representable by type %s",
+				tok, v.Type())
+		}
+		v.SetInt(val * mul)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		trimmed, mul := trimStringWithMultiplier(tok)
+		val, err := strconv.ParseUint(trimmed, base, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		if (val*uint64(mul))/uint64(mul) != val || v.OverflowUint(val*uint64(mul)) {
+			return fmt.Errorf("overflow: %s not representable by type %s",
+				tok, v.Type())
+		}
+		v.SetUint(val * uint64(mul))
+	case reflect.Float32, reflect.Float64:
+		trimmed, mul := trimStringWithMultiplier(tok)
+		val, err := strconv.ParseFloat(trimmed, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(val * float64(mul))
+	case reflect.Bool:
+		val, err := strconv.ParseBool(tok)
+		if err != nil {
+			return err
+		}
+		v.SetBool(val)
+	case reflect.String:
+		v.SetString(tok)
+	default:
+		return fmt.Errorf("unhandled element kind: %s", v.Kind())
+	}
+	return nil
+}
+
+// setSliceField splits fieldValue on whitespace, and populates the named
+// slice field with the parsed elements. An empty value results in an empty
+// (non-nil) slice.
+func (p *LineKVFileParser[T]) setSliceField(
+	outVal *reflect.Value, fieldName, fieldValue string) error {
+	fieldIndex, knownField := p.idx[fieldName]
+	var sliceType reflect.Type
+	if knownField {
+		sliceType = p.fieldType(fieldIndex)
+	} else {
+		if p.unknownFieldsIdx == -1 {
+			panic("invariant failure: slice-specific " +
+				"function called with no field to handle it")
+		}
+		sliceType = p.structType.Field(p.unknownFieldsIdx).Type.Elem()
+	}
+
+	toks := splitElems(fieldValue, sliceType.Elem().Kind(), unicode.IsSpace)
+	sl := reflect.MakeSlice(sliceType, len(toks), len(toks))
+	for i, tok := range toks {
+		if elemErr := parseSliceElem(sl.Index(i), tok, p.fieldBase(fieldName)); elemErr != nil {
+			return fmt.Errorf("field %q: element %d (%q): %w",
+				fieldName, i, tok, elemErr)
+		}
+	}
+
+	if !knownField {
+		unknownFields := outVal.Field(p.unknownFieldsIdx)
+		if unknownFields.IsNil() {
+			unknownFields.Set(reflect.MakeMap(unknownFields.Type()))
+		}
+		unknownFields.SetMapIndex(reflect.ValueOf(fieldName), sl)
+		return nil
+	}
+	p.fieldTarget(outVal, fieldIndex).Set(sl)
+
+	return nil
+}
+
+// setArrayField splits fieldValue on commas (and/or whitespace), and
+// populates the named fixed-size array field with the parsed elements. The
+// number of elements must match the array's length exactly.
+func (p *LineKVFileParser[T]) setArrayField(
+	outVal *reflect.Value, fieldName, fieldValue string) error {
+	fieldIndex, knownField := p.idx[fieldName]
+	var arrType reflect.Type
+	if knownField {
+		arrType = p.fieldType(fieldIndex)
+	} else {
+		if p.unknownFieldsIdx == -1 {
+			panic("invariant failure: array-specific " +
+				"function called with no field to handle it")
+		}
+		arrType = p.structType.Field(p.unknownFieldsIdx).Type.Elem()
+	}
+
+	toks := splitElems(fieldValue, arrType.Elem().Kind(), func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	})
+	if len(toks) != arrType.Len() {
+		return fmt.Errorf("field %q: found %d elements; expected %d",
+			fieldName, len(toks), arrType.Len())
+	}
+	arr := reflect.New(arrType).Elem()
+	for i, tok := range toks {
+		if elemErr := parseSliceElem(arr.Index(i), tok, p.fieldBase(fieldName)); elemErr != nil {
+			return fmt.Errorf("field %q: element %d (%q): %w",
+				fieldName, i, tok, elemErr)
+		}
+	}
+
+	if !knownField {
+		unknownFields := outVal.Field(p.unknownFieldsIdx)
+		if unknownFields.IsNil() {
+			unknownFields.Set(reflect.MakeMap(unknownFields.Type()))
+		}
+		unknownFields.SetMapIndex(reflect.ValueOf(fieldName), arr)
+		return nil
+	}
+	p.fieldTarget(outVal, fieldIndex).Set(arr)
+
+	return nil
+}
+
 func (p *LineKVFileParser[T]) setStringField(
 	outVal *reflect.Value, fieldName, fieldValue string) error {
 	fieldIndex, knownField := p.idx[fieldName]
@@ -246,7 +774,7 @@ func (p *LineKVFileParser[T]) setStringField(
 
 		return nil
 	}
-	f = outVal.Field(fieldIndex)
+	f = p.fieldTarget(outVal, fieldIndex)
 	f.SetString(fieldValue)
 
 	return nil
@@ -257,77 +785,198 @@ func (p *LineKVFileParser[T]) setStringField(
 func (p *LineKVFileParser[T]) Parse(contentBytes []byte, out *T) error {
 	outVal := reflect.ValueOf(out).Elem()
 
-	b := bytes.NewBuffer(contentBytes)
-	line, err := b.ReadString('\n')
-	for ; len(line) > 0; line, err = b.ReadString('\n') {
-		parts := strings.SplitN(line, p.splitKey, 2)
-		if len(parts) < 2 {
-			return fmt.Errorf("unable to split line %q", line)
+	// Copy the contents into a string once, so the lines (and the keys and
+	// values within them) are all substrings of it, rather than allocating
+	// a new string for each.
+	contents := string(contentBytes)
+	for len(contents) > 0 {
+		line, rest, lineErr := p.nextLine(contents)
+		if lineErr != nil {
+			return lineErr
+		}
+		if parseErr := p.parseLine(&outVal, line); parseErr != nil {
+			return parseErr
 		}
+		contents = rest
+	}
+	return nil
+}
 
-		trimmedVal := strings.TrimSpace(parts[1])
+// ParseReader is like Parse, but reads the file-contents from r as it's
+// parsed (one line at a time) rather than requiring the entire contents up
+// front.
+func (p *LineKVFileParser[T]) ParseReader(r io.Reader, out *T) error {
+	outVal := reflect.ValueOf(out).Elem()
 
-		k := p.fieldKind(parts[0])
-		// Convert to the appropriate kind of value for the destination
-		// field.
-		switch k {
-		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-			{
-				trimmedIntVal, mul := trimStringWithMultiplier(trimmedVal)
-				val, intParseErr := strconv.ParseInt(trimmedIntVal, 10, 64)
-				if intParseErr != nil {
-					return fmt.Errorf("failed to parse line %q: %s",
-						line, intParseErr)
-				}
-				val *= mul
-				if setErr := p.setIntField(
-					&outVal, parts[0], val); setErr != nil {
-					return setErr
-				}
+	b := bufio.NewReader(r)
+	line, err := p.readLine(b)
+	for ; len(line) > 0; line, err = p.readLine(b) {
+		if lineErr := p.parseLine(&outVal, line); lineErr != nil {
+			return lineErr
+		}
+	}
+
+	if err != io.EOF {
+		return err
+	}
+	return nil
+}
+
+// ParseCollect is like Parse, but rather than stopping at the first
+// malformed line, it continues past per-line errors (split failures,
+// unparseable values, overflows, etc.), populating all fields that did parse,
+// and returns every error encountered (nil if none).
+// Errors reading the contents (such as ErrLineTooLong) still end parsing.
+func (p *LineKVFileParser[T]) ParseCollect(contentBytes []byte, out *T) []error {
+	outVal := reflect.ValueOf(out).Elem()
+
+	errs := []error(nil)
+	contents := string(contentBytes)
+	for len(contents) > 0 {
+		line, rest, lineErr := p.nextLine(contents)
+		if lineErr != nil {
+			return append(errs, lineErr)
+		}
+		if parseErr := p.parseLine(&outVal, line); parseErr != nil {
+			errs = append(errs, parseErr)
+		}
+		contents = rest
+	}
+	return errs
+}
+
+// ParseLenient is like ParseCollect, but aggregates any errors into a single
+// error with errors.Join (nil if every line parsed), so callers may inspect
+// them with errors.Is/errors.As and decide whether they're fatal.
+func (p *LineKVFileParser[T]) ParseLenient(contentBytes []byte, out *T) error {
+	return errors.Join(p.ParseCollect(contentBytes, out)...)
+}
+
+// parseLine parses a single key-value line, populating the corresponding
+// field in outVal.
+func (p *LineKVFileParser[T]) parseLine(outVal *reflect.Value, line string) error {
+	key, rawVal, found := strings.Cut(line, p.splitKey)
+	if !found {
+		return fmt.Errorf("unable to split line %q", line)
+	}
+
+	trimmedVal := strings.TrimSpace(rawVal)
+
+	if p.opts.keyRE != nil {
+		key = p.opts.keyRE.ReplaceAllString(key, p.opts.keyRepl)
+	}
+
+	if _, knownField := p.idx[key]; !knownField && p.unknownFieldsIdx == -1 {
+		if p.opts.ignoreUnknownFields {
+			return nil
+		}
+		return NoUnknownFieldsFieldErr{fieldName: key, value: trimmedVal}
+	}
+
+	if unit := p.durationFieldUnit(key); unit != 0 {
+		val, intParseErr := strconv.ParseInt(trimmedVal, 10, 64)
+		if intParseErr != nil {
+			return fmt.Errorf("failed to parse line %q: %s",
+				line, intParseErr)
+		}
+		if setErr := p.setDurationField(
+			outVal, key, val, unit); setErr != nil {
+			return setErr
+		}
+		return nil
+	}
+
+	k := p.fieldKind(key)
+	// Convert to the appropriate kind of value for the destination
+	// field.
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		{
+			trimmedIntVal, mul := trimStringWithMultiplier(trimmedVal)
+			val, intParseErr := strconv.ParseInt(
+				trimmedIntVal, p.fieldBase(key), 64)
+			if intParseErr != nil {
+				return fmt.Errorf("failed to parse line %q: %s",
+					line, intParseErr)
 			}
-		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-			{
-				trimmedUintVal, mul := trimStringWithMultiplier(trimmedVal)
-				val, intParseErr := strconv.ParseUint(trimmedUintVal, 10, 64)
-				if intParseErr != nil {
-					return fmt.Errorf("failed to parse line %q: %s",
-						line, intParseErr)
-				}
-				val *= uint64(mul)
-				if setErr := p.setUintField(
-					&outVal, parts[0], val); setErr != nil {
-					return setErr
-				}
+			if val*mul/mul != val {
+				return fmt.Errorf("failed to parse line %q: "+
+					"value overflows int64 with multiplier %d", line, mul)
 			}
-		case reflect.Float32, reflect.Float64:
-			{
-				trimmedFloatVal, mul := trimStringWithMultiplier(trimmedVal)
-				val, floatParseErr := strconv.ParseFloat(trimmedFloatVal, 64)
-				if floatParseErr != nil {
-					return fmt.Errorf("failed to parse line %q: %s",
-						line, floatParseErr)
-				}
-				val *= float64(mul)
-				if setErr := p.setFloatField(
-					&outVal, parts[0], val); setErr != nil {
-					return setErr
-				}
+			val *= mul
+			if setErr := p.setIntField(
+				outVal, key, val); setErr != nil {
+				return setErr
+			}
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		{
+			trimmedUintVal, mul := trimStringWithMultiplier(trimmedVal)
+			val, intParseErr := strconv.ParseUint(
+				trimmedUintVal, p.fieldBase(key), 64)
+			if intParseErr != nil {
+				return fmt.Errorf("failed to parse line %q: %s",
+					line, intParseErr)
+			}
+			if val*uint64(mul)/uint64(mul) != val {
+				return fmt.Errorf("failed to parse line %q: "+
+					"value overflows uint64 with multiplier %d", line, mul)
+			}
+			val *= uint64(mul)
+			if setErr := p.setUintField(
+				outVal, key, val); setErr != nil {
+				return setErr
 			}
-		case reflect.String:
-			if setErr := p.setStringField(
-				&outVal, parts[0], trimmedVal); setErr != nil {
+		}
+	case reflect.Float32, reflect.Float64:
+		{
+			trimmedFloatVal, mul := trimStringWithMultiplier(trimmedVal)
+			val, floatParseErr := strconv.ParseFloat(trimmedFloatVal, 64)
+			if floatParseErr != nil {
+				return fmt.Errorf("failed to parse line %q: %s",
+					line, floatParseErr)
+			}
+			val *= float64(mul)
+			if setErr := p.setFloatField(
+				outVal, key, val); setErr != nil {
 				return setErr
 			}
+		}
+	case reflect.Bool:
+		{
+			val, boolParseErr := strconv.ParseBool(trimmedVal)
+			if boolParseErr != nil {
+				return fmt.Errorf("failed to parse line %q: %s",
+					line, boolParseErr)
+			}
+			if setErr := p.setBoolField(
+				outVal, key, val); setErr != nil {
+				return setErr
+			}
+		}
+	case reflect.String:
+		if setErr := p.setStringField(
+			outVal, key, trimmedVal); setErr != nil {
+			return setErr
+		}
 
-		default:
-			// TODO: implement slice and fixed-size array support
-			return fmt.Errorf("unhandled field kind: %s", k)
+	case reflect.Slice:
+		if setErr := p.setSliceField(
+			outVal, key, trimmedVal); setErr != nil {
+			return fmt.Errorf("failed to parse line %q: %w",
+				line, setErr)
+		}
 
+	case reflect.Array:
+		if setErr := p.setArrayField(
+			outVal, key, trimmedVal); setErr != nil {
+			return fmt.Errorf("failed to parse line %q: %w",
+				line, setErr)
 		}
-	}
 
-	if err != io.EOF {
-		return err
+	default:
+		return fmt.Errorf("unhandled field kind: %s", k)
+
 	}
 	return nil
 }
@@ -1,6 +1,17 @@
 package pparser
 
-import "testing"
+import (
+	"errors"
+	"io"
+	"math"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+)
 
 func TestParseSimpleValFloats(t *testing.T) {
 	type testStruct struct {
@@ -85,6 +96,39 @@ C: 123`
 	if err == nil {
 		t.Fatal("expected error from parsing data with unknown fields")
 	}
+	unkErr := NoUnknownFieldsFieldErr{}
+	if !errors.As(err, &unkErr) {
+		t.Fatalf("unexpected error type %T: %s", err, err)
+	}
+	if unkErr.fieldName != "B" || unkErr.value != "42" {
+		t.Errorf("unexpected unknown field %q with value %q; expected \"B\" with \"42\"",
+			unkErr.fieldName, unkErr.value)
+	}
+}
+
+func TestParseIgnoreUnknownFields(t *testing.T) {
+	type testStruct struct {
+		Known int64
+		Last  string
+	}
+
+	testVal := `Known: 1023
+B: 42
+C: not a number
+Last: foo`
+
+	p := NewLineKVFileParser(testStruct{}, ":", IgnoreUnknownFields())
+
+	out := testStruct{}
+	if err := p.Parse([]byte(testVal), &out); err != nil {
+		t.Fatalf("failed to parse: %s", err)
+	}
+	if out.Known != 1023 {
+		t.Errorf("unexpected value for Known: %d; expected 1023", out.Known)
+	}
+	if out.Last != "foo" {
+		t.Errorf("unexpected value for Last: %q; expected \"foo\"", out.Last)
+	}
 }
 
 func TestParseDatatypeTooSmall(t *testing.T) {
@@ -101,3 +145,883 @@ func TestParseDatatypeTooSmall(t *testing.T) {
 		t.Fatal("expected data overflow error")
 	}
 }
+
+func TestParseMaxLineLength(t *testing.T) {
+	type testStruct struct {
+		A int64
+		B string
+	}
+	testVal := "A: 1023\nB: " + strings.Repeat("x", 64) + "\n"
+
+	{
+		p := NewLineKVFileParser(testStruct{}, ":", WithMaxLineLength(32))
+		out := testStruct{}
+		err := p.Parse([]byte(testVal), &out)
+		if !errors.Is(err, ErrLineTooLong) {
+			t.Fatalf("unexpected error %v; expected ErrLineTooLong", err)
+		}
+	}
+	{
+		// the limit excludes the trailing newline
+		p := NewLineKVFileParser(testStruct{}, ":", WithMaxLineLength(67))
+		out := testStruct{}
+		if err := p.Parse([]byte(testVal), &out); err != nil {
+			t.Fatalf("failed to parse: %s", err)
+		}
+		if out.A != 1023 {
+			t.Errorf("unexpected value for A; %d; expected 1023", out.A)
+		}
+		if len(out.B) != 64 {
+			t.Errorf("unexpected length for B; %d; expected 64", len(out.B))
+		}
+	}
+	{
+		// unterminated final lines are also bounded
+		p := NewLineKVFileParser(testStruct{}, ":", WithMaxLineLength(32))
+		out := testStruct{}
+		err := p.Parse([]byte("A: 1\nB: "+strings.Repeat("y", 64)), &out)
+		if !errors.Is(err, ErrLineTooLong) {
+			t.Fatalf("unexpected error %v; expected ErrLineTooLong", err)
+		}
+	}
+}
+
+func TestParseBools(t *testing.T) {
+	type testStruct struct {
+		A             bool
+		B             bool
+		C             bool
+		D             bool
+		UnknownFields map[string]bool `pparser:"skip,unknown"`
+	}
+	testVal := `A: 1
+B: 0
+C: true
+D: false
+E: 1
+F: false`
+
+	p := NewLineKVFileParser(testStruct{}, ":")
+
+	out := testStruct{B: true, D: true}
+	err := p.Parse([]byte(testVal), &out)
+	if err != nil {
+		t.Fatalf("failed to parse: %s", err)
+	}
+	if !out.A {
+		t.Errorf("unexpected value for A; %t; expected true", out.A)
+	}
+	if out.B {
+		t.Errorf("unexpected value for B; %t; expected false", out.B)
+	}
+	if !out.C {
+		t.Errorf("unexpected value for C; %t; expected true", out.C)
+	}
+	if out.D {
+		t.Errorf("unexpected value for D; %t; expected false", out.D)
+	}
+	if len(out.UnknownFields) != 2 {
+		t.Errorf("unexpected number of unknown fields %d; expected 2: %v", len(out.UnknownFields), out.UnknownFields)
+	}
+	if e, ok := out.UnknownFields["E"]; !ok || !e {
+		t.Errorf("unexpected value for unknown field E; %t (present: %t); expected true", e, ok)
+	}
+	if f, ok := out.UnknownFields["F"]; !ok || f {
+		t.Errorf("unexpected value for unknown field F; %t (present: %t); expected false", f, ok)
+	}
+
+	if err := p.Parse([]byte("A: maybe\n"), &out); err == nil {
+		t.Error("expected error parsing non-boolean value")
+	}
+}
+
+func TestParseDurations(t *testing.T) {
+	type testStruct struct {
+		Throttled time.Duration `pparser:"throttled_usec,unit=usec"`
+		Wait      time.Duration `pparser:"wait_sum"`
+		Period    time.Duration `pparser:"period_ms,unit=ms"`
+		Count     int64         `pparser:"nr_periods"`
+	}
+	testVal := `throttled_usec 1234
+wait_sum 5678
+period_ms 100
+nr_periods 42
+`
+
+	p := NewLineKVFileParser(testStruct{}, " ")
+
+	out := testStruct{}
+	if err := p.Parse([]byte(testVal), &out); err != nil {
+		t.Fatalf("failed to parse: %s", err)
+	}
+	if out.Throttled != 1234*time.Microsecond {
+		t.Errorf("unexpected value for Throttled; %s; expected %s", out.Throttled, 1234*time.Microsecond)
+	}
+	if out.Wait != 5678*time.Nanosecond {
+		t.Errorf("unexpected value for Wait; %s; expected %s", out.Wait, 5678*time.Nanosecond)
+	}
+	if out.Period != 100*time.Millisecond {
+		t.Errorf("unexpected value for Period; %s; expected %s", out.Period, 100*time.Millisecond)
+	}
+	if out.Count != 42 {
+		t.Errorf("unexpected value for Count; %d; expected 42", out.Count)
+	}
+
+	if err := p.Parse([]byte("period_ms 9223372036854775\n"), &out); err == nil {
+		t.Error("expected overflow error")
+	}
+}
+
+func TestParseDurationBadTags(t *testing.T) {
+	for _, tbl := range []struct {
+		name string
+		t    any
+	}{
+		{
+			name: "unknown_unit",
+			t: struct {
+				D time.Duration `pparser:"d,unit=fortnight"`
+			}{},
+		},
+		{
+			name: "unknown_option",
+			t: struct {
+				D time.Duration `pparser:"d,scale=2"`
+			}{},
+		},
+		{
+			name: "empty_alias",
+			t: struct {
+				D int64 `pparser:"d,,e"`
+			}{},
+		},
+		{
+			name: "unit_on_int",
+			t: struct {
+				D int64 `pparser:"d,unit=usec"`
+			}{},
+		},
+	} {
+		t.Run(tbl.name, func(t *testing.T) {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Error("expected panic")
+				}
+			}()
+			fieldIndex(tbl.t)
+		})
+	}
+}
+
+func TestParseSlices(t *testing.T) {
+	type testStruct struct {
+		UID           []uint64           `pparser:"Uid"`
+		GID           []int64            `pparser:"Gid"`
+		Groups        []uint32           `pparser:"Groups"`
+		Loads         []float64          `pparser:"Loads"`
+		Names         []string           `pparser:"Names"`
+		UnknownFields map[string][]int64 `pparser:"skip,unknown"`
+	}
+	testVal := "Uid:\t1000\t1000\t1000\t1000\n" +
+		"Gid:\t100 100 100 100\n" +
+		"Groups:\t\n" +
+		"Loads:\t0.25 1.5\n" +
+		"Names:\tfoo bar\n" +
+		"NSpid:\t1234 1\n"
+
+	p := NewLineKVFileParser(testStruct{}, ":")
+
+	out := testStruct{}
+	if err := p.Parse([]byte(testVal), &out); err != nil {
+		t.Fatalf("failed to parse: %s", err)
+	}
+	if !slices.Equal(out.UID, []uint64{1000, 1000, 1000, 1000}) {
+		t.Errorf("unexpected value for UID: %v", out.UID)
+	}
+	if !slices.Equal(out.GID, []int64{100, 100, 100, 100}) {
+		t.Errorf("unexpected value for GID: %v", out.GID)
+	}
+	if out.Groups == nil || len(out.Groups) != 0 {
+		t.Errorf("unexpected value for Groups: %#v; expected empty non-nil slice", out.Groups)
+	}
+	if !slices.Equal(out.Loads, []float64{0.25, 1.5}) {
+		t.Errorf("unexpected value for Loads: %v", out.Loads)
+	}
+	if !slices.Equal(out.Names, []string{"foo", "bar"}) {
+		t.Errorf("unexpected value for Names: %v", out.Names)
+	}
+	if !slices.Equal(out.UnknownFields["NSpid"], []int64{1234, 1}) {
+		t.Errorf("unexpected value for unknown field NSpid: %v", out.UnknownFields["NSpid"])
+	}
+
+	err := p.Parse([]byte("Uid:\t1000 10x0 1000 1000\n"), &out)
+	if err == nil {
+		t.Fatal("expected error for malformed element")
+	}
+	if !strings.Contains(err.Error(), `"10x0"`) {
+		t.Errorf("error doesn't identify offending token: %s", err)
+	}
+	if err := p.Parse([]byte("Groups:\t1 4294967296\n"), &out); err == nil {
+		t.Error("expected overflow error")
+	}
+}
+
+func TestParseArrays(t *testing.T) {
+	type testStruct struct {
+		MemsAllowed   [32]uint32           `pparser:"Mems_allowed,base=16"`
+		CPUsAllowed   [1]uint32            `pparser:"Cpus_allowed,base=16"`
+		Triple        [3]int16             `pparser:"Triple"`
+		UnknownFields map[string][2]uint64 `pparser:"skip,unknown"`
+	}
+	memsAllowed := strings.Repeat("00000000,", 31) + "00000001"
+	testVal := "Mems_allowed:\t" + memsAllowed + "\n" +
+		"Cpus_allowed:\tf\n" +
+		"Triple:\t-1, 2, 3\n" +
+		"Pair:\t7 8\n"
+
+	p := NewLineKVFileParser(testStruct{}, ":")
+
+	out := testStruct{}
+	if err := p.Parse([]byte(testVal), &out); err != nil {
+		t.Fatalf("failed to parse: %s", err)
+	}
+	expMems := [32]uint32{}
+	expMems[31] = 1
+	if out.MemsAllowed != expMems {
+		t.Errorf("unexpected value for MemsAllowed: %v", out.MemsAllowed)
+	}
+	if out.CPUsAllowed != [1]uint32{0xf} {
+		t.Errorf("unexpected value for CPUsAllowed: %v", out.CPUsAllowed)
+	}
+	if out.Triple != [3]int16{-1, 2, 3} {
+		t.Errorf("unexpected value for Triple: %v", out.Triple)
+	}
+	if out.UnknownFields["Pair"] != [2]uint64{7, 8} {
+		t.Errorf("unexpected value for unknown field Pair: %v", out.UnknownFields["Pair"])
+	}
+
+	if err := p.Parse([]byte("Mems_allowed:\t00000000,00000001\n"), &out); err == nil {
+		t.Error("expected error for element-count mismatch")
+	}
+	err := p.Parse([]byte("Cpus_allowed:\tfg\n"), &out)
+	if err == nil {
+		t.Fatal("expected error for malformed hex element")
+	}
+	if !strings.Contains(err.Error(), `"fg"`) {
+		t.Errorf("error doesn't identify offending token: %s", err)
+	}
+	if err := p.Parse([]byte("Cpus_allowed:\t1ffffffff\n"), &out); err == nil {
+		t.Error("expected overflow error")
+	}
+}
+
+func TestParseHexFlag(t *testing.T) {
+	type testStruct struct {
+		CapEff        uint64            `pparser:"CapEff,hex"`
+		CapBnd        uint64            `pparser:"CapBnd,hex"`
+		CPUsAllowed   [2]uint32         `pparser:"Cpus_allowed,hex"`
+		UnknownFields map[string]string `pparser:"skip,unknown"`
+	}
+	p := NewLineKVFileParser(testStruct{}, ":")
+
+	out := testStruct{}
+	testVal := "CapEff:\t000001ffffffffff\n" +
+		"CapBnd:\tffffffffffffffff\n" +
+		"Cpus_allowed:\tffffffff,0000000f\n" +
+		"SigQ:\t0/78835\n"
+	if err := p.Parse([]byte(testVal), &out); err != nil {
+		t.Fatalf("failed to parse: %s", err)
+	}
+	if out.CapEff != 0x1ffffffffff {
+		t.Errorf("unexpected value for CapEff: %#x; expected 0x1ffffffffff", out.CapEff)
+	}
+	if out.CapBnd != math.MaxUint64 {
+		t.Errorf("unexpected value for CapBnd: %#x; expected %#x", out.CapBnd, uint64(math.MaxUint64))
+	}
+	if out.CPUsAllowed != [2]uint32{0xffffffff, 0xf} {
+		t.Errorf("unexpected value for CPUsAllowed: %#x", out.CPUsAllowed)
+	}
+	if out.UnknownFields["SigQ"] != "0/78835" {
+		t.Errorf("unexpected value for unknown field SigQ: %q", out.UnknownFields["SigQ"])
+	}
+}
+
+func TestParseUIDArrayAndKBSlice(t *testing.T) {
+	type testStruct struct {
+		UID   [4]int64 `pparser:"Uid"`
+		Sizes []uint64 `pparser:"Sizes"`
+	}
+	p := NewLineKVFileParser(testStruct{}, ":")
+
+	out := testStruct{}
+	if err := p.Parse([]byte("Uid:\t1000\t1000\t1001\t1000\nSizes:\t4 kB 8 kB\t16\n"), &out); err != nil {
+		t.Fatalf("failed to parse: %s", err)
+	}
+	if out.UID != [4]int64{1000, 1000, 1001, 1000} {
+		t.Errorf("unexpected value for UID: %v", out.UID)
+	}
+	if !slices.Equal(out.Sizes, []uint64{4096, 8192, 16}) {
+		t.Errorf("unexpected value for Sizes: %v", out.Sizes)
+	}
+
+	for _, tbl := range []struct {
+		name string
+		in   string
+	}{
+		{name: "too_few", in: "Uid:\t1000\t1000\t1000\n"},
+		{name: "too_many", in: "Uid:\t1000\t1000\t1000\t1000\t1000\n"},
+		{name: "kb_overflow", in: "Sizes:\t18446744073709551615 kB\n"},
+	} {
+		t.Run(tbl.name, func(t *testing.T) {
+			if err := p.Parse([]byte(tbl.in), &out); err == nil {
+				t.Errorf("expected error parsing %q", tbl.in)
+			}
+		})
+	}
+}
+
+func TestParseHexMasks(t *testing.T) {
+	type testStruct struct {
+		SigCgt  uint64 `pparser:"SigCgt,base=16"`
+		CapBnd  uint64 `pparser:"CapBnd,base=16"`
+		Signed  int32  `pparser:"Signed,base=16"`
+		Decimal uint64 `pparser:"Decimal"`
+		Size    uint64 `pparser:"Size,base=16"`
+	}
+	p := NewLineKVFileParser(testStruct{}, ":")
+
+	out := testStruct{}
+	testVal := "SigCgt:\t00000001ef824eff\n" +
+		"CapBnd:\t0000003fffffffff\n" +
+		"Signed:\t-7f\n" +
+		"Decimal:\t0000000000000010\n" +
+		"Size:\t10 kB\n"
+	if err := p.Parse([]byte(testVal), &out); err != nil {
+		t.Fatalf("failed to parse: %s", err)
+	}
+	if out.SigCgt != 0x1ef824eff {
+		t.Errorf("unexpected value for SigCgt: %#x; expected 0x1ef824eff", out.SigCgt)
+	}
+	if out.CapBnd != 0x3fffffffff {
+		t.Errorf("unexpected value for CapBnd: %#x; expected 0x3fffffffff", out.CapBnd)
+	}
+	if out.Signed != -0x7f {
+		t.Errorf("unexpected value for Signed: %d; expected %d", out.Signed, -0x7f)
+	}
+	if out.Decimal != 10 {
+		t.Errorf("unexpected value for Decimal: %d; expected 10", out.Decimal)
+	}
+	if out.Size != 0x10*1024 {
+		t.Errorf("unexpected value for Size: %d; expected %d", out.Size, 0x10*1024)
+	}
+
+	if err := p.Parse([]byte("SigCgt:\t00000001ef824efg\n"), &out); err == nil {
+		t.Error("expected error for malformed hex value")
+	}
+}
+
+func TestParseBadBaseTags(t *testing.T) {
+	for _, tbl := range []struct {
+		name string
+		t    any
+	}{
+		{
+			name: "base_on_string",
+			t: struct {
+				A string `pparser:"a,base=16"`
+			}{},
+		},
+		{
+			name: "hex_on_float",
+			t: struct {
+				A float64 `pparser:"a,hex"`
+			}{},
+		},
+		{
+			name: "hex_with_value",
+			t: struct {
+				A uint64 `pparser:"a,hex=1"`
+			}{},
+		},
+		{
+			name: "malformed_base",
+			t: struct {
+				A [2]uint32 `pparser:"a,base=x"`
+			}{},
+		},
+		{
+			name: "out_of_range_base",
+			t: struct {
+				A [2]uint32 `pparser:"a,base=1"`
+			}{},
+		},
+	} {
+		t.Run(tbl.name, func(t *testing.T) {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Error("expected panic")
+				}
+			}()
+			fieldIndex(tbl.t)
+		})
+	}
+}
+
+const testMeminfo = `MemTotal:       32657580 kB
+MemFree:         1719008 kB
+MemAvailable:   23131108 kB
+Buffers:         1393264 kB
+Cached:         19183172 kB
+SwapCached:            0 kB
+Active:         13164140 kB
+Inactive:       15296616 kB
+SwapTotal:       2097148 kB
+SwapFree:        2097148 kB
+Dirty:               844 kB
+HugePages_Total:       0
+HugePages_Free:        0
+Hugepagesize:       2048 kB`
+
+func TestParseReaderMatchesParse(t *testing.T) {
+	type meminfo struct {
+		MemTotal      int64
+		MemFree       int64
+		MemAvailable  int64
+		Cached        int64
+		SwapTotal     int64
+		SwapFree      int64
+		HugePageSize  int64            `pparser:"Hugepagesize"`
+		UnknownFields map[string]int64 `pparser:"skip,unknown"`
+	}
+	p := NewLineKVFileParser(meminfo{}, ":")
+
+	for _, tbl := range []struct {
+		name     string
+		contents string
+	}{
+		{name: "trailing_newline", contents: testMeminfo + "\n"},
+		{name: "no_trailing_newline", contents: testMeminfo},
+	} {
+		t.Run(tbl.name, func(t *testing.T) {
+			fromBytes := meminfo{}
+			if err := p.Parse([]byte(tbl.contents), &fromBytes); err != nil {
+				t.Fatalf("failed to parse: %s", err)
+			}
+			fromReader := meminfo{}
+			if err := p.ParseReader(iotest.OneByteReader(strings.NewReader(tbl.contents)), &fromReader); err != nil {
+				t.Fatalf("failed to parse from reader: %s", err)
+			}
+			if !reflect.DeepEqual(fromBytes, fromReader) {
+				t.Errorf("mismatched results:\n  Parse: %+v\n ParseReader: %+v", fromBytes, fromReader)
+			}
+			if fromReader.HugePageSize != 2048*1024 {
+				t.Errorf("unexpected value for Hugepagesize (last line): %d; expected %d",
+					fromReader.HugePageSize, 2048*1024)
+			}
+			if len(fromReader.UnknownFields) != 7 {
+				t.Errorf("unexpected number of unknown fields %d; expected 7: %v",
+					len(fromReader.UnknownFields), fromReader.UnknownFields)
+			}
+		})
+	}
+}
+
+// endlessReader produces an endless stream of non-newline bytes
+type endlessReader struct{}
+
+func (endlessReader) Read(b []byte) (int, error) {
+	for i := range b {
+		b[i] = 'x'
+	}
+	return len(b), nil
+}
+
+func TestParseReaderMaxLineLength(t *testing.T) {
+	type testStruct struct {
+		A string
+	}
+	p := NewLineKVFileParser(testStruct{}, ":", WithMaxLineLength(1<<16))
+	out := testStruct{}
+	err := p.ParseReader(io.MultiReader(strings.NewReader("A: "), endlessReader{}), &out)
+	if !errors.Is(err, ErrLineTooLong) {
+		t.Fatalf("unexpected error %v; expected ErrLineTooLong", err)
+	}
+}
+
+func TestParseReaderErrors(t *testing.T) {
+	type testStruct struct {
+		A int64
+	}
+	p := NewLineKVFileParser(testStruct{}, ":")
+	out := testStruct{}
+
+	err := p.ParseReader(strings.NewReader("A: 12x\n"), &out)
+	if err == nil {
+		t.Fatal("expected error for malformed value")
+	}
+	if !strings.Contains(err.Error(), "A: 12x") {
+		t.Errorf("error doesn't include offending line: %s", err)
+	}
+
+	readErr := errors.New("read failed")
+	err = p.ParseReader(io.MultiReader(strings.NewReader("A: 12\n"), iotest.ErrReader(readErr)), &out)
+	if !errors.Is(err, readErr) {
+		t.Errorf("unexpected error %v; expected %v", err, readErr)
+	}
+	if out.A != 12 {
+		t.Errorf("unexpected value for A: %d; expected 12", out.A)
+	}
+}
+
+func TestParseCollect(t *testing.T) {
+	type meminfo struct {
+		MemTotal     int64
+		MemFree      int64
+		MemAvailable int64
+		Buffers      int8
+		Cached       int64
+	}
+	testVal := "MemTotal:       32657580 kB\n" +
+		"MemFree:         17190x8 kB\n" +
+		"MemAvailable:   23131108 kB\n" +
+		"Buffers:         1393264 kB\n" +
+		"Cached:         19183172 kB\n"
+	p := NewLineKVFileParser(meminfo{}, ":")
+
+	out := meminfo{}
+	errs := p.ParseCollect([]byte(testVal), &out)
+	if len(errs) != 2 {
+		t.Fatalf("unexpected number of errors: %d; expected 2: %v", len(errs), errs)
+	}
+	if !strings.Contains(errs[0].Error(), "MemFree") {
+		t.Errorf("first error doesn't identify the MemFree line: %s", errs[0])
+	}
+	if !strings.Contains(errs[1].Error(), "Buffers") {
+		t.Errorf("second error doesn't identify the Buffers field: %s", errs[1])
+	}
+	exp := meminfo{
+		MemTotal:     32657580 * 1024,
+		MemAvailable: 23131108 * 1024,
+		Cached:       19183172 * 1024,
+	}
+	if out != exp {
+		t.Errorf("unexpected output:\n  got %+v\n want %+v", out, exp)
+	}
+
+	if err := p.Parse([]byte(testVal), &meminfo{}); err == nil {
+		t.Error("expected Parse to fail on first malformed line")
+	}
+	if errs := p.ParseCollect([]byte("MemTotal: 1\n"), &out); errs != nil {
+		t.Errorf("unexpected errors: %v", errs)
+	}
+}
+
+func TestParseLenient(t *testing.T) {
+	type testStruct struct {
+		A int64
+		B int64
+		C int64
+	}
+	testVal := "A: 1\nbroken line\nB: two\nC: 3\nD: 4\n"
+	p := NewLineKVFileParser(testStruct{}, ":")
+
+	out := testStruct{}
+	err := p.ParseLenient([]byte(testVal), &out)
+	if err == nil {
+		t.Fatal("expected error from malformed lines")
+	}
+	unkErr := NoUnknownFieldsFieldErr{}
+	if !errors.As(err, &unkErr) || unkErr.fieldName != "D" {
+		t.Errorf("joined error doesn't include the unknown field D: %s", err)
+	}
+	if !strings.Contains(err.Error(), "broken line") {
+		t.Errorf("joined error doesn't include the unsplittable line: %s", err)
+	}
+	if exp := (testStruct{A: 1, C: 3}); out != exp {
+		t.Errorf("unexpected output:\n  got %+v\n want %+v", out, exp)
+	}
+
+	if err := p.ParseLenient([]byte("A: 1\nC: 3\n"), &out); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
+
+func TestParseUnitMultipliers(t *testing.T) {
+	type testStruct struct {
+		KB    int64
+		MB    int64
+		GB    uint64
+		KiB   int64
+		MiB   uint64
+		GiB   int64
+		Float float64
+		Sizes []int64
+	}
+	testVal := "KB: 2 kB\n" +
+		"MB: 3 MB\n" +
+		"GB: 4GB\n" +
+		"KiB: 5 KiB\n" +
+		"MiB: 6 MiB\n" +
+		"GiB: 7 GiB\n" +
+		"Float: 1.5 MB\n" +
+		"Sizes: 1 KiB 2 MB 3\n"
+	p := NewLineKVFileParser(testStruct{}, ":")
+
+	out := testStruct{}
+	if err := p.Parse([]byte(testVal), &out); err != nil {
+		t.Fatalf("failed to parse: %s", err)
+	}
+	exp := testStruct{
+		KB:    2 * 1024,
+		MB:    3 * 1000 * 1000,
+		GB:    4 * 1000 * 1000 * 1000,
+		KiB:   5 * 1024,
+		MiB:   6 * 1024 * 1024,
+		GiB:   7 * 1024 * 1024 * 1024,
+		Float: 1.5 * 1000 * 1000,
+		Sizes: []int64{1024, 2 * 1000 * 1000, 3},
+	}
+	if !reflect.DeepEqual(out, exp) {
+		t.Errorf("unexpected output:\n  got %+v\n want %+v", out, exp)
+	}
+
+	if err := p.Parse([]byte("GiB: 9223372036854775807 GiB\n"), &out); err == nil {
+		t.Error("expected overflow error")
+	}
+}
+
+func TestParseAliases(t *testing.T) {
+	type memStat struct {
+		Anon          int64            `pparser:"anon,rss,anonymous"`
+		Hex           uint64           `pparser:"hexval,hex,old_hexval"`
+		UnknownFields map[string]int64 `pparser:"skip,unknown"`
+	}
+	p := NewLineKVFileParser(memStat{}, " ")
+
+	for _, tbl := range []struct {
+		name     string
+		contents string
+	}{
+		{name: "cgroup_v2", contents: "anon 4096\nhexval ff\nfile 8192\n"},
+		{name: "cgroup_v1", contents: "rss 4096\nold_hexval ff\ncache 8192\n"},
+		{name: "other_alias", contents: "anonymous 4096\nhexval ff\n"},
+	} {
+		t.Run(tbl.name, func(t *testing.T) {
+			out := memStat{}
+			if err := p.Parse([]byte(tbl.contents), &out); err != nil {
+				t.Fatalf("failed to parse: %s", err)
+			}
+			if out.Anon != 4096 {
+				t.Errorf("unexpected value for Anon: %d; expected 4096", out.Anon)
+			}
+			if out.Hex != 0xff {
+				t.Errorf("unexpected value for Hex: %#x; expected 0xff", out.Hex)
+			}
+			if _, ok := out.UnknownFields["rss"]; ok {
+				t.Errorf("alias unexpectedly in UnknownFields: %v", out.UnknownFields)
+			}
+		})
+	}
+}
+
+func TestParserFields(t *testing.T) {
+	type testStruct struct {
+		Anon          int64 `pparser:"anon,rss"`
+		File          int64
+		Ignored       int64            `pparser:"skip"`
+		UnknownFields map[string]int64 `pparser:"skip,unknown"`
+	}
+	p := NewLineKVFileParser(testStruct{}, " ")
+
+	fields := p.Fields()
+	names := make([]string, 0, len(fields))
+	for name, field := range fields {
+		names = append(names, name+"->"+field.Name)
+	}
+	slices.Sort(names)
+	if exp := []string{"File->File", "anon->Anon", "rss->Anon"}; !slices.Equal(names, exp) {
+		t.Errorf("unexpected fields: %v; expected %v", names, exp)
+	}
+
+	// mutating the returned map must not affect the parser
+	delete(fields, "anon")
+	out := testStruct{}
+	if err := p.Parse([]byte("anon 12\n"), &out); err != nil {
+		t.Fatalf("failed to parse: %s", err)
+	}
+	if out.Anon != 12 {
+		t.Errorf("unexpected value for Anon: %d; expected 12", out.Anon)
+	}
+}
+
+func TestParsePointerFields(t *testing.T) {
+	type testStruct struct {
+		Present       *int64           `pparser:"present"`
+		Absent        *int64           `pparser:"absent"`
+		Name          *string          `pparser:"name"`
+		Zero          *uint32          `pparser:"zero"`
+		Mask          *uint64          `pparser:"mask,hex"`
+		Wait          *time.Duration   `pparser:"wait_usec,unit=usec"`
+		IDs           *[]int64         `pparser:"ids"`
+		Overflowed    *int8            `pparser:"overflowed"`
+		UnknownFields map[string]int64 `pparser:"skip,unknown"`
+	}
+	p := NewLineKVFileParser(testStruct{}, ":")
+
+	out := testStruct{}
+	testVal := "present: 4 kB\nname: vim\nzero: 0\nmask: ff\nwait_usec: 3\nids: 1 2\n"
+	if err := p.Parse([]byte(testVal), &out); err != nil {
+		t.Fatalf("failed to parse: %s", err)
+	}
+	if out.Present == nil || *out.Present != 4096 {
+		t.Errorf("unexpected value for Present: %v; expected pointer to 4096", out.Present)
+	}
+	if out.Absent != nil {
+		t.Errorf("unexpectedly non-nil Absent: %d", *out.Absent)
+	}
+	if out.Name == nil || *out.Name != "vim" {
+		t.Errorf("unexpected value for Name: %v; expected pointer to \"vim\"", out.Name)
+	}
+	if out.Zero == nil || *out.Zero != 0 {
+		t.Errorf("unexpected value for Zero: %v; expected pointer to 0", out.Zero)
+	}
+	if out.Mask == nil || *out.Mask != 0xff {
+		t.Errorf("unexpected value for Mask: %v; expected pointer to 0xff", out.Mask)
+	}
+	if out.Wait == nil || *out.Wait != 3*time.Microsecond {
+		t.Errorf("unexpected value for Wait: %v; expected pointer to 3µs", out.Wait)
+	}
+	if out.IDs == nil || !slices.Equal(*out.IDs, []int64{1, 2}) {
+		t.Errorf("unexpected value for IDs: %v; expected pointer to [1 2]", out.IDs)
+	}
+
+	errs := p.ParseCollect([]byte("overflowed: 1024\n"), &out)
+	if len(errs) != 1 {
+		t.Fatalf("unexpected errors: %v; expected one overflow error", errs)
+	}
+	if out.Overflowed != nil {
+		t.Errorf("unexpectedly allocated Overflowed after overflow: %d", *out.Overflowed)
+	}
+
+	marshaled, marshalErr := p.Marshal(&out)
+	if marshalErr != nil {
+		t.Fatalf("failed to marshal: %s", marshalErr)
+	}
+	if strings.Contains(string(marshaled), "absent") {
+		t.Errorf("marshaled output unexpectedly contains absent field: %q", marshaled)
+	}
+}
+
+func TestParseKeyNormalization(t *testing.T) {
+	type meminfo struct {
+		Active        int64
+		ActiveAnon    int64            `pparser:"Active_anon"`
+		InactiveFile  int64            `pparser:"Inactive_file"`
+		UnknownFields map[string]int64 `pparser:"skip,unknown"`
+	}
+	testVal := "Active:         13164140 kB\n" +
+		"Active(anon):    7442312 kB\n" +
+		"Inactive(file):  9196804 kB\n" +
+		"Unevictable(x):        4 kB\n"
+
+	{
+		p := NewLineKVFileParser(meminfo{}, ":")
+		out := meminfo{}
+		if err := p.Parse([]byte(testVal), &out); err != nil {
+			t.Fatalf("failed to parse: %s", err)
+		}
+		if out.ActiveAnon != 0 || out.InactiveFile != 0 {
+			t.Errorf("unexpectedly populated normalized fields without normalization: %+v", out)
+		}
+		if out.UnknownFields["Active(anon)"] != 7442312*1024 {
+			t.Errorf("raw key missing from UnknownFields: %v", out.UnknownFields)
+		}
+	}
+	{
+		p := NewLineKVFileParser(meminfo{}, ":",
+			WithKeyNormalization(regexp.MustCompile(`\((\w+)\)`), "_$1"))
+		out := meminfo{}
+		if err := p.Parse([]byte(testVal), &out); err != nil {
+			t.Fatalf("failed to parse: %s", err)
+		}
+		exp := meminfo{
+			Active:        13164140 * 1024,
+			ActiveAnon:    7442312 * 1024,
+			InactiveFile:  9196804 * 1024,
+			UnknownFields: map[string]int64{"Unevictable_x": 4 * 1024},
+		}
+		if !reflect.DeepEqual(out, exp) {
+			t.Errorf("unexpected output:\n  got %+v\n want %+v", out, exp)
+		}
+	}
+}
+
+func BenchmarkLineKVFileParserParse(b *testing.B) {
+	type memInfo struct {
+		MemTotal     int64
+		MemFree      int64
+		MemAvailable int64
+		Buffers      int64
+		Cached       int64
+		SwapTotal    int64
+		SwapFree     int64
+		Dirty        *int64
+		HugePages    uint64           `pparser:"HugePages_Total"`
+		Unknown      map[string]int64 `pparser:"skip,unknown"`
+	}
+	type cgStat struct {
+		Anon          int64  `pparser:"anon"`
+		File          int64  `pparser:"file"`
+		KernelStack   int64  `pparser:"kernel_stack"`
+		Sock          uint64 `pparser:"sock"`
+		Shmem         int64  `pparser:"shmem"`
+		FileMapped    int64  `pparser:"file_mapped"`
+		PgFault       uint64 `pparser:"pgfault"`
+		PgMajFault    uint64 `pparser:"pgmajfault"`
+		WorkingsetHit uint64 `pparser:"workingset_refault"`
+	}
+	const memInfoContents = `MemTotal:       16310472 kB
+MemFree:         1180616 kB
+MemAvailable:    9865664 kB
+Buffers:          776332 kB
+Cached:          7803488 kB
+SwapTotal:       2097148 kB
+SwapFree:        2097148 kB
+Dirty:               368 kB
+HugePages_Total:       0
+`
+	const cgStatContents = `anon 1234567168
+file 2345678848
+kernel_stack 1179648
+sock 0
+shmem 4096
+file_mapped 123456512
+pgfault 4567890
+pgmajfault 1234
+workingset_refault 5678
+`
+	b.Run("meminfo", func(b *testing.B) {
+		p := NewLineKVFileParser(memInfo{}, ":")
+		contents := []byte(memInfoContents)
+		b.ReportAllocs()
+		b.SetBytes(int64(len(contents)))
+		for i := 0; i < b.N; i++ {
+			out := memInfo{}
+			if err := p.Parse(contents, &out); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("memory_stat", func(b *testing.B) {
+		p := NewLineKVFileParser(cgStat{}, " ")
+		contents := []byte(cgStatContents)
+		b.ReportAllocs()
+		b.SetBytes(int64(len(contents)))
+		for i := 0; i < b.N; i++ {
+			out := cgStat{}
+			if err := p.Parse(contents, &out); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
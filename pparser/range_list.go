@@ -0,0 +1,51 @@
+package pparser
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ParseRangeList parses the "list format" used by the kernel for sets of
+// CPU and memory-node numbers (e.g. /proc/<pid>/status's
+// Cpus_allowed_list, cpuset.cpus and /sys/devices/system/cpu/online),
+// expanding it into a sorted slice of unique values.
+//
+// From cpuset(7):
+//
+//	The List Format for cpus and mems is a comma-separated list of CPU or
+//	memory-node numbers and ranges of numbers, in ASCII decimal.
+//
+//	Examples of the List Format:
+//	    0-4,9           # bits 0, 1, 2, 3, 4, and 9 set
+//	    0-2,7,12-14     # bits 0, 1, 2, 7, 12, 13, and 14 set
+//
+// An empty (or whitespace-only) list yields an empty, non-nil slice.
+func ParseRangeList(list string) ([]int, error) {
+	list = strings.TrimSpace(list)
+	out := []int{}
+	if list == "" {
+		return out, nil
+	}
+	for _, elem := range strings.Split(list, ",") {
+		startStr, endStr, isRange := strings.Cut(elem, "-")
+		start, startErr := strconv.Atoi(startStr)
+		if startErr != nil || start < 0 {
+			return nil, fmt.Errorf("invalid element %q in list %q", elem, list)
+		}
+		if !isRange {
+			out = append(out, start)
+			continue
+		}
+		end, endErr := strconv.Atoi(endStr)
+		if endErr != nil || end < start {
+			return nil, fmt.Errorf("invalid range %q in list %q", elem, list)
+		}
+		for i := start; i <= end; i++ {
+			out = append(out, i)
+		}
+	}
+	slices.Sort(out)
+	return slices.Compact(out), nil
+}
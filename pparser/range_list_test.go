@@ -0,0 +1,43 @@
+package pparser
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseRangeList(t *testing.T) {
+	for _, tbl := range []struct {
+		in     string
+		expOut []int
+		expErr bool
+	}{
+		{in: "0-3", expOut: []int{0, 1, 2, 3}},
+		{in: "0,2,4", expOut: []int{0, 2, 4}},
+		{in: "1-2,5-6", expOut: []int{1, 2, 5, 6}},
+		{in: "7", expOut: []int{7}},
+		{in: "0-4,9\n", expOut: []int{0, 1, 2, 3, 4, 9}},
+		{in: "9,0-2,1", expOut: []int{0, 1, 2, 9}},
+		{in: "", expOut: []int{}},
+		{in: "\n", expOut: []int{}},
+		{in: "3-1", expErr: true},
+		{in: "1,,2", expErr: true},
+		{in: "a-3", expErr: true},
+		{in: "1-b", expErr: true},
+		{in: "-1", expErr: true},
+	} {
+		out, err := ParseRangeList(tbl.in)
+		if tbl.expErr {
+			if err == nil {
+				t.Errorf("%q: expected error; got %v", tbl.in, out)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", tbl.in, err)
+			continue
+		}
+		if out == nil || !slices.Equal(out, tbl.expOut) {
+			t.Errorf("%q: unexpected output %v; expected %v", tbl.in, out, tbl.expOut)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package pparser
+
+import (
+	"reflect"
+	"sync"
+)
+
+type parserCacheKey struct {
+	t        reflect.Type
+	splitKey string
+}
+
+// parserCache maps parserCacheKey to *LineKVFileParser[T] values (as
+// constructed by cachedParser).
+var parserCache sync.Map
+
+// cachedParser returns a LineKVFileParser for T and splitKey, constructing
+// (and caching) one if necessary.
+func cachedParser[T any](splitKey string) *LineKVFileParser[T] {
+	var zero T
+	key := parserCacheKey{t: reflect.TypeOf(zero), splitKey: splitKey}
+	if p, ok := parserCache.Load(key); ok {
+		return p.(*LineKVFileParser[T])
+	}
+	// Racing constructions are harmless; LoadOrStore ensures everyone uses
+	// the same instance after the first store.
+	p, _ := parserCache.LoadOrStore(key, NewLineKVFileParser(zero, splitKey))
+	return p.(*LineKVFileParser[T])
+}
+
+// Unmarshal parses contentBytes into out, as if with a LineKVFileParser
+// constructed by NewLineKVFileParser for T and splitKey. Parsers are cached
+// by type and splitKey, so the reflection needed to construct one is only
+// done once per type. T must be a struct type.
+// Unmarshal is intended for one-off parsing; callers wishing to customize the
+// parser with ParserOptions should use NewLineKVFileParser.
+func Unmarshal[T any](contentBytes []byte, splitKey string, out *T) error {
+	return cachedParser[T](splitKey).Parse(contentBytes, out)
+}
@@ -0,0 +1,50 @@
+package pparser
+
+import (
+	"testing"
+)
+
+func TestUnmarshal(t *testing.T) {
+	type testStruct struct {
+		MemTotal      int64
+		MemFree       int64
+		UnknownFields map[string]int64 `pparser:"skip,unknown"`
+	}
+
+	for i, tbl := range []struct {
+		contents string
+		expTotal int64
+		expFree  int64
+	}{
+		{contents: "MemTotal: 4 kB\nMemFree: 2 kB\nCached: 1 kB\n", expTotal: 4096, expFree: 2048},
+		{contents: "MemTotal: 8 kB\nMemFree: 3 kB\n", expTotal: 8192, expFree: 3072},
+	} {
+		out := testStruct{}
+		if err := Unmarshal([]byte(tbl.contents), ":", &out); err != nil {
+			t.Fatalf("%d: failed to unmarshal: %s", i, err)
+		}
+		if out.MemTotal != tbl.expTotal || out.MemFree != tbl.expFree {
+			t.Errorf("%d: unexpected values: MemTotal %d, MemFree %d; expected %d, %d",
+				i, out.MemTotal, out.MemFree, tbl.expTotal, tbl.expFree)
+		}
+	}
+
+	if p1, p2 := cachedParser[testStruct](":"), cachedParser[testStruct](":"); p1 != p2 {
+		t.Error("expected repeated calls to reuse the cached parser")
+	}
+	if cachedParser[testStruct](":") == cachedParser[testStruct](" ") {
+		t.Error("expected distinct parsers for distinct split keys")
+	}
+
+	// A different type with the same splitKey must not collide.
+	type otherStruct struct {
+		MemTotal string
+	}
+	other := otherStruct{}
+	if err := Unmarshal([]byte("MemTotal: 4 kB\n"), ":", &other); err != nil {
+		t.Fatalf("failed to unmarshal: %s", err)
+	}
+	if other.MemTotal != "4 kB" {
+		t.Errorf("unexpected value for MemTotal: %q; expected \"4 kB\"", other.MemTotal)
+	}
+}
@@ -0,0 +1,105 @@
+package procstats
+
+import (
+	"fmt"
+	"os/exec"
+	"time"
+)
+
+// rusageSamplingInterval is the interval at which RunAndMeasure samples a
+// running command on platforms where the exited process's resource usage
+// is unavailable.
+const rusageSamplingInterval = 50 * time.Millisecond
+
+// RunAndMeasure starts the command, waits for it to exit, and returns the
+// CPU time it consumed and its peak RSS in bytes.
+//
+// On unix platforms, these come from the rusage the kernel reports when the
+// process is reaped (cmd.ProcessState.SysUsage()), so short-lived commands
+// are measured accurately. Note that ru_maxrss is reported in kilobytes on
+// linux and the BSDs, but in bytes on darwin; RunAndMeasure always returns
+// bytes. On windows, the CPU time similarly comes from the exited process's
+// kernel and user times, but the peak working set isn't available once it's
+// exited, so that's sampled with MaxRSS while the command runs (and is -1,
+// along with an error, if the command exits before the first sample). On
+// other platforms, RunAndMeasure falls back to sampling ProcessCPUTime and
+// MaxRSS while the command runs, which may undercount anything that happens
+// between the last sample and the command's exit.
+//
+// If the command ran, but exited unsuccessfully, the measurements are
+// returned along with the error from cmd.Wait().
+func RunAndMeasure(cmd *exec.Cmd) (CPUTime, int64, error) {
+	if startErr := cmd.Start(); startErr != nil {
+		return CPUTime{}, -1, fmt.Errorf("failed to start command: %w", startErr)
+	}
+
+	var samples chan rusageSample
+	done := make(chan struct{})
+	if !haveProcessStateRusage {
+		samples = make(chan rusageSample, 1)
+		go sampleUntilDone(cmd.Process.Pid, done, samples)
+	}
+
+	waitErr := cmd.Wait()
+	close(done)
+
+	ct, maxRSS, haveUsage := processStateUsage(cmd.ProcessState)
+	if haveUsage && maxRSS >= 0 {
+		return ct, maxRSS, waitErr
+	}
+	if samples == nil {
+		return CPUTime{}, -1, fmt.Errorf("resource usage unavailable for exited command: %w",
+			ErrUnimplementedPlatform)
+	}
+	s := <-samples
+	if haveUsage {
+		// The exited process's CPU time is exact, so only the peak
+		// RSS comes from the samples.
+		if s.err != nil {
+			return ct, -1, fmt.Errorf("failed to sample command's peak RSS: %w", s.err)
+		}
+		return ct, s.maxRSS, waitErr
+	}
+	if s.err != nil {
+		return CPUTime{}, -1, fmt.Errorf("failed to sample command's resource usage: %w", s.err)
+	}
+	return s.cpuTime, s.maxRSS, waitErr
+}
+
+type rusageSample struct {
+	cpuTime CPUTime
+	maxRSS  int64
+	err     error
+}
+
+// sampleUntilDone polls the CPU time and max RSS of pid until done is
+// closed, then sends the last successful sample on out.
+func sampleUntilDone(pid int, done <-chan struct{}, out chan<- rusageSample) {
+	t := time.NewTicker(rusageSamplingInterval)
+	defer t.Stop()
+
+	last := rusageSample{maxRSS: -1}
+	sampled := false
+	for {
+		ct, ctErr := readProcessCPUTime(pid)
+		maxRSS, rssErr := readMaxRSS(pid)
+		if ctErr == nil && rssErr == nil {
+			last = rusageSample{cpuTime: ct, maxRSS: max(maxRSS, last.maxRSS)}
+			sampled = true
+		} else if !sampled {
+			// Keep the first error around in case we never manage
+			// to get a sample.
+			if ctErr != nil {
+				last.err = ctErr
+			} else {
+				last.err = rssErr
+			}
+		}
+		select {
+		case <-done:
+			out <- last
+			return
+		case <-t.C:
+		}
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !unix && !windows
+// +build !unix,!windows
+
+package procstats
+
+import "os"
+
+// haveProcessStateRusage indicates whether processStateUsage is expected to
+// report both the CPU time and peak RSS on this platform; if not,
+// RunAndMeasure samples the running command.
+const haveProcessStateRusage = false
+
+func processStateUsage(ps *os.ProcessState) (CPUTime, int64, bool) {
+	return CPUTime{}, -1, false
+}
@@ -0,0 +1,38 @@
+//go:build unix
+// +build unix
+
+package procstats
+
+import (
+	"os"
+	"runtime"
+	"syscall"
+	"time"
+)
+
+// haveProcessStateRusage indicates whether processStateUsage is expected to
+// report both the CPU time and peak RSS on this platform; if not,
+// RunAndMeasure samples the running command.
+const haveProcessStateRusage = true
+
+func processStateUsage(ps *os.ProcessState) (CPUTime, int64, bool) {
+	if ps == nil {
+		return CPUTime{}, -1, false
+	}
+	ru, ok := ps.SysUsage().(*syscall.Rusage)
+	if !ok || ru == nil {
+		return CPUTime{}, -1, false
+	}
+	// From getrusage(2):
+	//   ru_maxrss (since Linux 2.6.32)
+	//          This is the maximum resident set size used (in kilobytes).
+	// darwin, however, reports ru_maxrss in bytes.
+	maxRSS := int64(ru.Maxrss)
+	if runtime.GOOS != "darwin" && runtime.GOOS != "ios" {
+		maxRSS *= 1024
+	}
+	return CPUTime{
+		Utime: time.Duration(ru.Utime.Nano()),
+		Stime: time.Duration(ru.Stime.Nano()),
+	}, maxRSS, true
+}
@@ -0,0 +1,44 @@
+package procstats
+
+import (
+	"os/exec"
+	"testing"
+)
+
+func TestRunAndMeasure(t *testing.T) {
+	t.Parallel()
+	sh, lookErr := exec.LookPath("sh")
+	if lookErr != nil {
+		t.Skipf("no shell available: %s", lookErr)
+	}
+	cmd := exec.Command(sh, "-c", "i=0; while [ $i -lt 100000 ]; do i=$((i+1)); done")
+	ct, maxRSS, err := RunAndMeasure(cmd)
+	if err != nil {
+		t.Fatalf("failed to run and measure command: %s", err)
+	}
+	t.Logf("cpu time: %+v; max RSS: %d", ct, maxRSS)
+	if ct.Utime < 0 || ct.Stime < 0 {
+		t.Errorf("unexpectedly negative CPU time: %+v", ct)
+	}
+	if ct.eq(&CPUTime{}) {
+		t.Errorf("want: <non-zero>, got: %+v", ct)
+	}
+	if maxRSS <= 0 {
+		t.Errorf("unexpectedly non-positive max RSS: %d", maxRSS)
+	}
+}
+
+func TestRunAndMeasureExitError(t *testing.T) {
+	t.Parallel()
+	sh, lookErr := exec.LookPath("sh")
+	if lookErr != nil {
+		t.Skipf("no shell available: %s", lookErr)
+	}
+	_, maxRSS, err := RunAndMeasure(exec.Command(sh, "-c", "exit 3"))
+	if _, ok := err.(*exec.ExitError); !ok {
+		t.Fatalf("expected *exec.ExitError; got %T: %v", err, err)
+	}
+	if maxRSS <= 0 {
+		t.Errorf("unexpectedly non-positive max RSS: %d", maxRSS)
+	}
+}
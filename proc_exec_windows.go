@@ -0,0 +1,31 @@
+//go:build windows
+// +build windows
+
+package procstats
+
+import (
+	"os"
+	"syscall"
+)
+
+// haveProcessStateRusage indicates whether processStateUsage is expected to
+// report both the CPU time and peak RSS on this platform; if not,
+// RunAndMeasure samples the running command. The exited process's kernel
+// and user times are available on windows, but its peak working set isn't.
+const haveProcessStateRusage = false
+
+// processStateUsage returns the CPU time of the exited process from its
+// kernel and user times, with a peak RSS of -1 (unknown).
+func processStateUsage(ps *os.ProcessState) (CPUTime, int64, bool) {
+	if ps == nil {
+		return CPUTime{}, -1, false
+	}
+	ru, ok := ps.SysUsage().(*syscall.Rusage)
+	if !ok || ru == nil {
+		return CPUTime{}, -1, false
+	}
+	return CPUTime{
+		Utime: filetimeDuration(&ru.UserTime),
+		Stime: filetimeDuration(&ru.KernelTime),
+	}, -1, true
+}
@@ -0,0 +1,32 @@
+//go:build linux
+// +build linux
+
+package procstats
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+)
+
+func readFDCount(pid int) (int, error) {
+	fdDir := procFileName(pid, "fd")
+	d, openErr := os.Open(fdDir)
+	if openErr != nil {
+		if errors.Is(openErr, fs.ErrPermission) {
+			return -1, fmt.Errorf("%w: failed to open %q: %w",
+				ErrPermissionDenied, fdDir, openErr)
+		}
+		return -1, fmt.Errorf("failed to open %q: %w", fdDir, openErr)
+	}
+	defer d.Close()
+	// Readdirnames doesn't stat the entries (and omits "." and ".."), so
+	// descriptors that are closed mid-scan can't make this fail; we just
+	// get the count as of whenever the kernel listed each chunk.
+	names, readErr := d.Readdirnames(-1)
+	if readErr != nil {
+		return -1, fmt.Errorf("failed to list %q: %w", fdDir, readErr)
+	}
+	return len(names), nil
+}
@@ -0,0 +1,39 @@
+package procstats
+
+import (
+	"os"
+	"testing"
+)
+
+func TestFDCountSelf(t *testing.T) {
+	pid := os.Getpid()
+	before, err := FDCount(pid)
+	if err != nil {
+		t.Fatalf("failed to count fds: %s", err)
+	}
+	// stdin, stdout and stderr, at least
+	if before < 3 {
+		t.Errorf("unexpectedly small fd count: %d", before)
+	}
+
+	const extraFDs = 4
+	for i := 0; i < extraFDs; i++ {
+		f, openErr := os.Open(os.DevNull)
+		if openErr != nil {
+			t.Fatalf("failed to open %s: %s", os.DevNull, openErr)
+		}
+		defer f.Close()
+	}
+
+	after, err := FDCount(pid)
+	if err != nil {
+		t.Fatalf("failed to count fds: %s", err)
+	}
+	// other goroutines (e.g. the runtime's netpoller) may open fds
+	// concurrently, so only require that the count went up by at least
+	// what we opened.
+	if after < before+extraFDs {
+		t.Errorf("fd count %d after opening %d files; expected at least %d",
+			after, extraFDs, before+extraFDs)
+	}
+}
@@ -0,0 +1,27 @@
+package procstats
+
+// IOStat contains the I/O accounting counters for a process.
+type IOStat struct {
+	// RChar is the number of bytes read via read(2) and similar
+	// syscalls (including from pipes, ttys and the page cache)
+	RChar uint64 `pparser:"rchar"`
+	// WChar is the number of bytes written via write(2) and similar
+	// syscalls
+	WChar uint64 `pparser:"wchar"`
+	// Syscr is the number of read syscalls
+	Syscr uint64 `pparser:"syscr"`
+	// Syscw is the number of write syscalls
+	Syscw uint64 `pparser:"syscw"`
+	// ReadBytes is the number of bytes actually fetched from the storage
+	// layer
+	ReadBytes uint64 `pparser:"read_bytes"`
+	// WriteBytes is the number of bytes caused to be sent to the storage
+	// layer
+	WriteBytes uint64 `pparser:"write_bytes"`
+	// CancelledWriteBytes is the number of bytes that were accounted in
+	// WriteBytes, but never written out (e.g. due to truncation of dirty
+	// page-cache)
+	CancelledWriteBytes uint64 `pparser:"cancelled_write_bytes"`
+
+	UnknownFields map[string]uint64 `pparser:"skip,unknown"`
+}
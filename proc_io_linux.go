@@ -0,0 +1,42 @@
+//go:build linux
+// +build linux
+
+package procstats
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/vimeo/procstats/pparser"
+)
+
+var procPidIOParser = pparser.NewLineKVFileParser(IOStat{}, ":")
+
+// ProcessIO reads the I/O accounting counters for the process with PID pid
+// from /proc/[pid]/io.
+// Reading another process's io file requires ptrace-read access to it
+// (usually the same UID, or CAP_SYS_PTRACE); if that's missing, the returned
+// error wraps ErrPermissionDenied.
+// It is only implemented on linux.
+func ProcessIO(pid int) (IOStat, error) {
+	ioPath := procFileName(pid, "io")
+	contents, err := os.ReadFile(ioPath)
+	if err != nil {
+		if errors.Is(err, fs.ErrPermission) {
+			return IOStat{}, fmt.Errorf("%w: failed to read %q: %w",
+				ErrPermissionDenied, ioPath, err)
+		}
+		return IOStat{}, fmt.Errorf("failed to read %q: %w", ioPath, err)
+	}
+	return parseProcPidIO(contents)
+}
+
+func parseProcPidIO(contents []byte) (IOStat, error) {
+	out := IOStat{}
+	if parseErr := procPidIOParser.Parse(contents, &out); parseErr != nil {
+		return IOStat{}, fmt.Errorf("failed to parse io: %w", parseErr)
+	}
+	return out, nil
+}
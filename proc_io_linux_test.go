@@ -0,0 +1,58 @@
+package procstats
+
+import (
+	"os"
+	"testing"
+)
+
+func TestParseProcPidIO(t *testing.T) {
+	const contents = `rchar: 323934931
+wchar: 323929600
+syscr: 632687
+syscw: 632675
+read_bytes: 8192
+write_bytes: 323932160
+cancelled_write_bytes: 4096
+`
+	st, err := parseProcPidIO([]byte(contents))
+	if err != nil {
+		t.Fatalf("failed to parse io: %s", err)
+	}
+	exp := IOStat{
+		RChar:               323934931,
+		WChar:               323929600,
+		Syscr:               632687,
+		Syscw:               632675,
+		ReadBytes:           8192,
+		WriteBytes:          323932160,
+		CancelledWriteBytes: 4096,
+	}
+	if st.RChar != exp.RChar || st.WChar != exp.WChar || st.Syscr != exp.Syscr ||
+		st.Syscw != exp.Syscw || st.ReadBytes != exp.ReadBytes ||
+		st.WriteBytes != exp.WriteBytes || st.CancelledWriteBytes != exp.CancelledWriteBytes {
+		t.Errorf("unexpected IOStat:\n  got %+v\n want %+v", st, exp)
+	}
+	if len(st.UnknownFields) != 0 {
+		t.Errorf("unexpected unknown fields: %v", st.UnknownFields)
+	}
+}
+
+func TestProcessIOSelf(t *testing.T) {
+	before, err := ProcessIO(os.Getpid())
+	if err != nil {
+		t.Fatalf("failed to read io: %s", err)
+	}
+	if _, err := os.ReadFile("/proc/self/status"); err != nil {
+		t.Fatalf("failed to read status: %s", err)
+	}
+	after, err := ProcessIO(os.Getpid())
+	if err != nil {
+		t.Fatalf("failed to read io: %s", err)
+	}
+	if after.Syscr <= before.Syscr {
+		t.Errorf("read syscall count didn't increase: before %d; after %d", before.Syscr, after.Syscr)
+	}
+	if after.RChar <= before.RChar {
+		t.Errorf("rchar didn't increase: before %d; after %d", before.RChar, after.RChar)
+	}
+}
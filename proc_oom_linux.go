@@ -0,0 +1,60 @@
+//go:build linux
+// +build linux
+
+package procstats
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+)
+
+// From the proc(5) manpage:
+// /proc/[pid]/oom_score (since Linux 2.6.11)
+//        This file displays the current score that the kernel gives to
+//        this process for the purpose of selecting a process for the
+//        OOM-killer.  A higher score means that the process is more
+//        likely to be selected by the OOM-killer.
+//
+// /proc/[pid]/oom_score_adj (since Linux 2.6.36)
+//        This file can be used to adjust the badness heuristic used to
+//        select which process gets killed in out-of-memory conditions.
+//        [...] The value of oom_score_adj is added to the badness score
+//        before it is used to determine which task to kill.  Acceptable
+//        values range from -1000 (OOM_SCORE_ADJ_MIN) to +1000
+//        (OOM_SCORE_ADJ_MAX).
+
+// OOMScore returns the OOM-killer badness score of the process with PID pid,
+// along with the adjustment (oom_score_adj, in [-1000, 1000]) already
+// factored into that score. When memory runs out, the kernel kills the
+// process with the highest score.
+// It is only implemented on linux.
+func OOMScore(pid int) (score int, adj int, err error) {
+	score, err = readProcIntFile(pid, "oom_score")
+	if err != nil {
+		return -1, 0, fmt.Errorf("failed to get OOM score: %s", err)
+	}
+	adj, err = readProcIntFile(pid, "oom_score_adj")
+	if err != nil {
+		return -1, 0, fmt.Errorf("failed to get OOM score adjustment: %s", err)
+	}
+	return score, adj, nil
+}
+
+// readProcIntFile reads a procfs file for the process with PID pid that
+// contains a single decimal integer.
+func readProcIntFile(pid int, leafName string) (int, error) {
+	c, err := procFileContents(pid, leafName)
+	if err != nil {
+		return 0, err
+	}
+	return parseIntFile(c, leafName)
+}
+
+func parseIntFile(b []byte, leafName string) (int, error) {
+	v, err := strconv.Atoi(string(bytes.TrimSpace(b)))
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse %s: %s", leafName, err)
+	}
+	return v, nil
+}
@@ -0,0 +1,48 @@
+package procstats
+
+import (
+	"os"
+	"testing"
+)
+
+func TestParseIntFile(t *testing.T) {
+	for _, tbl := range []struct {
+		contents string
+		exp      int
+		expErr   bool
+	}{
+		{contents: "667\n", exp: 667},
+		{contents: "-1000\n", exp: -1000},
+		{contents: "0", exp: 0},
+		{contents: "", expErr: true},
+		{contents: "abc\n", expErr: true},
+	} {
+		v, err := parseIntFile([]byte(tbl.contents), "oom_score")
+		if tbl.expErr {
+			if err == nil {
+				t.Errorf("expected error parsing %q; got %d", tbl.contents, v)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("failed to parse %q: %s", tbl.contents, err)
+			continue
+		}
+		if v != tbl.exp {
+			t.Errorf("unexpected value parsing %q: %d; expected %d", tbl.contents, v, tbl.exp)
+		}
+	}
+}
+
+func TestOOMScoreSelf(t *testing.T) {
+	score, adj, err := OOMScore(os.Getpid())
+	if err != nil {
+		t.Fatalf("failed to read OOM score: %s", err)
+	}
+	if score < 0 {
+		t.Errorf("unexpectedly negative OOM score: %d", score)
+	}
+	if adj < -1000 || adj > 1000 {
+		t.Errorf("OOM score adjustment %d out of range [-1000, 1000]", adj)
+	}
+}
@@ -6,8 +6,6 @@ package procstats
 import (
 	"fmt"
 	"os"
-	"path/filepath"
-	"strconv"
 
 	"github.com/vimeo/procstats/pparser"
 )
@@ -50,16 +48,16 @@ type ProcPidStatus struct {
 	CoreDumping              int64
 	Threads                  int64
 	SigQ                     string
-	SigPnd                   string
-	ShdPnd                   string
-	SigBlk                   string
-	SigIgn                   string
-	SigCgt                   string
-	CapInh                   string
-	CapPrm                   string
-	CapEff                   string
-	CapBnd                   string
-	CapAmb                   string
+	SigPnd                   uint64 `pparser:"SigPnd,hex"`
+	ShdPnd                   uint64 `pparser:"ShdPnd,hex"`
+	SigBlk                   uint64 `pparser:"SigBlk,hex"`
+	SigIgn                   uint64 `pparser:"SigIgn,hex"`
+	SigCgt                   uint64 `pparser:"SigCgt,hex"`
+	CapInh                   uint64 `pparser:"CapInh,hex"`
+	CapPrm                   uint64 `pparser:"CapPrm,hex"`
+	CapEff                   uint64 `pparser:"CapEff,hex"`
+	CapBnd                   uint64 `pparser:"CapBnd,hex"`
+	CapAmb                   uint64 `pparser:"CapAmb,hex"`
 	NoNewPrivs               string
 	Seccomp                  string
 	SpeculationStoreBypass   string            `pparser:"Speculation_Store_Bypass"`
@@ -80,7 +78,7 @@ var procPidStatusParser = pparser.NewLineKVFileParser(ProcPidStatus{}, ":")
 // Portable applications should use the higher-level wrappers in this package
 // (ProcessCPUTime, MaxRSS, and RSS) rather than the low-level.
 func ReadProcStatus(pid int) (*ProcPidStatus, error) {
-	statusPath := filepath.Join("/proc", strconv.Itoa(pid), "status")
+	statusPath := procFileName(pid, "status")
 	contents, err := os.ReadFile(statusPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read file %q: %s",
@@ -96,6 +94,47 @@ func ReadProcStatus(pid int) (*ProcPidStatus, error) {
 
 }
 
+// RunState returns the state letter of the process (e.g. 'R' for running,
+// 'S' for sleeping, 'D' for uninterruptible disk sleep, 'Z' for zombie or
+// 'T' for stopped), without the parenthesized description. It returns 0 if
+// State is empty.
+func (s *ProcPidStatus) RunState() byte {
+	if s.State == "" {
+		return 0
+	}
+	return s.State[0]
+}
+
+// IsZombie reports whether the process has exited, but not yet been reaped
+// by its parent.
+func (s *ProcPidStatus) IsZombie() bool {
+	return s.RunState() == 'Z'
+}
+
+// AllowedCPUs expands CpusAllowedList (e.g. "0-3,7") into a sorted slice
+// of the CPU numbers the process may run on.
+func (s *ProcPidStatus) AllowedCPUs() ([]int, error) {
+	cpus, err := pparser.ParseRangeList(s.CpusAllowedList)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Cpus_allowed_list: %w", err)
+	}
+	return cpus, nil
+}
+
+// RSSBreakdown returns the resident memory of the process with PID pid,
+// split into anonymous, file-backed and shared-memory (shmem, including
+// tmpfs and SysV shm) pages, all in bytes, from /proc/[pid]/status.
+// The three sum to the process's RSS. (all zero on kernels older than 4.5,
+// which don't report the breakdown)
+// It is only implemented on linux.
+func RSSBreakdown(pid int) (anon, file, shmem int64, err error) {
+	status, err := ReadProcStatus(pid)
+	if err != nil {
+		return -1, -1, -1, fmt.Errorf("failed to obtain status: %s", err)
+	}
+	return status.RssAnon, status.RssFile, status.RssShmem, nil
+}
+
 func readMaxRSS(pid int) (int64, error) {
 	status, err := ReadProcStatus(pid)
 	if err != nil {
@@ -105,7 +144,7 @@ func readMaxRSS(pid int) (int64, error) {
 }
 
 func resetMaxRSS(pid int) error {
-	refsPath := filepath.Join("/proc", strconv.Itoa(pid), "clear_refs")
+	refsPath := procFileName(pid, "clear_refs")
 	// From the proc(5) manpage:
 	//
 	//      This is a write-only file, writable only by owner of the process.
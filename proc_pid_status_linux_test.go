@@ -1,9 +1,12 @@
 package procstats
 
-import "testing"
+import (
+	"os"
+	"slices"
+	"testing"
+)
 
-func TestProcPidStatusParse(t *testing.T) {
-	procSelfStatus := `Name:	vim
+const testProcSelfStatus = `Name:	vim
 Umask:	0022
 State:	R (running)
 Tgid:	22987
@@ -58,8 +61,9 @@ Mems_allowed_list:	0
 voluntary_ctxt_switches:	1163
 nonvoluntary_ctxt_switches:	597`
 
+func TestProcPidStatusParse(t *testing.T) {
 	out := ProcPidStatus{}
-	if parseErr := procPidStatusParser.Parse([]byte(procSelfStatus), &out); parseErr != nil {
+	if parseErr := procPidStatusParser.Parse([]byte(testProcSelfStatus), &out); parseErr != nil {
 		t.Fatalf("failed to parse: %s", parseErr)
 	}
 
@@ -68,3 +72,137 @@ nonvoluntary_ctxt_switches:	597`
 			46661*1024)
 	}
 }
+
+func TestRSSBreakdownSelf(t *testing.T) {
+	anon, file, shmem, err := RSSBreakdown(os.Getpid())
+	if err != nil {
+		t.Fatalf("failed to read RSS breakdown: %s", err)
+	}
+	if anon <= 0 {
+		t.Errorf("unexpectedly non-positive anonymous RSS: %d", anon)
+	}
+	if file < 0 || shmem < 0 {
+		t.Errorf("unexpectedly negative file (%d) or shmem (%d) RSS", file, shmem)
+	}
+}
+
+func TestProcPidStatusRunState(t *testing.T) {
+	out := ProcPidStatus{}
+	if parseErr := procPidStatusParser.Parse([]byte(testProcSelfStatus), &out); parseErr != nil {
+		t.Fatalf("failed to parse: %s", parseErr)
+	}
+	if st := out.RunState(); st != 'R' {
+		t.Errorf("unexpected run state: %q; expected 'R'", st)
+	}
+	if out.IsZombie() {
+		t.Error("running process unexpectedly reported as a zombie")
+	}
+
+	for _, tbl := range []struct {
+		state     string
+		expState  byte
+		expZombie bool
+	}{
+		{state: "S (sleeping)", expState: 'S'},
+		{state: "D (disk sleep)", expState: 'D'},
+		{state: "T (stopped)", expState: 'T'},
+		{state: "Z (zombie)", expState: 'Z', expZombie: true},
+		{state: "", expState: 0},
+	} {
+		st := ProcPidStatus{State: tbl.state}
+		if rs := st.RunState(); rs != tbl.expState {
+			t.Errorf("unexpected run state for %q: %q; expected %q", tbl.state, rs, tbl.expState)
+		}
+		if z := st.IsZombie(); z != tbl.expZombie {
+			t.Errorf("unexpected IsZombie for %q: %t; expected %t", tbl.state, z, tbl.expZombie)
+		}
+	}
+}
+
+func TestProcPidStatusAllowedCPUs(t *testing.T) {
+	out := ProcPidStatus{}
+	if parseErr := procPidStatusParser.Parse([]byte(testProcSelfStatus), &out); parseErr != nil {
+		t.Fatalf("failed to parse: %s", parseErr)
+	}
+	for _, tbl := range []struct {
+		list   string
+		exp    []int
+		expErr bool
+	}{
+		{list: out.CpusAllowedList, exp: []int{0, 1, 2, 3}},
+		{list: "0,2,4", exp: []int{0, 2, 4}},
+		{list: "1-2,5-6", exp: []int{1, 2, 5, 6}},
+		{list: "3-1", expErr: true},
+		{list: "0,x", expErr: true},
+	} {
+		st := ProcPidStatus{CpusAllowedList: tbl.list}
+		cpus, err := st.AllowedCPUs()
+		if tbl.expErr {
+			if err == nil {
+				t.Errorf("expected error for %q; got %v", tbl.list, cpus)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("unexpected error for %q: %s", tbl.list, err)
+			continue
+		}
+		if !slices.Equal(cpus, tbl.exp) {
+			t.Errorf("unexpected CPUs for %q: %v; expected %v", tbl.list, cpus, tbl.exp)
+		}
+	}
+}
+
+func TestProcPidStatusSignals(t *testing.T) {
+	out := ProcPidStatus{}
+	if parseErr := procPidStatusParser.Parse([]byte(testProcSelfStatus), &out); parseErr != nil {
+		t.Fatalf("failed to parse: %s", parseErr)
+	}
+
+	pending, blocked, ignored, caught := out.signalMasks()
+	if pending != 0 {
+		t.Errorf("unexpected pending mask: %#x; expected 0", pending)
+	}
+	if blocked != 0 {
+		t.Errorf("unexpected blocked mask: %#x; expected 0", blocked)
+	}
+	if ignored != 0x3000 {
+		t.Errorf("unexpected ignored mask: %#x; expected 0x3000", ignored)
+	}
+	if caught != 0x1ef824eff {
+		t.Errorf("unexpected caught mask: %#x; expected 0x1ef824eff", caught)
+	}
+
+	cur, max, sigQErr := out.sigQueue()
+	if sigQErr != nil {
+		t.Fatalf("failed to parse SigQ: %s", sigQErr)
+	}
+	if cur != 0 || max != 78835 {
+		t.Errorf("unexpected SigQ values: %d/%d; expected 0/78835", cur, max)
+	}
+
+	out.SigQ = "12"
+	if _, _, err := out.sigQueue(); err == nil {
+		t.Error("expected error for malformed SigQ")
+	}
+	if err := procPidStatusParser.Parse([]byte("ShdPnd:\tnot hex\n"), &out); err == nil {
+		t.Error("expected error for malformed ShdPnd")
+	}
+}
+
+func TestPendingSignalsSelf(t *testing.T) {
+	_, _, _, caught, err := PendingSignals(os.Getpid())
+	if err != nil {
+		t.Fatalf("failed to read signal masks: %s", err)
+	}
+	// The go runtime installs handlers for most signals, including SIGURG
+	// (23) for async preemption.
+	if caught&(1<<(23-1)) == 0 {
+		t.Errorf("expected SIGURG to be caught; got caught mask %#x", caught)
+	}
+	if _, max, err := SigQueueLen(os.Getpid()); err != nil {
+		t.Errorf("failed to read SigQ: %s", err)
+	} else if max <= 0 {
+		t.Errorf("unexpectedly non-positive queued signal limit: %d", max)
+	}
+}
@@ -2,6 +2,7 @@ package procstats
 
 import (
 	"os"
+	"path/filepath"
 	"strconv"
 	"testing"
 	"time"
@@ -18,31 +19,49 @@ func TestReadCPUUsage(t *testing.T) {
 	}{
 		{
 			Name: "zeroes",
-			Stat: []byte("x x x x x x x x x x x x x 0 0 0 0 x"),
+			Stat: []byte("x (x) x x x x x x x x x x x 0 0 0 0 x"),
 			Err:  false,
 			Want: CPUTime{},
 		},
 		{
 			Name: "err parse",
-			Stat: []byte("x x x x x x x x x x x x 0 0 0 0"),
+			Stat: []byte("x (x) x x x x x x x x x x 0 0 0 0"),
 			Err:  true,
 			Want: CPUTime{},
 		},
 		{
 			Name: "err fmt utime",
-			Stat: []byte("x x x x x x x x x x x x x x 0 0 0 x"),
+			Stat: []byte("x (x) x x x x x x x x x x x x 0 0 0 x"),
 			Err:  true,
 			Want: CPUTime{},
 		},
 		{
 			Name: "err fmt stime",
-			Stat: []byte("x x x x x x x x x x x x x 0 0 0 x x"),
+			Stat: []byte("x (x) x x x x x x x x x x x 0 0 0 x x"),
+			Err:  true,
+			Want: CPUTime{},
+		},
+		{
+			Name: "comm with spaces",
+			Stat: []byte("x (a b) x x x x x x x x x x x 0 0 0 0 x"),
+			Err:  false,
+			Want: CPUTime{},
+		},
+		{
+			Name: "comm with parens",
+			Stat: []byte("x (weird )proc) x x x x x x x x x x x 0 0 0 0 x"),
+			Err:  false,
+			Want: CPUTime{},
+		},
+		{
+			Name: "err comm with parens insufficient fields",
+			Stat: []byte("x (weird ) 0 0 0 0 proc) x x x x x x x x x x x 0 0 0"),
 			Err:  true,
 			Want: CPUTime{},
 		},
 		{
 			Name: "parse",
-			Stat: []byte("x x x x x x x x x x x x x "),
+			Stat: []byte("x (x) x x x x x x x x x x x "),
 			Err:  false,
 			Want: CPUTime{2 * dur, 2 * dur},
 		},
@@ -96,3 +115,266 @@ func TestReadCPUUsage(t *testing.T) {
 		}
 	})
 }
+
+func TestReadCPUUsageSelfOnly(t *testing.T) {
+	res := cpuTimeResolution()
+	stat := []byte("x (x) x x x x x x x x x x x 10 20 3 4 x")
+	withChildren, err := linuxParseCPUTime(stat)
+	if err != nil {
+		t.Fatalf("failed to parse CPU time: %s", err)
+	}
+	selfOnly, err := linuxParseStatCPUTime(stat, false)
+	if err != nil {
+		t.Fatalf("failed to parse self-only CPU time: %s", err)
+	}
+	if exp := (CPUTime{Utime: 13 * res, Stime: 24 * res}); withChildren != exp {
+		t.Errorf("unexpected CPU time with children %+v; expected %+v", withChildren, exp)
+	}
+	if exp := (CPUTime{Utime: 10 * res, Stime: 20 * res}); selfOnly != exp {
+		t.Errorf("unexpected self-only CPU time %+v; expected %+v", selfOnly, exp)
+	}
+}
+
+func TestProcessCPUTimeSelfOnly(t *testing.T) {
+	pid := os.Getpid()
+	selfOnly, err := ProcessCPUTimeSelfOnly(pid)
+	if err != nil {
+		t.Fatalf("failed to read self-only CPU time: %s", err)
+	}
+	withChildren, err := ProcessCPUTime(pid)
+	if err != nil {
+		t.Fatalf("failed to read CPU time: %s", err)
+	}
+	// the second read can only have accumulated more CPU time
+	if withChildren.Utime < selfOnly.Utime || withChildren.Stime < selfOnly.Stime {
+		t.Errorf("CPU time with children %+v less than self-only %+v", withChildren, selfOnly)
+	}
+}
+
+func TestCPUTimeResolution(t *testing.T) {
+	res := CPUTimeResolution()
+	if res <= 0 || res > time.Second {
+		t.Fatalf("unexpected CPU time resolution %s", res)
+	}
+	if want := time.Second / time.Duration(sysClockTick()); res != want {
+		t.Errorf("unexpected CPU time resolution %s; expected %s", res, want)
+	}
+}
+
+func TestLinuxParsePageFaults(t *testing.T) {
+	for _, tbl := range []struct {
+		name   string
+		stat   string
+		expErr bool
+		exp    PageFaultStats
+	}{
+		{
+			name: "simple",
+			stat: "4242 (cat) R 1 4242 4242 34816 4242 4194304 92 7 3 1 0 0 0 0 20 0 1 0\n",
+			exp: PageFaultStats{
+				Minor:             92,
+				Major:             3,
+				MinorWithChildren: 99,
+				MajorWithChildren: 4,
+			},
+		},
+		{
+			name: "comm_with_spaces_and_parens",
+			stat: "4242 (a (b) c) R 1 4242 4242 34816 4242 4194304 92 7 3 1 0 0 0 0 20 0 1 0\n",
+			exp: PageFaultStats{
+				Minor:             92,
+				Major:             3,
+				MinorWithChildren: 99,
+				MajorWithChildren: 4,
+			},
+		},
+		{
+			name:   "insufficient_fields",
+			stat:   "4242 (cat) R 1 4242 4242 34816 4242 4194304 92 7\n",
+			expErr: true,
+		},
+		{
+			name:   "bad_majflt",
+			stat:   "4242 (cat) R 1 4242 4242 34816 4242 4194304 92 7 x 1 0 0 0 0 20 0 1 0\n",
+			expErr: true,
+		},
+	} {
+		t.Run(tbl.name, func(t *testing.T) {
+			pf, err := linuxParsePageFaults([]byte(tbl.stat))
+			if tbl.expErr {
+				if err == nil {
+					t.Fatalf("expected error; got %+v", pf)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if pf != tbl.exp {
+				t.Errorf("unexpected page faults %+v; expected %+v", pf, tbl.exp)
+			}
+		})
+	}
+}
+
+func TestLinuxParseCPUTimeAndPageFaults(t *testing.T) {
+	res := cpuTimeResolution()
+	for _, tbl := range []struct {
+		name   string
+		stat   string
+		expErr bool
+		expCPU CPUTime
+		expPF  PageFaultStats
+	}{
+		{
+			name:   "simple",
+			stat:   "4242 (cat) R 1 4242 4242 34816 4242 4194304 92 7 3 1 10 20 1 2 20 0 1 0\n",
+			expCPU: CPUTime{Utime: 11 * res, Stime: 22 * res},
+			expPF: PageFaultStats{
+				Minor:             92,
+				Major:             3,
+				MinorWithChildren: 99,
+				MajorWithChildren: 4,
+			},
+		},
+		{
+			// enough fields for the page faults, but not the CPU time
+			name:   "insufficient_fields",
+			stat:   "4242 (cat) R 1 4242 4242 34816 4242 4194304 92 7 3 1 10 20\n",
+			expErr: true,
+		},
+		{
+			name:   "bad_cminflt",
+			stat:   "4242 (cat) R 1 4242 4242 34816 4242 4194304 92 x 3 1 10 20 1 2 20 0 1 0\n",
+			expErr: true,
+		},
+	} {
+		t.Run(tbl.name, func(t *testing.T) {
+			ct, pf, err := linuxParseCPUTimeAndPageFaults([]byte(tbl.stat))
+			if tbl.expErr {
+				if err == nil {
+					t.Fatalf("expected error; got %+v, %+v", ct, pf)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if ct != tbl.expCPU {
+				t.Errorf("unexpected CPU time %+v; expected %+v", ct, tbl.expCPU)
+			}
+			if pf != tbl.expPF {
+				t.Errorf("unexpected page faults %+v; expected %+v", pf, tbl.expPF)
+			}
+		})
+	}
+}
+
+func TestPageFaultsSelf(t *testing.T) {
+	pf, err := PageFaults(os.Getpid())
+	if err != nil {
+		t.Fatalf("failed to read page faults: %s", err)
+	}
+	if pf.Minor == 0 {
+		t.Errorf("want: <non-zero> minor faults, got: %+v", pf)
+	}
+	if pf.MinorWithChildren < pf.Minor || pf.MajorWithChildren < pf.Major {
+		t.Errorf("counts with children less than without: %+v", pf)
+	}
+}
+
+func TestSetProcRoot(t *testing.T) {
+	root := t.TempDir()
+	pidDir := filepath.Join(root, "4242")
+	if err := os.Mkdir(pidDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, contents := range map[string]string{
+		"status": testProcSelfStatus,
+		"statm":  "1000 200 50 10 0 300 0\n",
+	} {
+		if err := os.WriteFile(filepath.Join(pidDir, name), []byte(contents), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	SetProcRoot(root)
+	t.Cleanup(func() { SetProcRoot("") })
+
+	status, err := ReadProcStatus(4242)
+	if err != nil {
+		t.Fatalf("failed to read status from %s: %s", root, err)
+	}
+	if status.Name != "vim" {
+		t.Errorf("unexpected process name %q; expected \"vim\"", status.Name)
+	}
+	rss, err := RSS(4242)
+	if err != nil {
+		t.Fatalf("failed to read RSS from %s: %s", root, err)
+	}
+	if exp := 200 * int64(os.Getpagesize()); rss != exp {
+		t.Errorf("unexpected RSS %d; expected %d", rss, exp)
+	}
+
+	SetProcRoot("")
+	if _, err := ReadProcStatus(os.Getpid()); err != nil {
+		t.Errorf("failed to read own status after restoring the default root: %s", err)
+	}
+}
+
+func TestStatReadersWithProcRoot(t *testing.T) {
+	// a snapshot of a single process, without a self entry
+	const stat = "4242 (my prog) S 1 4242 4242 0 -1 4194560 92 3 1 2 100 50 10 5 20 0 1 0 123456 5582848 256 18446744073709551615 1 1 0 0 0 0 0 0 0 0 0 0 17 3 0 0 0 0 0 0 0 0 0 0 0 0 0\n"
+	root := t.TempDir()
+	taskDir := filepath.Join(root, "4242", "task", "4242")
+	if err := os.MkdirAll(taskDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, dir := range []string{filepath.Join(root, "4242"), taskDir} {
+		if err := os.WriteFile(filepath.Join(dir, "stat"), []byte(stat), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	SetProcRoot(root)
+	t.Cleanup(func() { SetProcRoot("") })
+
+	wantCPU := CPUTime{
+		Utime: 110 * time.Second / time.Duration(sysClockTick()),
+		Stime: 55 * time.Second / time.Duration(sysClockTick()),
+	}
+	if ct, err := ProcessCPUTime(4242); err != nil {
+		t.Errorf("ProcessCPUTime failed: %s", err)
+	} else if ct != wantCPU {
+		t.Errorf("unexpected CPU time %+v; expected %+v", ct, wantCPU)
+	}
+	if _, err := ProcessCPUTimeSelfOnly(4242); err != nil {
+		t.Errorf("ProcessCPUTimeSelfOnly failed: %s", err)
+	}
+	if _, _, err := ProcessCPUTimeAndPageFaults(4242); err != nil {
+		t.Errorf("ProcessCPUTimeAndPageFaults failed: %s", err)
+	}
+	if ct, err := ProcessTreeCPUTime(4242); err != nil {
+		t.Errorf("ProcessTreeCPUTime failed: %s", err)
+	} else if ct != wantCPU {
+		t.Errorf("unexpected tree CPU time %+v; expected %+v", ct, wantCPU)
+	}
+	if _, err := ThreadCPUTime(4242, 4242); err != nil {
+		t.Errorf("ThreadCPUTime failed: %s", err)
+	}
+	if pf, err := PageFaults(4242); err != nil {
+		t.Errorf("PageFaults failed: %s", err)
+	} else if pf.Minor != 92 || pf.Major != 1 {
+		t.Errorf("unexpected page faults %+v", pf)
+	}
+	if _, rss, err := ProcessMemoryFromStat(4242); err != nil {
+		t.Errorf("ProcessMemoryFromStat failed: %s", err)
+	} else if exp := 256 * int64(os.Getpagesize()); rss != exp {
+		t.Errorf("unexpected RSS %d; expected %d", rss, exp)
+	}
+	if rss, err := ProcessTreeRSS(4242); err != nil {
+		t.Errorf("ProcessTreeRSS failed: %s", err)
+	} else if exp := 256 * int64(os.Getpagesize()); rss != exp {
+		t.Errorf("unexpected tree RSS %d; expected %d", rss, exp)
+	}
+}
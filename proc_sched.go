@@ -0,0 +1,12 @@
+package procstats
+
+// Scheduling policies, as reported by SchedPolicy.
+// These match the SCHED_* constants from linux's sched.h.
+const (
+	SchedOther    = 0
+	SchedFIFO     = 1
+	SchedRR       = 2
+	SchedBatch    = 3
+	SchedIdle     = 5
+	SchedDeadline = 6
+)
@@ -0,0 +1,57 @@
+//go:build linux
+// +build linux
+
+package procstats
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// excerpt from proc(5) man page section on /proc/[pid]/stat:
+//
+//               (40) rt_priority  %u  (since Linux 2.5.19)
+//                         Real-time scheduling priority, a number in the
+//                         range 1 to 99 for processes scheduled under a real-
+//                         time policy, or 0, for non-real-time processes (see
+//                         sched_setscheduler(2)).
+//
+//               (41) policy  %u  (since Linux 2.5.19)
+//                         Scheduling policy (see sched_setscheduler(2)).
+//                         Decode using the SCHED_* constants in linux/sched.h.
+
+// SchedPolicy returns the scheduling policy (one of the Sched* constants)
+// and real-time priority of the process with PID pid.
+// rtPriority is 0 for processes that aren't under a real-time policy
+// (SchedFIFO or SchedRR).
+// It is only implemented on linux.
+func SchedPolicy(pid int) (policy int, rtPriority int, err error) {
+	c, err := procFileContents(pid, "stat")
+	if err != nil {
+		return -1, -1, fmt.Errorf("failed to get scheduling policy: %s", err)
+	}
+	return linuxParseSchedPolicy(c)
+}
+
+func linuxParseSchedPolicy(b []byte) (policy int, rtPriority int, err error) {
+	statFields, err := splitStatFields(b)
+	if err != nil {
+		return -1, -1, err
+	}
+	if len(statFields) < 41 {
+		return -1, -1, fmt.Errorf("insufficient fields present in stat: %d",
+			len(statFields))
+	}
+
+	rtPrio, err := strconv.ParseUint(string(statFields[39]), 10, 32)
+	if err != nil {
+		return -1, -1, fmt.Errorf("failed to parse the rt_priority column of stat: %s",
+			err)
+	}
+	pol, err := strconv.ParseUint(string(statFields[40]), 10, 32)
+	if err != nil {
+		return -1, -1, fmt.Errorf("failed to parse the policy column of stat: %s",
+			err)
+	}
+	return int(pol), int(rtPrio), nil
+}
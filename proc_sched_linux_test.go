@@ -0,0 +1,79 @@
+package procstats
+
+import (
+	"os"
+	"testing"
+)
+
+func TestLinuxParseSchedPolicy(t *testing.T) {
+	for _, tbl := range []struct {
+		name      string
+		stat      string
+		expErr    bool
+		expPolicy int
+		expRTPrio int
+	}{
+		{
+			name:      "sched_other",
+			stat:      "4242 (cat) R 1 4242 4242 34816 4242 4194304 92 0 0 0 0 0 0 0 20 0 1 0 123456 5582848 256 18446744073709551615 1 1 0 0 0 0 0 0 0 0 0 0 17 3 0 0 0 0 0 0 0 0 0 0 0 0 0\n",
+			expPolicy: SchedOther,
+			expRTPrio: 0,
+		},
+		{
+			name:      "sched_fifo",
+			stat:      "4242 (rt) S 1 4242 4242 0 -1 4194560 92 0 0 0 0 0 0 0 -51 0 1 0 123456 5582848 256 18446744073709551615 1 1 0 0 0 0 0 0 0 0 0 0 17 3 50 1 0 0 0 0 0 0 0 0 0 0 0\n",
+			expPolicy: SchedFIFO,
+			expRTPrio: 50,
+		},
+		{
+			name:      "truncated_at_policy",
+			stat:      "4242 (rt) S 1 4242 4242 0 -1 4194560 92 0 0 0 0 0 0 0 -51 0 1 0 123456 5582848 256 18446744073709551615 1 1 0 0 0 0 0 0 0 0 0 0 17 3 99 2\n",
+			expPolicy: SchedRR,
+			expRTPrio: 99,
+		},
+		{
+			name:   "insufficient_fields",
+			stat:   "4242 (cat) R 1 4242 4242 34816 4242 4194304 92 0 0 0 0 0 0 0 20 0 1 0 123456\n",
+			expErr: true,
+		},
+		{
+			name:   "bad_policy",
+			stat:   "4242 (cat) R 1 4242 4242 34816 4242 4194304 92 0 0 0 0 0 0 0 20 0 1 0 123456 5582848 256 18446744073709551615 1 1 0 0 0 0 0 0 0 0 0 0 17 3 0 x 0\n",
+			expErr: true,
+		},
+	} {
+		t.Run(tbl.name, func(t *testing.T) {
+			pol, rtPrio, err := linuxParseSchedPolicy([]byte(tbl.stat))
+			if tbl.expErr {
+				if err == nil {
+					t.Fatalf("expected error; got policy %d, rt_priority %d", pol, rtPrio)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if pol != tbl.expPolicy {
+				t.Errorf("unexpected policy %d; expected %d", pol, tbl.expPolicy)
+			}
+			if rtPrio != tbl.expRTPrio {
+				t.Errorf("unexpected rt_priority %d; expected %d", rtPrio, tbl.expRTPrio)
+			}
+		})
+	}
+}
+
+func TestSchedPolicySelf(t *testing.T) {
+	pol, rtPrio, err := SchedPolicy(os.Getpid())
+	if err != nil {
+		t.Fatalf("failed to read scheduling policy: %s", err)
+	}
+	switch pol {
+	case SchedOther, SchedFIFO, SchedRR, SchedBatch, SchedIdle, SchedDeadline:
+	default:
+		t.Errorf("unexpected policy %d", pol)
+	}
+	if rtPrio < 0 || rtPrio > 99 {
+		t.Errorf("unexpected rt_priority %d", rtPrio)
+	}
+}
@@ -0,0 +1,58 @@
+//go:build linux
+// +build linux
+
+package procstats
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// signalMasks returns the signal masks from the status, combining the
+// thread and process pending masks.
+func (s *ProcPidStatus) signalMasks() (pending, blocked, ignored, caught uint64) {
+	return s.SigPnd | s.ShdPnd, s.SigBlk, s.SigIgn, s.SigCgt
+}
+
+// sigQueue parses the SigQ field, which is formatted as "cur/max".
+func (s *ProcPidStatus) sigQueue() (cur, max int, err error) {
+	curStr, maxStr, ok := strings.Cut(s.SigQ, "/")
+	if !ok {
+		return -1, -1, fmt.Errorf("malformed SigQ value %q: missing '/'", s.SigQ)
+	}
+	cur, curErr := strconv.Atoi(curStr)
+	if curErr != nil {
+		return -1, -1, fmt.Errorf("failed to parse queued signal count from SigQ %q: %w", s.SigQ, curErr)
+	}
+	max, maxErr := strconv.Atoi(maxStr)
+	if maxErr != nil {
+		return -1, -1, fmt.Errorf("failed to parse queued signal limit from SigQ %q: %w", s.SigQ, maxErr)
+	}
+	return cur, max, nil
+}
+
+// PendingSignals returns the signal masks from /proc/$pid/status as bitsets,
+// with signal N represented by bit N-1 (e.g. SIGHUP (1) is 1<<0).
+// pending includes signals pending for both the thread (SigPnd) and the
+// whole process (ShdPnd). pid may also be a thread ID.
+func PendingSignals(pid int) (pending, blocked, ignored, caught uint64, err error) {
+	status, statusErr := ReadProcStatus(pid)
+	if statusErr != nil {
+		return 0, 0, 0, 0, fmt.Errorf("failed to obtain status: %w", statusErr)
+	}
+	pending, blocked, ignored, caught = status.signalMasks()
+	return pending, blocked, ignored, caught, nil
+}
+
+// SigQueueLen returns the number of signals currently queued for the real
+// user ID of the process, and the resource limit on the number of queued
+// signals (RLIMIT_SIGPENDING), as reported by the SigQ field of
+// /proc/$pid/status.
+func SigQueueLen(pid int) (cur, max int, err error) {
+	status, statusErr := ReadProcStatus(pid)
+	if statusErr != nil {
+		return -1, -1, fmt.Errorf("failed to obtain status: %w", statusErr)
+	}
+	return status.sigQueue()
+}
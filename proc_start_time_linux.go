@@ -0,0 +1,90 @@
+//go:build linux
+// +build linux
+
+package procstats
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// excerpt from proc(5) man page section on /proc/[pid]/stat:
+//
+//               (22) starttime  %llu
+//                         The time the process started after system boot.
+//                         In kernels before Linux 2.6, this value was
+//                         expressed in jiffies.  Since Linux 2.6, the value is
+//                         expressed in clock ticks (divide by
+//                         sysconf(_SC_CLK_TCK)).
+
+func linuxParseStartTimeTicks(b []byte) (int64, error) {
+	statFields, err := splitStatFields(b)
+	if err != nil {
+		return -1, err
+	}
+	if len(statFields) < 22 {
+		return -1, fmt.Errorf("insufficient fields present in stat: %d",
+			len(statFields))
+	}
+	startTicks, err := strconv.ParseInt(string(statFields[21]), 10, 64)
+	if err != nil {
+		return -1, fmt.Errorf("failed to parse the starttime column of stat: %s",
+			err)
+	}
+	return startTicks, nil
+}
+
+// excerpt from proc(5) man page section on /proc/stat:
+//
+//        btime 769041601
+//               boot time, in seconds since the Epoch, 1970-01-01
+//               00:00:00 +0000 (UTC).
+
+func parseBootTime(procStat []byte) (time.Time, error) {
+	s := bufio.NewScanner(bytes.NewReader(procStat))
+	for s.Scan() {
+		val, found := bytes.CutPrefix(s.Bytes(), []byte("btime "))
+		if !found {
+			continue
+		}
+		btime, err := strconv.ParseInt(string(bytes.TrimSpace(val)), 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("failed to parse btime: %s", err)
+		}
+		return time.Unix(btime, 0), nil
+	}
+	if err := s.Err(); err != nil {
+		return time.Time{}, err
+	}
+	return time.Time{}, fmt.Errorf("no btime line found in /proc/stat")
+}
+
+func readBootTime() (time.Time, error) {
+	procStat := procPath("stat")
+	c, err := os.ReadFile(procStat)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to read %s: %s", procStat, err)
+	}
+	return parseBootTime(c)
+}
+
+func readProcessStartTime(pid int) (time.Time, error) {
+	c, err := procFileContents(pid, "stat")
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get start time: %s", err)
+	}
+	startTicks, err := linuxParseStartTimeTicks(c)
+	if err != nil {
+		return time.Time{}, err
+	}
+	btime, err := readBootTime()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get boot time: %s", err)
+	}
+	clockTick := time.Duration(sysClockTick())
+	return btime.Add(time.Duration(startTicks) * time.Second / clockTick), nil
+}
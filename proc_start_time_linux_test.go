@@ -0,0 +1,44 @@
+package procstats
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestParseBootTime(t *testing.T) {
+	procStat := []byte(`cpu  10132153 290696 3084719 46828483 16683 0 25195 0 0 0
+cpu0 1393280 32966 572056 13343292 6130 0 17875 0 0 0
+intr 1462898 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0
+ctxt 115315
+btime 769041601
+processes 86031
+procs_running 6
+procs_blocked 0
+`)
+	btime, err := parseBootTime(procStat)
+	if err != nil {
+		t.Fatalf("failed to parse boot time: %s", err)
+	}
+	if !btime.Equal(time.Unix(769041601, 0)) {
+		t.Errorf("unexpected boot time %s; expected %s", btime, time.Unix(769041601, 0))
+	}
+	if _, err := parseBootTime([]byte("cpu 1 2 3\n")); err == nil {
+		t.Error("expected error for missing btime")
+	}
+}
+
+func TestProcessStartTimeSelf(t *testing.T) {
+	start, err := ProcessStartTime(os.Getpid())
+	if err != nil {
+		t.Fatalf("failed to read start time: %s", err)
+	}
+	now := time.Now()
+	// the boot time only has one-second granularity
+	if start.After(now.Add(time.Second)) {
+		t.Errorf("start time %s is in the future (now %s)", start, now)
+	}
+	if now.Sub(start) > time.Hour {
+		t.Errorf("start time %s is implausibly far in the past (now %s)", start, now)
+	}
+}
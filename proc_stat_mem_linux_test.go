@@ -0,0 +1,90 @@
+package procstats
+
+import (
+	"os"
+	"testing"
+)
+
+func TestSplitStatFields(t *testing.T) {
+	for _, tbl := range []struct {
+		name    string
+		stat    string
+		expComm string
+		expLen  int
+		expErr  bool
+	}{
+		{
+			name:    "simple",
+			stat:    "4242 (cat) R 1 4242\n",
+			expComm: "(cat)",
+			expLen:  5,
+		},
+		{
+			name:    "comm_with_spaces_and_parens",
+			stat:    "4242 (a b) (c) R 1 4242\n",
+			expComm: "(a b) (c)",
+			expLen:  5,
+		},
+		{
+			name:   "missing_comm",
+			stat:   "4242 cat R 1 4242\n",
+			expErr: true,
+		},
+	} {
+		t.Run(tbl.name, func(t *testing.T) {
+			fields, err := splitStatFields([]byte(tbl.stat))
+			if tbl.expErr {
+				if err == nil {
+					t.Fatalf("expected error; got fields %q", fields)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if len(fields) != tbl.expLen {
+				t.Fatalf("unexpected number of fields %d; expected %d: %q", len(fields), tbl.expLen, fields)
+			}
+			if string(fields[0]) != "4242" {
+				t.Errorf("unexpected pid field %q; expected \"4242\"", fields[0])
+			}
+			if string(fields[1]) != tbl.expComm {
+				t.Errorf("unexpected comm field %q; expected %q", fields[1], tbl.expComm)
+			}
+			if string(fields[2]) != "R" {
+				t.Errorf("unexpected state field %q; expected \"R\"", fields[2])
+			}
+		})
+	}
+}
+
+func TestLinuxParseStatMemory(t *testing.T) {
+	const stat = "4242 (my prog) S 1 4242 4242 0 -1 4194560 92 0 0 0 0 0 0 0 20 0 1 0 123456 5582848 256 18446744073709551615 1 1 0 0 0 0 0 0 0 0 0 0 17 3 0 0 0 0 0 0 0 0 0 0 0 0 0\n"
+	vsize, rss, err := linuxParseStatMemory([]byte(stat), 4096)
+	if err != nil {
+		t.Fatalf("failed to parse stat: %s", err)
+	}
+	if vsize != 5582848 {
+		t.Errorf("unexpected vsize %d; expected 5582848", vsize)
+	}
+	if rss != 256*4096 {
+		t.Errorf("unexpected rss %d; expected %d", rss, 256*4096)
+	}
+
+	if _, _, err := linuxParseStatMemory([]byte("4242 (my prog) S 1 4242 4242 0\n"), 4096); err == nil {
+		t.Error("expected error for truncated stat")
+	}
+}
+
+func TestProcessMemoryFromStatSelf(t *testing.T) {
+	vsize, rss, err := ProcessMemoryFromStat(os.Getpid())
+	if err != nil {
+		t.Fatalf("failed to read memory from stat: %s", err)
+	}
+	if vsize <= 0 {
+		t.Errorf("unexpected non-positive vsize %d", vsize)
+	}
+	if rss <= 0 || rss > vsize {
+		t.Errorf("unexpected rss %d (vsize %d)", rss, vsize)
+	}
+}
@@ -0,0 +1,23 @@
+package procstats
+
+// StatMStats contains the memory usage of a process, as reported by
+// /proc/[pid]/statm, converted from pages to bytes.
+type StatMStats struct {
+	// Size is the total program size (same as VmSize in
+	// /proc/[pid]/status)
+	Size int64
+	// Resident is the resident set size (same as VmRSS in
+	// /proc/[pid]/status)
+	Resident int64
+	// Shared is the resident shared memory, i.e. backed by a file (same as
+	// RssFile+RssShmem in /proc/[pid]/status)
+	Shared int64
+	// Text is the size of the program's text (code)
+	Text int64
+	// Lib is unused since Linux 2.6 (always 0)
+	Lib int64
+	// Data is the size of the program's data + stack
+	Data int64
+	// Dirty is unused since Linux 2.6 (always 0)
+	Dirty int64
+}
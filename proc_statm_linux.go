@@ -0,0 +1,65 @@
+//go:build linux
+// +build linux
+
+package procstats
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// From the proc(5) manpage:
+// /proc/[pid]/statm
+//        Provides information about memory usage, measured in pages.  The columns are:
+//
+//            size       (1) total program size
+//                       (same as VmSize in /proc/[pid]/status)
+//            resident   (2) resident set size
+//                       (same as VmRSS in /proc/[pid]/status)
+//            shared     (3) number of resident shared pages (i.e., backed by a file)
+//                       (same as RssFile+RssShmem in /proc/[pid]/status)
+//            text       (4) text (code)
+//            lib        (5) library (unused since Linux 2.6; always 0)
+//            data       (6) data + stack
+//            dt         (7) dirty pages (unused since Linux 2.6; always 0)
+
+// StatM reads the memory usage of the process with PID pid from
+// /proc/[pid]/statm, with all values converted from pages to bytes.
+// It is only implemented on linux.
+func StatM(pid int) (StatMStats, error) {
+	statmContents, readErr := procFileContents(pid, "statm")
+	if readErr != nil {
+		return StatMStats{}, readErr
+	}
+	// statm's field values are listed in units of pages
+	return parseStatM(statmContents, int64(os.Getpagesize()))
+}
+
+func parseStatM(b []byte, pageSize int64) (StatMStats, error) {
+	statmFields := bytes.Fields(b)
+	if len(statmFields) < 7 {
+		return StatMStats{}, fmt.Errorf("unexpected number of fields present in statm: %d",
+			len(statmFields))
+	}
+
+	var pages [7]int64
+	for i := range pages {
+		p, err := strconv.ParseInt(string(statmFields[i]), 10, 64)
+		if err != nil {
+			return StatMStats{}, fmt.Errorf("failed to parse column %d of statm: %s",
+				i+1, err)
+		}
+		pages[i] = p * pageSize
+	}
+	return StatMStats{
+		Size:     pages[0],
+		Resident: pages[1],
+		Shared:   pages[2],
+		Text:     pages[3],
+		Lib:      pages[4],
+		Data:     pages[5],
+		Dirty:    pages[6],
+	}, nil
+}
@@ -0,0 +1,45 @@
+package procstats
+
+import (
+	"os"
+	"testing"
+)
+
+func TestParseStatM(t *testing.T) {
+	st, err := parseStatM([]byte("1000 200 50 10 0 300 0\n"), 4096)
+	if err != nil {
+		t.Fatalf("failed to parse statm: %s", err)
+	}
+	exp := StatMStats{
+		Size:     1000 * 4096,
+		Resident: 200 * 4096,
+		Shared:   50 * 4096,
+		Text:     10 * 4096,
+		Data:     300 * 4096,
+	}
+	if st != exp {
+		t.Errorf("unexpected StatMStats:\n  got %+v\n want %+v", st, exp)
+	}
+
+	for _, bad := range []string{"1000 200 50\n", "1000 200 50 10 0 abc 0\n", ""} {
+		if st, err := parseStatM([]byte(bad), 4096); err == nil {
+			t.Errorf("expected error parsing %q; got %+v", bad, st)
+		}
+	}
+}
+
+func TestStatMSelf(t *testing.T) {
+	st, err := StatM(os.Getpid())
+	if err != nil {
+		t.Fatalf("failed to read statm: %s", err)
+	}
+	if st.Resident <= 0 || st.Resident > st.Size {
+		t.Errorf("unexpected resident size %d (total size %d)", st.Resident, st.Size)
+	}
+	if st.Text <= 0 {
+		t.Errorf("unexpectedly non-positive text size: %d", st.Text)
+	}
+	if st.Shared > st.Resident {
+		t.Errorf("shared %d exceeds resident %d", st.Shared, st.Resident)
+	}
+}
@@ -21,6 +21,7 @@ import "C"
 
 import (
 	"fmt"
+	"time"
 
 	"golang.org/x/sys/unix"
 )
@@ -52,3 +53,13 @@ func resetMaxRSS(pid int) error {
 	// noop
 	return nil
 }
+
+func cpuTimeResolution() time.Duration {
+	// CPU time isn't implemented here
+	return 0
+}
+
+func readFDCount(pid int) (int, error) {
+	// bsd doesn't appear to expose the list of descriptors via sysctl
+	return -1, ErrUnimplementedPlatform
+}
@@ -7,6 +7,7 @@ package procstats
 // also @see http://vinceyuan.github.io/wrong-info-from-procpidinfo/
 
 // #include <libproc.h>
+// #include <stdlib.h>
 //
 // int get_mem_info(int pid, uint64_t *rss)
 // {
@@ -32,10 +33,32 @@ package procstats
 //     *total_system = ti.ptinfo.pti_total_system;
 //     return 0;
 // }
+//
+// int get_fd_count(int pid)
+// {
+//     int nb = proc_pidinfo(pid, PROC_PIDLISTFDS, 0, NULL, 0);
+//     if (nb <= 0) {
+//         return -1;
+//     }
+//     // the first call only returns an upper bound on the buffer size, so
+//     // actually list the descriptors to get an exact count.
+//     struct proc_fdinfo *fds = malloc(nb);
+//     if (fds == NULL) {
+//         return -1;
+//     }
+//     nb = proc_pidinfo(pid, PROC_PIDLISTFDS, 0, fds, nb);
+//     free(fds);
+//     if (nb <= 0) {
+//         return -1;
+//     }
+//     return nb / PROC_PIDLISTFD_SIZE;
+// }
 import "C"
 
 import (
 	"fmt"
+	"os"
+	"syscall"
 	"time"
 )
 
@@ -68,12 +91,35 @@ func readProcessCPUTime(pid int) (CPUTime, error) {
 	return cpuTime, nil
 }
 
+// cpuTimeResolution matches the tick-based conversion in readProcessCPUTime
+func cpuTimeResolution() time.Duration {
+	return time.Second / time.Duration(sysClockTick())
+}
+
+// readMaxRSS returns the peak RSS of the current process via getrusage(2).
+// darwin doesn't expose the peak RSS of other processes, so for other pids
+// this falls back to their current RSS.
 func readMaxRSS(pid int) (int64, error) {
-	// darwin doesn't appear to expose Max RSS independently
-	return readProcessRSS(pid)
+	if pid != os.Getpid() {
+		return readProcessRSS(pid)
+	}
+	ru := syscall.Rusage{}
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &ru); err != nil {
+		return 0, fmt.Errorf("getrusage failed: %w", err)
+	}
+	// unlike linux, darwin reports ru_maxrss in bytes
+	return ru.Maxrss, nil
 }
 
 func resetMaxRSS(pid int) error {
 	// noop
 	return nil
 }
+
+func readFDCount(pid int) (int, error) {
+	n := C.get_fd_count(C.int(pid))
+	if n < 0 {
+		return -1, fmt.Errorf("failed to list fds for pid: non-zero return")
+	}
+	return int(n), nil
+}
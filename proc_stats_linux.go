@@ -5,49 +5,24 @@ package procstats
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strconv"
-	"strings"
 	"time"
+
+	"github.com/vimeo/procstats/cgresolver"
 )
 
-func init() {
-	const self = "/proc/self/stat"
-	b, err := os.ReadFile(self)
-	if err != nil {
-		panic(fmt.Sprintf("unexpected kernel shenanigans: %v", err))
-	}
-	if _, err := linuxParseCPUTime(b); err != nil {
-		panic(fmt.Sprintf("unexpected kernel shenanigans: %v", err))
-	}
-
-	// This burns extra cycles at the beginning of our execution, but allows
-	// us to know the kernel we're running on is actually reporting these numbers.
-	// Any binaries will almost certainly not be run on the same kernel they were
-	// compiled and tested on, so just running at test-time is of limited use.
-	go func() {
-		t := time.NewTicker(time.Second)
-		defer t.Stop()
-		var ct *CPUTime
-		for i := 0; i < 30 || ct.eq(&CPUTime{}); i++ {
-			<-t.C
-			b, err := os.ReadFile(self)
-			if err != nil {
-				panic(fmt.Sprintf("unexpected kernel shenanigans: %v", err))
-			}
-			r, err := linuxParseCPUTime(b)
-			if err != nil {
-				panic(fmt.Sprintf("unexpected kernel shenanigans: %v", err))
-			}
-			ct = &r
-		}
-	}()
+// procPath returns the path of the named file within procfs (see
+// SetProcRoot).
+func procPath(elem ...string) string {
+	return filepath.Join(append([]string{cgresolver.ProcRoot()}, elem...)...)
 }
 
 func procFileName(pid int, leafName string) string {
-	return filepath.Join("/proc", strconv.Itoa(pid), leafName)
+	return procPath(strconv.Itoa(pid), leafName)
 }
 
 func procFileContents(pid int, leafName string) ([]byte, error) {
@@ -59,43 +34,37 @@ func procFileContents(pid int, leafName string) ([]byte, error) {
 	return contents, nil
 }
 
-// From the proc(5) manpage:
-// /proc/[pid]/statm
-//        Provides information about memory usage, measured in pages.  The columns are:
-//
-//            size       (1) total program size
-//                       (same as VmSize in /proc/[pid]/status)
-//            resident   (2) resident set size
-//                       (same as VmRSS in /proc/[pid]/status)
-//            shared     (3) number of resident shared pages (i.e., backed by a file)
-//                       (same as RssFile+RssShmem in /proc/[pid]/status)
-//            text       (4) text (code)
-//            lib        (5) library (unused since Linux 2.6; always 0)
-//            data       (6) data + stack
-//            dt         (7) dirty pages (unused since Linux 2.6; always 0)
-
-func readProcessRSS(pid int) (int64, error) {
-	statmContents, readErr := procFileContents(pid, "statm")
-	if readErr != nil {
-		return 0, fmt.Errorf("failed to get memory usage: %s", readErr)
+// splitStatFields splits the contents of /proc/[pid]/stat into its
+// space-separated fields, such that field N (1-indexed, as in proc(5)) is at
+// index N-1. The comm field (2) is parenthesized, and may itself contain
+// spaces and parentheses, so it's delimited by the first "(" and the last
+// ")" rather than by spaces.
+func splitStatFields(b []byte) ([][]byte, error) {
+	commStart := bytes.IndexByte(b, '(')
+	commEnd := bytes.LastIndexByte(b, ')')
+	if commStart == -1 || commEnd < commStart {
+		return nil, fmt.Errorf("malformed stat: unable to locate comm field")
 	}
+	fields := make([][]byte, 0, 52)
+	fields = append(fields, bytes.TrimSpace(b[:commStart]), b[commStart:commEnd+1])
+	fields = append(fields, bytes.Fields(b[commEnd+1:])...)
+	return fields, nil
+}
 
-	// statm's field values are listed in units of pages, so get that
-	// value.
-	sysPagesize := os.Getpagesize()
-
-	statmFields := strings.SplitN(string(statmContents), " ", 7)
-	if len(statmFields) < 3 {
-		return 0, fmt.Errorf("unexpected number of fields present in statm: %d",
-			len(statmFields))
+// readProcessRSS reads the RSS from /proc/[pid]/statm, falling back to
+// VmRSS in /proc/[pid]/status if statm can't be read. (some seccomp and LSM
+// policies deny access to one but not the other)
+func readProcessRSS(pid int) (int64, error) {
+	statm, statmErr := StatM(pid)
+	if statmErr == nil {
+		return statm.Resident, nil
 	}
-
-	rssPages, err := strconv.ParseInt(statmFields[1], 10, 64)
-	if err != nil {
-		return 0, fmt.Errorf("failed to parse the second column of statm: %s",
-			err)
+	status, statusErr := ReadProcStatus(pid)
+	if statusErr != nil {
+		return 0, fmt.Errorf("failed to get memory usage: %w",
+			errors.Join(statmErr, statusErr))
 	}
-	return int64(sysPagesize) * rssPages, nil
+	return status.VMRSS, nil
 }
 
 // excerpt from proc(5) man page section on /proc/[pid]/stat:
@@ -128,6 +97,12 @@ func readProcessRSS(pid int) (int64, error) {
 //                         have  been scheduled in kernel mode, measured in clock
 //                         ticks (divide by sysconf(_SC_CLK_TCK)).
 
+// cpuTimeResolution is one clock tick (USER_HZ), as that's the unit the
+// kernel uses for the stat times
+func cpuTimeResolution() time.Duration {
+	return time.Second / time.Duration(sysClockTick())
+}
+
 func readProcessCPUTime(pid int) (CPUTime, error) {
 	c, err := procFileContents(pid, "stat")
 	if err != nil {
@@ -137,7 +112,34 @@ func readProcessCPUTime(pid int) (CPUTime, error) {
 }
 
 func linuxParseCPUTime(b []byte) (r CPUTime, err error) {
-	statFields := bytes.SplitN(b, []byte{' '}, 18)
+	return linuxParseStatCPUTime(b, true)
+}
+
+// ProcessCPUTimeSelfOnly returns the cumulative CPUTime of the process with
+// PID pid, excluding the CPU time of its waited-for children (which
+// ProcessCPUTime includes).
+// It is only implemented on linux.
+func ProcessCPUTimeSelfOnly(pid int) (CPUTime, error) {
+	c, err := procFileContents(pid, "stat")
+	if err != nil {
+		return CPUTime{}, fmt.Errorf("failed to get CPU time: %s", err)
+	}
+	return linuxParseStatCPUTime(c, false)
+}
+
+// linuxParseStatCPUTime parses the CPU time from stat, including the CPU
+// time of waited-for children if includeChildren is true.
+func linuxParseStatCPUTime(b []byte, includeChildren bool) (CPUTime, error) {
+	statFields, err := splitStatFields(b)
+	if err != nil {
+		return CPUTime{}, err
+	}
+	return statFieldsCPUTime(statFields, includeChildren)
+}
+
+// statFieldsCPUTime extracts the CPU time from the already-split fields of
+// stat (see splitStatFields).
+func statFieldsCPUTime(statFields [][]byte, includeChildren bool) (r CPUTime, err error) {
 	if len(statFields) < 17 {
 		return r, fmt.Errorf("insufficient fields present in stat: %d",
 			len(statFields))
@@ -166,8 +168,103 @@ func linuxParseCPUTime(b []byte) (r CPUTime, err error) {
 		return r, fmt.Errorf("failed to parse the cstime column of stat: %s",
 			err)
 	}
+	if !includeChildren {
+		cutimeTicks, cstimeTicks = 0, 0
+	}
 	clockTick := time.Duration(sysClockTick())
 	r.Utime = time.Duration(utimeTicks+cutimeTicks) * time.Second / clockTick
 	r.Stime = time.Duration(stimeTicks+cstimeTicks) * time.Second / clockTick
 	return r, nil
 }
+
+// excerpt from proc(5) man page section on /proc/[pid]/stat:
+//
+//               (10) minflt  %lu
+//                         The number of minor faults the process has made
+//                         which have not required loading a memory page from
+//                         disk.
+//
+//               (11) cminflt  %lu
+//                         The number of minor faults that the process's
+//                         waited-for children have made.
+//
+//               (12) majflt  %lu
+//                         The number of major faults the process has made
+//                         which have required loading a memory page from
+//                         disk.
+//
+//               (13) cmajflt  %lu
+//                         The number of major faults that the process's
+//                         waited-for children have made.
+
+// PageFaults returns the minor and major page-fault counts of the process
+// with PID pid.
+// It is only implemented on linux.
+func PageFaults(pid int) (PageFaultStats, error) {
+	c, err := procFileContents(pid, "stat")
+	if err != nil {
+		return PageFaultStats{}, fmt.Errorf("failed to get page faults: %s", err)
+	}
+	return linuxParsePageFaults(c)
+}
+
+func linuxParsePageFaults(b []byte) (PageFaultStats, error) {
+	statFields, err := splitStatFields(b)
+	if err != nil {
+		return PageFaultStats{}, err
+	}
+	return statFieldsPageFaults(statFields)
+}
+
+// statFieldsPageFaults extracts the page-fault counts from the
+// already-split fields of stat (see splitStatFields).
+func statFieldsPageFaults(statFields [][]byte) (PageFaultStats, error) {
+	if len(statFields) < 13 {
+		return PageFaultStats{}, fmt.Errorf("insufficient fields present in stat: %d",
+			len(statFields))
+	}
+
+	var faults [4]uint64
+	for i, name := range [...]string{"minflt", "cminflt", "majflt", "cmajflt"} {
+		var err error
+		faults[i], err = strconv.ParseUint(string(statFields[9+i]), 10, 64)
+		if err != nil {
+			return PageFaultStats{}, fmt.Errorf("failed to parse the %s column of stat: %s",
+				name, err)
+		}
+	}
+	return PageFaultStats{
+		Minor:             faults[0],
+		Major:             faults[2],
+		MinorWithChildren: faults[0] + faults[1],
+		MajorWithChildren: faults[2] + faults[3],
+	}, nil
+}
+
+// ProcessCPUTimeAndPageFaults returns both the cumulative CPUTime (as
+// ProcessCPUTime) and the page-fault counts (as PageFaults) of the process
+// with PID pid, reading and parsing its stat file only once.
+// It is only implemented on linux.
+func ProcessCPUTimeAndPageFaults(pid int) (CPUTime, PageFaultStats, error) {
+	c, err := procFileContents(pid, "stat")
+	if err != nil {
+		return CPUTime{}, PageFaultStats{}, fmt.Errorf("failed to read stat: %s", err)
+	}
+	return linuxParseCPUTimeAndPageFaults(c)
+}
+
+func linuxParseCPUTimeAndPageFaults(b []byte) (CPUTime, PageFaultStats, error) {
+	statFields, err := splitStatFields(b)
+	if err != nil {
+		return CPUTime{}, PageFaultStats{}, err
+	}
+	ct, ctErr := statFieldsCPUTime(statFields, true)
+	if ctErr != nil {
+		return CPUTime{}, PageFaultStats{}, ctErr
+	}
+	pf, pfErr := statFieldsPageFaults(statFields)
+	if pfErr != nil {
+		return CPUTime{}, PageFaultStats{}, pfErr
+	}
+	return ct, pf, nil
+}
@@ -0,0 +1,128 @@
+//go:build !linux
+// +build !linux
+
+package procstats
+
+import "time"
+
+// PendingSignals returns the pending, blocked, ignored and caught signal
+// masks for the process.
+// It is only implemented on linux.
+func PendingSignals(pid int) (pending, blocked, ignored, caught uint64, err error) {
+	return 0, 0, 0, 0, ErrUnimplementedPlatform
+}
+
+// SigQueueLen returns the number of queued signals and the limit on queued
+// signals for the process's real user ID.
+// It is only implemented on linux.
+func SigQueueLen(pid int) (cur, max int, err error) {
+	return -1, -1, ErrUnimplementedPlatform
+}
+
+func readProcessStartTime(pid int) (time.Time, error) {
+	return time.Time{}, ErrUnimplementedPlatform
+}
+
+// SchedPolicy returns the scheduling policy (one of the Sched* constants)
+// and real-time priority of the process with PID pid.
+// It is only implemented on linux.
+func SchedPolicy(pid int) (policy int, rtPriority int, err error) {
+	return -1, -1, ErrUnimplementedPlatform
+}
+
+// ProcessMemoryFromStat returns the virtual memory size and RSS (both in
+// bytes) of the process with PID pid.
+// It is only implemented on linux.
+func ProcessMemoryFromStat(pid int) (vsize int64, rssBytes int64, err error) {
+	return -1, -1, ErrUnimplementedPlatform
+}
+
+// StatM reads the memory usage of the process with PID pid, with all values
+// in bytes.
+// It is only implemented on linux.
+func StatM(pid int) (StatMStats, error) {
+	return StatMStats{}, ErrUnimplementedPlatform
+}
+
+// RSSBreakdown returns the resident memory of the process with PID pid,
+// split into anonymous, file-backed and shared-memory pages, all in bytes.
+// It is only implemented on linux.
+func RSSBreakdown(pid int) (anon, file, shmem int64, err error) {
+	return -1, -1, -1, ErrUnimplementedPlatform
+}
+
+// OOMScore returns the OOM-killer badness score of the process with PID pid,
+// along with its adjustment (oom_score_adj).
+// It is only implemented on linux.
+func OOMScore(pid int) (score int, adj int, err error) {
+	return -1, 0, ErrUnimplementedPlatform
+}
+
+// ProcessCPUTimeSelfOnly returns the cumulative CPUTime of the process with
+// PID pid, excluding the CPU time of its waited-for children.
+// It is only implemented on linux.
+func ProcessCPUTimeSelfOnly(pid int) (CPUTime, error) {
+	return CPUTime{}, ErrUnimplementedPlatform
+}
+
+// ListThreads returns the thread IDs of the threads in the process with PID
+// pid.
+// It is only implemented on linux.
+func ListThreads(pid int) ([]int, error) {
+	return nil, ErrUnimplementedPlatform
+}
+
+// ProcessNumThreads returns the number of threads in the process with PID
+// pid.
+// It is only implemented on linux.
+func ProcessNumThreads(pid int) (int, error) {
+	return -1, ErrUnimplementedPlatform
+}
+
+// ThreadCPUTime returns the cumulative CPUTime of the thread with ID tid in
+// the process with PID pid.
+// It is only implemented on linux.
+func ThreadCPUTime(pid, tid int) (CPUTime, error) {
+	return CPUTime{}, ErrUnimplementedPlatform
+}
+
+// ThreadCPUTimes returns the cumulative CPUTime of each thread in the
+// process with PID pid, keyed by thread ID.
+// It is only implemented on linux.
+func ThreadCPUTimes(pid int) (map[int]CPUTime, error) {
+	return nil, ErrUnimplementedPlatform
+}
+
+// ProcessIO reads the I/O accounting counters for the process with PID pid.
+// It is only implemented on linux.
+func ProcessIO(pid int) (IOStat, error) {
+	return IOStat{}, ErrUnimplementedPlatform
+}
+
+// PageFaults returns the minor and major page-fault counts of the process
+// with PID pid.
+// It is only implemented on linux.
+func PageFaults(pid int) (PageFaultStats, error) {
+	return PageFaultStats{}, ErrUnimplementedPlatform
+}
+
+// ProcessCPUTimeAndPageFaults returns both the cumulative CPUTime and the
+// page-fault counts of the process with PID pid.
+// It is only implemented on linux.
+func ProcessCPUTimeAndPageFaults(pid int) (CPUTime, PageFaultStats, error) {
+	return CPUTime{}, PageFaultStats{}, ErrUnimplementedPlatform
+}
+
+// ProcessTreeCPUTime returns the sum of the cumulative CPUTime of the
+// process with PID rootPid and all its living descendants.
+// It is only implemented on linux.
+func ProcessTreeCPUTime(rootPid int) (CPUTime, error) {
+	return CPUTime{}, ErrUnimplementedPlatform
+}
+
+// ProcessTreeRSS returns the sum of the RSS of the process with PID rootPid
+// and all its living descendants.
+// It is only implemented on linux.
+func ProcessTreeRSS(rootPid int) (int64, error) {
+	return -1, ErrUnimplementedPlatform
+}
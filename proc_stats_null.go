@@ -1,8 +1,10 @@
-//go:build !linux && !cgo
-// +build !linux,!cgo
+//go:build !linux && !windows && !cgo
+// +build !linux,!windows,!cgo
 
 package procstats
 
+import "time"
+
 func readProcessRSS(pid int) (int64, error) {
 	return 0, ErrUnimplementedPlatform
 }
@@ -20,3 +22,12 @@ func resetMaxRSS(pid int) error {
 	// noop
 	return ErrUnimplementedPlatform
 }
+
+func cpuTimeResolution() time.Duration {
+	// CPU time isn't implemented here
+	return 0
+}
+
+func readFDCount(pid int) (int, error) {
+	return -1, ErrUnimplementedPlatform
+}
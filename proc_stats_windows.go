@@ -0,0 +1,133 @@
+//go:build windows
+// +build windows
+
+package procstats
+
+import (
+	"fmt"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+var (
+	modkernel32 = syscall.NewLazyDLL("kernel32.dll")
+
+	// K32GetProcessMemoryInfo is psapi's GetProcessMemoryInfo, exported
+	// from kernel32 since Windows 7.
+	procGetProcessMemoryInfo  = modkernel32.NewProc("K32GetProcessMemoryInfo")
+	procGetProcessHandleCount = modkernel32.NewProc("GetProcessHandleCount")
+)
+
+// processQueryLimitedInformation is PROCESS_QUERY_LIMITED_INFORMATION, which
+// suffices for all the queries here.
+const processQueryLimitedInformation = 0x1000
+
+// processMemoryCounters mirrors PROCESS_MEMORY_COUNTERS
+type processMemoryCounters struct {
+	cb                         uint32
+	PageFaultCount             uint32
+	PeakWorkingSetSize         uintptr
+	WorkingSetSize             uintptr
+	QuotaPeakPagedPoolUsage    uintptr
+	QuotaPagedPoolUsage        uintptr
+	QuotaPeakNonPagedPoolUsage uintptr
+	QuotaNonPagedPoolUsage     uintptr
+	PagefileUsage              uintptr
+	PeakPagefileUsage          uintptr
+}
+
+func openProcess(pid int) (syscall.Handle, error) {
+	h, err := syscall.OpenProcess(processQueryLimitedInformation, false, uint32(pid))
+	if err != nil {
+		return 0, fmt.Errorf("failed to open process %d: %w", pid, err)
+	}
+	return h, nil
+}
+
+func readMemoryCounters(pid int) (processMemoryCounters, error) {
+	h, openErr := openProcess(pid)
+	if openErr != nil {
+		return processMemoryCounters{}, openErr
+	}
+	defer syscall.CloseHandle(h)
+
+	pmc := processMemoryCounters{}
+	pmc.cb = uint32(unsafe.Sizeof(pmc))
+	r, _, err := procGetProcessMemoryInfo.Call(uintptr(h), uintptr(unsafe.Pointer(&pmc)), uintptr(pmc.cb))
+	if r == 0 {
+		return processMemoryCounters{}, fmt.Errorf("GetProcessMemoryInfo failed for pid %d: %w", pid, err)
+	}
+	return pmc, nil
+}
+
+// readProcessRSS returns the process's working set size (the closest
+// analogue of RSS)
+func readProcessRSS(pid int) (int64, error) {
+	pmc, err := readMemoryCounters(pid)
+	if err != nil {
+		return 0, err
+	}
+	return int64(pmc.WorkingSetSize), nil
+}
+
+// filetimeDuration converts a FILETIME holding an interval (in 100ns units)
+// to a time.Duration.
+func filetimeDuration(ft *syscall.Filetime) time.Duration {
+	return time.Duration(int64(ft.HighDateTime)<<32|int64(ft.LowDateTime)) * 100 * time.Nanosecond
+}
+
+func readProcessCPUTime(pid int) (CPUTime, error) {
+	h, openErr := openProcess(pid)
+	if openErr != nil {
+		return CPUTime{}, openErr
+	}
+	defer syscall.CloseHandle(h)
+
+	var creation, exit, kernel, user syscall.Filetime
+	if err := syscall.GetProcessTimes(h, &creation, &exit, &kernel, &user); err != nil {
+		return CPUTime{}, fmt.Errorf("GetProcessTimes failed for pid %d: %w", pid, err)
+	}
+	return CPUTime{
+		Utime: filetimeDuration(&user),
+		Stime: filetimeDuration(&kernel),
+	}, nil
+}
+
+// cpuTimeResolution is the unit of the times returned by GetProcessTimes.
+// (the times are only updated on clock interrupts, so the effective
+// resolution is usually much coarser)
+func cpuTimeResolution() time.Duration {
+	return 100 * time.Nanosecond
+}
+
+// readMaxRSS returns the process's peak working set size
+func readMaxRSS(pid int) (int64, error) {
+	pmc, err := readMemoryCounters(pid)
+	if err != nil {
+		return 0, err
+	}
+	return int64(pmc.PeakWorkingSetSize), nil
+}
+
+func resetMaxRSS(pid int) error {
+	// windows has no way to reset the peak working set size
+	return ErrUnimplementedPlatform
+}
+
+// readFDCount returns the number of open handles in the process (the
+// closest analogue of file descriptors)
+func readFDCount(pid int) (int, error) {
+	h, openErr := openProcess(pid)
+	if openErr != nil {
+		return -1, openErr
+	}
+	defer syscall.CloseHandle(h)
+
+	var count uint32
+	r, _, err := procGetProcessHandleCount.Call(uintptr(h), uintptr(unsafe.Pointer(&count)))
+	if r == 0 {
+		return -1, fmt.Errorf("GetProcessHandleCount failed for pid %d: %w", pid, err)
+	}
+	return int(count), nil
+}
@@ -0,0 +1,87 @@
+//go:build linux
+// +build linux
+
+package procstats
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// ListThreads returns the thread IDs of the threads in the process with PID
+// pid, as listed in /proc/[pid]/task.
+// It is only implemented on linux.
+func ListThreads(pid int) ([]int, error) {
+	ents, readDirErr := os.ReadDir(procFileName(pid, "task"))
+	if readDirErr != nil {
+		return nil, fmt.Errorf("failed to list threads: %w", readDirErr)
+	}
+	tids := make([]int, 0, len(ents))
+	for _, ent := range ents {
+		tid, atoiErr := strconv.Atoi(ent.Name())
+		if atoiErr != nil {
+			// not a thread directory
+			continue
+		}
+		tids = append(tids, tid)
+	}
+	return tids, nil
+}
+
+// ProcessNumThreads returns the number of threads in the process with PID
+// pid (the Threads field of /proc/[pid]/status).
+// It is only implemented on linux.
+func ProcessNumThreads(pid int) (int, error) {
+	st, err := ReadProcStatus(pid)
+	if err != nil {
+		return -1, err
+	}
+	return int(st.Threads), nil
+}
+
+// ThreadCPUTime returns the cumulative CPUTime of the thread with ID tid in
+// the process with PID pid.
+// Unlike ProcessCPUTime, this doesn't include the CPU time of waited-for
+// children, as the kernel only tracks that for the process as a whole.
+// It is only implemented on linux.
+func ThreadCPUTime(pid, tid int) (CPUTime, error) {
+	c, readErr := os.ReadFile(filepath.Join(procFileName(pid, "task"), strconv.Itoa(tid), "stat"))
+	if readErr != nil {
+		return CPUTime{}, fmt.Errorf("failed to read stat for thread %d: %w", tid, readErr)
+	}
+	ct, parseErr := linuxParseStatCPUTime(c, false)
+	if parseErr != nil {
+		return CPUTime{}, fmt.Errorf("failed to parse stat for thread %d: %w", tid, parseErr)
+	}
+	return ct, nil
+}
+
+// ThreadCPUTimes returns the cumulative CPUTime of each thread in the
+// process with PID pid, keyed by thread ID.
+// Unlike ProcessCPUTime, these don't include the CPU time of waited-for
+// children, as the kernel only tracks that for the process as a whole.
+// Threads which exit while the threads are being enumerated are omitted.
+// It is only implemented on linux.
+func ThreadCPUTimes(pid int) (map[int]CPUTime, error) {
+	tids, listErr := ListThreads(pid)
+	if listErr != nil {
+		return nil, listErr
+	}
+	out := make(map[int]CPUTime, len(tids))
+	for _, tid := range tids {
+		ct, err := ThreadCPUTime(pid, tid)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				// the thread exited since we listed the directory
+				continue
+			}
+			return nil, err
+		}
+		out[tid] = ct
+	}
+	return out, nil
+}
@@ -0,0 +1,89 @@
+package procstats
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestThreadCPUTimesSelf(t *testing.T) {
+	// burn a bit of CPU so at least one thread has non-zero CPU time
+	deadline := time.Now().Add(50 * time.Millisecond)
+	for time.Now().Before(deadline) {
+	}
+
+	pid := os.Getpid()
+	threads, err := ThreadCPUTimes(pid)
+	if err != nil {
+		t.Fatalf("failed to read thread CPU times: %s", err)
+	}
+	if _, ok := threads[pid]; !ok {
+		t.Errorf("main thread %d missing from %v", pid, threads)
+	}
+	total := CPUTime{}
+	for tid, ct := range threads {
+		if ct.Utime < 0 || ct.Stime < 0 {
+			t.Errorf("thread %d has negative CPU time: %+v", tid, ct)
+		}
+		total = total.Add(&ct)
+	}
+	if total.eq(&CPUTime{}) {
+		t.Errorf("want: <non-zero> total, got: %+v", total)
+	}
+
+	proc, err := ProcessCPUTime(pid)
+	if err != nil {
+		t.Fatalf("failed to read process CPU time: %s", err)
+	}
+	// The thread times were sampled first, so they can't exceed the
+	// process's total (modulo a tick of rounding per thread).
+	slop := time.Duration(len(threads)) * CPUTimeResolution()
+	if total.Utime > proc.Utime+slop || total.Stime > proc.Stime+slop {
+		t.Errorf("thread CPU total %+v exceeds process CPU time %+v", total, proc)
+	}
+}
+
+func TestListThreadsSelf(t *testing.T) {
+	pid := os.Getpid()
+	tids, err := ListThreads(pid)
+	if err != nil {
+		t.Fatalf("failed to list threads: %s", err)
+	}
+	foundMain := false
+	total := CPUTime{}
+	for _, tid := range tids {
+		if tid == pid {
+			foundMain = true
+		}
+		ct, ctErr := ThreadCPUTime(pid, tid)
+		if ctErr != nil {
+			// the runtime may have retired the thread since we
+			// listed them
+			t.Logf("failed to read CPU time for thread %d: %s", tid, ctErr)
+			continue
+		}
+		total = total.Add(&ct)
+	}
+	if !foundMain {
+		t.Errorf("main thread %d missing from %v", pid, tids)
+	}
+	if total.Utime < 0 || total.Stime < 0 {
+		t.Errorf("negative total thread CPU time: %+v", total)
+	}
+
+	n, err := ProcessNumThreads(pid)
+	if err != nil {
+		t.Fatalf("failed to read thread count: %s", err)
+	}
+	// the Go runtime always runs a few threads (sysmon, GC workers, etc.)
+	if n < 1 {
+		t.Errorf("unexpectedly small thread count: %d", n)
+	}
+}
+
+func TestThreadCPUTimesMissingProcess(t *testing.T) {
+	// PIDs are capped well below MaxInt32, so this process can't exist.
+	if _, err := ThreadCPUTimes(1 << 30); err == nil {
+		t.Error("expected error for nonexistent process")
+	}
+}
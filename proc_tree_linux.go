@@ -0,0 +1,131 @@
+//go:build linux
+// +build linux
+
+package procstats
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"syscall"
+)
+
+// excerpt from proc(5) man page section on /proc/[pid]/stat:
+//
+//               (4) ppid  %d
+//                         The PID of the parent of this process.
+
+// processTreeStats reads the stat file of every process in procFS (an fs.FS
+// rooted at /proc), and returns the split stat fields (see splitStatFields)
+// of rootPid and all its descendants.
+// Processes that exit mid-scan are skipped (unless it's rootPid itself).
+func processTreeStats(procFS fs.FS, rootPid int) ([][][]byte, error) {
+	ents, readDirErr := fs.ReadDir(procFS, ".")
+	if readDirErr != nil {
+		return nil, fmt.Errorf("failed to list processes: %w", readDirErr)
+	}
+	stats := make(map[int][][]byte, len(ents))
+	children := make(map[int][]int, len(ents))
+	for _, ent := range ents {
+		pid, atoiErr := strconv.Atoi(ent.Name())
+		if atoiErr != nil {
+			// not a process directory
+			continue
+		}
+		c, readErr := fs.ReadFile(procFS, ent.Name()+"/stat")
+		if readErr != nil {
+			if errors.Is(readErr, fs.ErrNotExist) || errors.Is(readErr, syscall.ESRCH) {
+				// the process exited since we listed the directory
+				continue
+			}
+			return nil, fmt.Errorf("failed to read stat for pid %d: %w", pid, readErr)
+		}
+		statFields, splitErr := splitStatFields(c)
+		if splitErr != nil {
+			return nil, fmt.Errorf("failed to parse stat for pid %d: %w", pid, splitErr)
+		}
+		if len(statFields) < 4 {
+			return nil, fmt.Errorf("insufficient fields present in stat for pid %d: %d",
+				pid, len(statFields))
+		}
+		ppid, ppidErr := strconv.Atoi(string(statFields[3]))
+		if ppidErr != nil {
+			return nil, fmt.Errorf("failed to parse the ppid column of stat for pid %d: %s",
+				pid, ppidErr)
+		}
+		stats[pid] = statFields
+		children[ppid] = append(children[ppid], pid)
+	}
+	if _, ok := stats[rootPid]; !ok {
+		return nil, fmt.Errorf("process %d not found: %w", rootPid, fs.ErrNotExist)
+	}
+
+	out := [][][]byte{}
+	for queue := []int{rootPid}; len(queue) > 0; queue = queue[1:] {
+		pid := queue[0]
+		out = append(out, stats[pid])
+		queue = append(queue, children[pid]...)
+	}
+	return out, nil
+}
+
+// ProcessTreeCPUTime returns the sum of the cumulative CPUTime (as returned
+// by ProcessCPUTime) of the process with PID rootPid and all its living
+// descendants. Since each process's CPU time includes that of its
+// waited-for children, this accounts for descendants that have exited and
+// been reaped as well.
+// Descendants that exit while the tree is being walked are skipped.
+// Note: this scans every process in /proc, so it's O(number of processes on
+// the host) regardless of the size of the tree.
+// It is only implemented on linux.
+func ProcessTreeCPUTime(rootPid int) (CPUTime, error) {
+	return processTreeCPUTime(os.DirFS(procPath()), rootPid)
+}
+
+func processTreeCPUTime(procFS fs.FS, rootPid int) (CPUTime, error) {
+	treeStats, treeErr := processTreeStats(procFS, rootPid)
+	if treeErr != nil {
+		return CPUTime{}, treeErr
+	}
+	total := CPUTime{}
+	for _, statFields := range treeStats {
+		ct, ctErr := statFieldsCPUTime(statFields, true)
+		if ctErr != nil {
+			return CPUTime{}, fmt.Errorf("failed to parse CPU time for pid %s: %w",
+				statFields[0], ctErr)
+		}
+		total = total.Add(&ct)
+	}
+	return total, nil
+}
+
+// ProcessTreeRSS returns the sum of the RSS of the process with PID rootPid
+// and all its living descendants (as computed by ProcessMemoryFromStat).
+// Pages shared between processes in the tree are counted once per process
+// mapping them.
+// Descendants that exit while the tree is being walked are skipped.
+// Note: this scans every process in /proc, so it's O(number of processes on
+// the host) regardless of the size of the tree.
+// It is only implemented on linux.
+func ProcessTreeRSS(rootPid int) (int64, error) {
+	return processTreeRSS(os.DirFS(procPath()), rootPid, int64(os.Getpagesize()))
+}
+
+func processTreeRSS(procFS fs.FS, rootPid int, pageSize int64) (int64, error) {
+	treeStats, treeErr := processTreeStats(procFS, rootPid)
+	if treeErr != nil {
+		return -1, treeErr
+	}
+	total := int64(0)
+	for _, statFields := range treeStats {
+		_, rss, memErr := statFieldsMemory(statFields, pageSize)
+		if memErr != nil {
+			return -1, fmt.Errorf("failed to parse RSS for pid %s: %w",
+				statFields[0], memErr)
+		}
+		total += rss
+	}
+	return total, nil
+}
@@ -0,0 +1,99 @@
+package procstats
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"testing"
+	"testing/fstest"
+)
+
+// testTreeStat synthesizes a /proc/[pid]/stat line with the given ppid, CPU
+// times (in ticks) and RSS (in pages).
+func testTreeStat(pid, ppid, utime, cutime, rssPages int) *fstest.MapFile {
+	return &fstest.MapFile{Data: []byte(fmt.Sprintf(
+		"%d (proc %d) S %d 1 1 0 -1 4194304 0 0 0 0 %d 0 %d 0 20 0 1 0 100 1000 %d\n",
+		pid, pid, ppid, utime, cutime, rssPages))}
+}
+
+func TestProcessTreeStats(t *testing.T) {
+	procFS := fstest.MapFS{
+		"1/stat":  testTreeStat(1, 0, 1, 0, 1),
+		"10/stat": testTreeStat(10, 1, 10, 5, 10),
+		"11/stat": testTreeStat(11, 10, 20, 0, 20),
+		"12/stat": testTreeStat(12, 11, 30, 0, 30),
+		"20/stat": testTreeStat(20, 1, 1000, 0, 1000),
+		// exited between listing /proc and reading its stat
+		"13/status": &fstest.MapFile{Data: []byte("Name:\tgone\n")},
+		"self":      &fstest.MapFile{Mode: fs.ModeSymlink, Data: []byte("20")},
+		"meminfo":   &fstest.MapFile{Data: []byte("MemTotal: 1 kB\n")},
+	}
+	res := cpuTimeResolution()
+
+	ct, err := processTreeCPUTime(procFS, 10)
+	if err != nil {
+		t.Fatalf("failed to sum tree CPU time: %s", err)
+	}
+	if exp := (CPUTime{Utime: (10 + 5 + 20 + 30) * res}); ct != exp {
+		t.Errorf("unexpected tree CPU time: %+v; expected %+v", ct, exp)
+	}
+
+	rss, err := processTreeRSS(procFS, 10, 4096)
+	if err != nil {
+		t.Fatalf("failed to sum tree RSS: %s", err)
+	}
+	if exp := int64((10 + 20 + 30) * 4096); rss != exp {
+		t.Errorf("unexpected tree RSS: %d; expected %d", rss, exp)
+	}
+
+	leafRSS, err := processTreeRSS(procFS, 12, 4096)
+	if err != nil {
+		t.Fatalf("failed to sum leaf RSS: %s", err)
+	}
+	if exp := int64(30 * 4096); leafRSS != exp {
+		t.Errorf("unexpected leaf RSS: %d; expected %d", leafRSS, exp)
+	}
+
+	if _, err := processTreeRSS(procFS, 13, 4096); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("unexpected error for exited root: %v", err)
+	}
+}
+
+func TestProcessTreeSelf(t *testing.T) {
+	sleep, lookErr := exec.LookPath("sleep")
+	if lookErr != nil {
+		t.Skipf("no sleep available: %s", lookErr)
+	}
+	cmd := exec.Command(sleep, "30")
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("failed to start child: %s", err)
+	}
+	defer func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	}()
+
+	pid := os.Getpid()
+	childRSS, err := ProcessTreeRSS(cmd.Process.Pid)
+	if err != nil {
+		t.Fatalf("failed to read child tree RSS: %s", err)
+	}
+	treeRSS, err := ProcessTreeRSS(pid)
+	if err != nil {
+		t.Fatalf("failed to read tree RSS: %s", err)
+	}
+	// the child's RSS is included in ours, and our own RSS is non-zero
+	if treeRSS <= childRSS {
+		t.Errorf("tree RSS %d not larger than the child's RSS %d", treeRSS, childRSS)
+	}
+
+	treeCT, err := ProcessTreeCPUTime(pid)
+	if err != nil {
+		t.Fatalf("failed to read tree CPU time: %s", err)
+	}
+	if treeCT.Utime < 0 || treeCT.Stime < 0 {
+		t.Errorf("unexpectedly negative tree CPU time: %+v", treeCT)
+	}
+}
@@ -2,16 +2,37 @@
 package procstats
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
 	"time"
+
+	"github.com/vimeo/procstats/cgresolver"
 )
 
 // ErrUnimplementedPlatform indicates that this request is not implemented for
 // this specific platform.
 var ErrUnimplementedPlatform = errors.New("unimplemented for this platform")
 
+// ErrPermissionDenied indicates that the caller lacks the privileges needed to
+// read the requested stats for another process. (e.g. /proc/[pid]/io requires
+// ptrace access to the target process under linux)
+var ErrPermissionDenied = errors.New("insufficient privileges to read process stats")
+
+// SetProcRoot overrides the path at which procfs is read from under linux
+// ("/proc" by default), e.g. when running in a chroot whose procfs is
+// mounted elsewhere, or to read a captured copy of /proc. An empty path
+// restores the default.
+// The setting is shared with the cgresolver and cgrouplimits packages. (see
+// cgresolver.SetProcRoot)
+func SetProcRoot(path string) {
+	cgresolver.SetProcRoot(path)
+}
+
 // RSS takes a pid and returns the RSS of that process (or an error)
 // This may return ErrUnimplementedPlatform on non-linux and non-darwin platforms.
+// On linux, it reads /proc/[pid]/statm, falling back to /proc/[pid]/status if
+// statm is unreadable.
 func RSS(pid int) (int64, error) {
 	return readProcessRSS(pid)
 }
@@ -22,6 +43,42 @@ type CPUTime struct {
 	Stime time.Duration
 }
 
+// String formats the CPUTime for logging, e.g. "utime=1.2s stime=300ms".
+func (c CPUTime) String() string {
+	return fmt.Sprintf("utime=%s stime=%s", c.Utime, c.Stime)
+}
+
+// cpuTimeJSON is the JSON representation of a CPUTime, with the durations
+// encoded as Go duration strings (e.g. "1.2s"), which round-trip exactly.
+type cpuTimeJSON struct {
+	Utime string `json:"utime"`
+	Stime string `json:"stime"`
+}
+
+// MarshalJSON implements json.Marshaler
+func (c CPUTime) MarshalJSON() ([]byte, error) {
+	return json.Marshal(cpuTimeJSON{Utime: c.Utime.String(), Stime: c.Stime.String()})
+}
+
+// UnmarshalJSON implements json.Unmarshaler, accepting the format emitted by
+// MarshalJSON.
+func (c *CPUTime) UnmarshalJSON(b []byte) error {
+	j := cpuTimeJSON{}
+	if err := json.Unmarshal(b, &j); err != nil {
+		return err
+	}
+	utime, utimeErr := time.ParseDuration(j.Utime)
+	if utimeErr != nil {
+		return fmt.Errorf("invalid utime: %w", utimeErr)
+	}
+	stime, stimeErr := time.ParseDuration(j.Stime)
+	if stimeErr != nil {
+		return fmt.Errorf("invalid stime: %w", stimeErr)
+	}
+	c.Utime, c.Stime = utime, stime
+	return nil
+}
+
 // Sub subtracts the operand from the receiver, returning a new CPUTime object.
 func (c *CPUTime) Sub(other *CPUTime) CPUTime {
 	return CPUTime{
@@ -38,6 +95,39 @@ func (c *CPUTime) Add(other *CPUTime) CPUTime {
 	}
 }
 
+// Total returns the total CPU time consumed (user + system).
+func (c *CPUTime) Total() time.Duration {
+	return c.Utime + c.Stime
+}
+
+// Utilization returns the average number of cores used between the prev
+// sample and the receiver, over a wall-clock interval of wall. (e.g. 0.5 if
+// half of a single core was used, 2.0 if two cores were fully used)
+// It returns 0 if wall is not positive.
+func (c *CPUTime) Utilization(prev *CPUTime, wall time.Duration) float64 {
+	if wall <= 0 {
+		return 0
+	}
+	delta := c.Sub(prev)
+	return float64(delta.Total()) / float64(wall)
+}
+
+// PageFaultStats contains the page-fault counts for a process.
+type PageFaultStats struct {
+	// Minor is the number of faults that didn't require loading a page
+	// from disk
+	Minor uint64
+	// Major is the number of faults that required loading a page from
+	// disk
+	Major uint64
+	// MinorWithChildren is Minor plus the minor faults of the process's
+	// waited-for children
+	MinorWithChildren uint64
+	// MajorWithChildren is Major plus the major faults of the process's
+	// waited-for children
+	MajorWithChildren uint64
+}
+
 // ProcessCPUTime returns either the cumulative CPUTime of the specified
 // process or an error.
 // This is a portable wrapper around platform-specific functions.
@@ -45,15 +135,45 @@ func ProcessCPUTime(pid int) (CPUTime, error) {
 	return readProcessCPUTime(pid)
 }
 
+// CPUTimeResolution returns the granularity of the CPUTime values returned by
+// ProcessCPUTime on this platform. CPU-time deltas over intervals that
+// aren't much longer than this (relative to the CPU used) will be dominated
+// by quantization error.
+// It returns 0 on platforms where ProcessCPUTime is unimplemented.
+func CPUTimeResolution() time.Duration {
+	return cpuTimeResolution()
+}
+
+// ProcessStartTime returns the time at which the specified process started.
+// Note: under linux, this is derived from the system boot time, which is only
+// reported with one-second granularity.
+// This may return ErrUnimplementedPlatform on non-linux platforms.
+func ProcessStartTime(pid int) (time.Time, error) {
+	return readProcessStartTime(pid)
+}
+
 // eq reports if the two CPUTimes are equal.
 func (c *CPUTime) eq(b *CPUTime) bool {
 	return c.Utime == b.Utime &&
 		c.Stime == b.Stime
 }
 
+// FDCount returns the number of open file descriptors of the process with
+// PID pid.
+// Under linux, counting another user's process's descriptors requires
+// ptrace-read access to it; if that's missing, the returned error wraps
+// ErrPermissionDenied.
+// This may return ErrUnimplementedPlatform on platforms other than linux and
+// darwin.
+func FDCount(pid int) (int, error) {
+	return readFDCount(pid)
+}
+
 // MaxRSS returns the maximum RSS (High Water Mark) of the process with PID
 // pid.
 // This is a portable wrapper around platform-specific functions.
+// On darwin, the peak is only available for the current process; for other
+// processes, the current RSS is returned.
 func MaxRSS(pid int) (int64, error) {
 	return readMaxRSS(pid)
 }
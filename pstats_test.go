@@ -0,0 +1,87 @@
+package procstats
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestCPUTimeTotal(t *testing.T) {
+	c := CPUTime{Utime: 3 * time.Second, Stime: 1500 * time.Millisecond}
+	if tot := c.Total(); tot != 4500*time.Millisecond {
+		t.Errorf("unexpected total: %s; expected 4.5s", tot)
+	}
+}
+
+func TestCPUTimeUtilization(t *testing.T) {
+	for _, tbl := range []struct {
+		name    string
+		prev    CPUTime
+		cur     CPUTime
+		wall    time.Duration
+		expUtil float64
+	}{
+		{
+			name:    "half_core",
+			prev:    CPUTime{Utime: time.Second, Stime: time.Second},
+			cur:     CPUTime{Utime: 5 * time.Second, Stime: 2 * time.Second},
+			wall:    10 * time.Second,
+			expUtil: 0.5,
+		},
+		{
+			name:    "two_cores",
+			prev:    CPUTime{},
+			cur:     CPUTime{Utime: 3 * time.Second, Stime: time.Second},
+			wall:    2 * time.Second,
+			expUtil: 2,
+		},
+		{
+			name:    "idle",
+			prev:    CPUTime{Utime: time.Second},
+			cur:     CPUTime{Utime: time.Second},
+			wall:    time.Second,
+			expUtil: 0,
+		},
+		{
+			name:    "zero_wall",
+			prev:    CPUTime{},
+			cur:     CPUTime{Utime: time.Second},
+			wall:    0,
+			expUtil: 0,
+		},
+	} {
+		t.Run(tbl.name, func(t *testing.T) {
+			if u := tbl.cur.Utilization(&tbl.prev, tbl.wall); u != tbl.expUtil {
+				t.Errorf("unexpected utilization: %g; expected %g", u, tbl.expUtil)
+			}
+		})
+	}
+}
+
+func TestCPUTimeString(t *testing.T) {
+	c := CPUTime{Utime: 1200 * time.Millisecond, Stime: 300 * time.Millisecond}
+	if s := c.String(); s != "utime=1.2s stime=300ms" {
+		t.Errorf("unexpected string: %q", s)
+	}
+}
+
+func TestCPUTimeJSONRoundTrip(t *testing.T) {
+	c := CPUTime{Utime: 1200*time.Millisecond + 7, Stime: 300 * time.Millisecond}
+	b, err := json.Marshal(c)
+	if err != nil {
+		t.Fatalf("failed to marshal: %s", err)
+	}
+	if exp := `{"utime":"1.200000007s","stime":"300ms"}`; string(b) != exp {
+		t.Errorf("unexpected JSON: %s; expected %s", b, exp)
+	}
+	out := CPUTime{}
+	if err := json.Unmarshal(b, &out); err != nil {
+		t.Fatalf("failed to unmarshal: %s", err)
+	}
+	if out != c {
+		t.Errorf("round-trip mismatch: %+v; expected %+v", out, c)
+	}
+	if err := json.Unmarshal([]byte(`{"utime":"12","stime":"1s"}`), &out); err == nil {
+		t.Error("expected error for duration without units")
+	}
+}